@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -26,15 +27,43 @@ import (
 
 // App struct
 type App struct {
-	ctx                  context.Context
-	ollamaCmd            *exec.Cmd
-	ollamaPath           string
-	logger               *logWriter
-	environmentVariables map[string]interface{}
-	websocketConnections map[string]*websocket.Conn
-	websocketMutex       sync.Mutex
-	pullProcesses        map[string]*exec.Cmd // 保存正在运行的拉取进程
-	pullProcessesMutex   sync.Mutex           // 拉取进程互斥锁
+	ctx                    context.Context
+	ollamaCmd              *exec.Cmd
+	ollamaPath             string
+	logger                 *logWriter
+	environmentVariables   map[string]interface{}
+	websocketConnections   map[string]*websocket.Conn
+	websocketMutex         sync.Mutex
+	pullJobs               []*PullJob                    // 拉取队列，按排队顺序排列
+	pullCancels            map[string]context.CancelFunc // 正在运行的拉取任务的取消函数，按任务ID索引
+	pullProcessesMutex     sync.Mutex                    // 拉取队列互斥锁
+	startTime              time.Time                     // 应用启动时间
+	modelRouting           ModelRoutingConfig            // 网关模型别名和路由规则
+	modelRoutingMutex      sync.RWMutex
+	responseCache          *responseCache // 网关响应缓存
+	responseCacheOnce      sync.Once
+	httpServer             *http.Server // 网关HTTP服务器
+	httpServerMutex        sync.Mutex
+	pullProxy              *pullProxy     // 模型下载限速代理
+	activeGenerations      map[string]int // 正在进行的生成请求数，按模型索引
+	activeGenerationsMutex sync.Mutex
+	cliProgress            func(event map[string]interface{}) // 命令行模式下接收进度事件
+	modelUpdates           modelUpdateChecker                 // 模型更新检查结果
+	modelKeepAlive         map[string]string                  // 各模型的默认 keep_alive
+	modelKeepAliveMutex    sync.RWMutex
+	runningModelsSignature string // 上次发送 running_models 事件时的已加载模型
+	runningModelsMutex     sync.Mutex
+	modelMetadata          map[string]*ModelMetadata // 模型收藏、备注和标签，按 digest 索引，首次使用时加载
+	modelMetadataMutex     sync.Mutex
+	modelCatalog           *ModelCatalog // 内置模型目录与配置目录中覆盖文件合并的结果
+	modelCatalogModTime    time.Time     // 覆盖文件的修改时间，变化时重新加载
+	modelCatalogError      string        // 覆盖文件无效时的错误
+	modelCatalogMutex      sync.Mutex
+	chatSessions           map[string]*ChatSession // 聊天会话列表，按 ID 索引，首次使用时加载
+	chatSessionsMutex      sync.Mutex
+	chatSearchIndex        *chatSearchIndex                // 聊天记录全文索引，首次搜索时建立
+	openAIModelMetadata    map[string]*OllamaModelMetadata // /v1/models 的模型扩展信息，按 digest 缓存，digest 不变时不再请求 /api/show
+	openAIModelMutex       sync.Mutex
 }
 
 // 内存地址正则表达式
@@ -298,13 +327,10 @@ const (
 	OllamaAPIBaseURL = "http://127.0.0.1:11434"
 )
 
-// WebSocket升级器
+// WebSocket升级器，来源检查在 initHTTPServer 中按网关的来源白名单设置
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // 允许所有来源的WebSocket连接
-	},
 }
 
 // ModelInfo 模型信息
@@ -315,6 +341,14 @@ type ModelInfo struct {
 	Digest   string                 `json:"digest"`
 	Details  map[string]interface{} `json:"details,omitempty"`
 	Modified string                 `json:"modified_at"`
+	// 更新检查结果，仓库中的标签指向了新版本时 UpdateAvailable 为 true
+	UpdateAvailable bool   `json:"update_available,omitempty"`
+	UpdateCheckedAt string `json:"update_checked_at,omitempty"`
+	// 应用保存的收藏、备注、标签和默认聊天参数
+	Favorite    bool                   `json:"favorite,omitempty"`
+	Notes       string                 `json:"notes,omitempty"`
+	Labels      []string               `json:"labels,omitempty"`
+	ChatOptions map[string]interface{} `json:"chat_options,omitempty"`
 }
 
 // ChatMessage 聊天消息
@@ -325,10 +359,11 @@ type ChatMessage struct {
 
 // ChatRequest 聊天请求
 type ChatRequest struct {
-	Model    string        `json:"model"`
-	Messages []ChatMessage `json:"messages"`
-	Stream   bool          `json:"stream"`
-	Options  interface{}   `json:"options,omitempty"`
+	Model     string        `json:"model"`
+	Messages  []ChatMessage `json:"messages"`
+	Stream    bool          `json:"stream"`
+	Options   interface{}   `json:"options,omitempty"`
+	KeepAlive interface{}   `json:"keep_alive,omitempty"`
 }
 
 // ChatResponse 聊天响应
@@ -344,7 +379,10 @@ type ChatResponse struct {
 func NewApp() *App {
 	return &App{
 		websocketConnections: make(map[string]*websocket.Conn),
-		pullProcesses:        make(map[string]*exec.Cmd),
+		pullCancels:          make(map[string]context.CancelFunc),
+		activeGenerations:    make(map[string]int),
+		modelKeepAlive:       make(map[string]string),
+		startTime:            time.Now(),
 	}
 }
 
@@ -400,20 +438,7 @@ func (a *App) startup(ctx context.Context) {
 	log.Print(startupMsg)
 
 	// 初始化环境变量存储
-	a.environmentVariables = make(map[string]interface{})
-	// 设置默认值
-	a.environmentVariables["OLLAMA_MODEL_SOURCE"] = "modelscope"
-	a.environmentVariables["OLLAMA_NUM_CTX"] = 2048
-	a.environmentVariables["OLLAMA_INTEL_GPU"] = true
-	a.environmentVariables["OLLAMA_DEBUG"] = false
-	a.environmentVariables["ONEAPI_DEVICE_SELECTOR"] = ""
-	// 允许跨域访问和外部访问
-	a.environmentVariables["OLLAMA_ORIGINS"] = "*"
-	a.environmentVariables["OLLAMA_HOST"] = "0.0.0.0:11434"
-	// OpenAI兼容API默认值
-	a.environmentVariables["OLLAMA_OPENAI_COMPATIBLE"] = true
-	a.environmentVariables["OLLAMA_OPENAI_PORT"] = 8080
-	a.environmentVariables["OLLAMA_OPENAI_API_KEY"] = ""
+	a.initDefaultConfig()
 
 	log.Println("startup: 开始初始化")
 
@@ -430,6 +455,10 @@ func (a *App) startup(ctx context.Context) {
 	log.Println("startup: 初始化HTTP服务器")
 	a.initHTTPServer()
 
+	// 启动下载限速代理，需要在 Ollama 服务之前启动
+	log.Println("startup: 启动下载限速代理")
+	a.startPullProxy()
+
 	// 启动 Ollama 服务（同步执行以便调试）
 	log.Println("startup: 启动 Ollama 服务")
 	if err := a.startOllamaService(); err != nil {
@@ -437,11 +466,70 @@ func (a *App) startup(ctx context.Context) {
 	} else {
 		log.Println("startup: Ollama 服务启动成功")
 	}
+
+	// 恢复上次未完成的拉取任务
+	log.Println("startup: 恢复拉取队列")
+	a.loadPullQueue()
+	a.startPullScheduler()
+	a.startModelUpdateChecker()
+	a.startRunningModelsWatcher()
 	log.Println("startup: 初始化完成")
 }
 
+// initDefaultConfig 初始化环境变量存储并设置默认值
+func (a *App) initDefaultConfig() {
+	a.environmentVariables = make(map[string]interface{})
+	// 设置默认值
+	a.environmentVariables["OLLAMA_MODEL_SOURCE"] = "modelscope"
+	a.environmentVariables["OLLAMA_NUM_CTX"] = 2048
+	a.environmentVariables["OLLAMA_INTEL_GPU"] = true
+	a.environmentVariables["OLLAMA_DEBUG"] = false
+	a.environmentVariables["ONEAPI_DEVICE_SELECTOR"] = ""
+	// 允许跨域访问和外部访问
+	a.environmentVariables["OLLAMA_ORIGINS"] = "*"
+	a.environmentVariables["OLLAMA_HOST"] = "0.0.0.0:11434"
+	// OpenAI兼容API默认值
+	a.environmentVariables["OLLAMA_OPENAI_COMPATIBLE"] = true
+	a.environmentVariables["OLLAMA_OPENAI_PORT"] = 8080
+	a.environmentVariables["OLLAMA_OPENAI_API_KEY"] = ""
+	// 网关监听地址、来源白名单和TLS默认值
+	a.environmentVariables["OLLAMA_GATEWAY_HOST"] = "0.0.0.0"
+	a.environmentVariables["OLLAMA_GATEWAY_PORT"] = 11435
+	a.environmentVariables["OLLAMA_GATEWAY_ALLOWED_ORIGINS"] = "*"
+	a.environmentVariables["OLLAMA_GATEWAY_TLS"] = false
+	a.environmentVariables["OLLAMA_GATEWAY_TLS_CERT"] = ""
+	a.environmentVariables["OLLAMA_GATEWAY_TLS_KEY"] = ""
+	// 网关响应缓存默认值（仅缓存确定性请求）
+	a.environmentVariables["OLLAMA_GATEWAY_CACHE_ENABLED"] = false
+	a.environmentVariables["OLLAMA_GATEWAY_CACHE_TTL"] = 86400
+	a.environmentVariables["OLLAMA_GATEWAY_CACHE_MAX_ENTRIES"] = 1000
+	// 模型拉取队列同时下载的任务数
+	a.environmentVariables["OLLAMA_PULL_CONCURRENCY"] = 2
+	// 下载带宽限制（如 10MB/s，空表示不限速）和允许下载的时段（如 19:00-07:00，空表示不限时段）
+	a.environmentVariables["OLLAMA_PULL_BANDWIDTH_LIMIT"] = ""
+	a.environmentVariables["OLLAMA_PULL_SCHEDULE"] = ""
+	// 检查模型更新使用的仓库地址（可以指向本地仓库镜像）和自动检查间隔（小时，0 表示不自动检查）
+	a.environmentVariables["OLLAMA_REGISTRY_URL"] = defaultRegistryURL
+	a.environmentVariables["OLLAMA_MODEL_UPDATE_CHECK_HOURS"] = defaultModelUpdateCheckHours
+	// 估算模型能否运行时使用的可用显存（如 16GB），空表示自动检测
+	a.environmentVariables["OLLAMA_GPU_MEMORY"] = ""
+	// 在线模型目录来源（ollama、modelscope 或 file，空表示跟随模型下载源）、模型库地址（可以指向本地镜像）和本地目录文件
+	a.environmentVariables["OLLAMA_CATALOG_SOURCE"] = ""
+	a.environmentVariables["OLLAMA_LIBRARY_URL"] = defaultLibraryURL
+	a.environmentVariables["OLLAMA_MODELSCOPE_URL"] = defaultModelScopeURL
+	a.environmentVariables["OLLAMA_CATALOG_FILE"] = ""
+	// 在线模型目录缓存的有效期（小时，0 表示每次都重新获取，离线时仍会使用缓存）
+	a.environmentVariables["OLLAMA_CATALOG_CACHE_TTL_HOURS"] = defaultCatalogCacheTTLHours
+}
+
 // shutdown is called when the app closes
 func (a *App) shutdown(ctx context.Context) {
+	// 停止网关HTTP服务器
+	a.stopGatewayServer()
+
+	// 中断正在进行的下载，下次启动时恢复
+	a.stopPullQueue()
+
 	// 停止 Ollama 服务
 	if a.ollamaCmd != nil && a.ollamaCmd.Process != nil {
 		a.ollamaCmd.Process.Kill()
@@ -622,28 +710,42 @@ func (a *App) getCPUInfo() string {
 
 // ListModels 获取本地模型列表
 func (a *App) ListModels() []ModelInfo {
+	models, err := a.fetchLocalModels()
+	if err != nil {
+		log.Printf("ListModels: %v", err)
+		// 如果失败，返回模拟数据
+		return a.getMockModels()
+	}
+
+	if len(models) == 0 {
+		log.Printf("ListModels: 模型列表为空，返回模拟数据")
+		return a.getMockModels()
+	}
+
+	a.applyModelUpdateStatus(models)
+	a.applyModelMetadata(models)
+	log.Printf("ListModels: 返回 %d 个模型", len(models))
+	return models
+}
+
+// fetchLocalModels 通过 /api/tags 获取本地模型列表，失败时返回错误而不是模拟数据
+func (a *App) fetchLocalModels() ([]ModelInfo, error) {
 	// 尝试通过 HTTP API 获取模型列表
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Get("http://127.0.0.1:11434/api/tags")
 	if err != nil {
-		log.Printf("ListModels: HTTP 请求失败: %v", err)
-		// 如果失败，返回模拟数据
-		return a.getMockModels()
+		return nil, fmt.Errorf("HTTP 请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("ListModels: HTTP 状态码错误: %d", resp.StatusCode)
-		// 如果失败，返回模拟数据
-		return a.getMockModels()
+		return nil, fmt.Errorf("HTTP 状态码错误: %d", resp.StatusCode)
 	}
 
 	// 解析结果
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("ListModels: JSON 解析失败: %v", err)
-		// 如果失败，返回模拟数据
-		return a.getMockModels()
+		return nil, fmt.Errorf("JSON 解析失败: %v", err)
 	}
 
 	log.Printf("ListModels: 原始结果: %+v", result)
@@ -677,13 +779,7 @@ func (a *App) ListModels() []ModelInfo {
 		log.Printf("ListModels: 未找到 'models' 字段或格式不正确")
 	}
 
-	if len(models) == 0 {
-		log.Printf("ListModels: 模型列表为空，返回模拟数据")
-		return a.getMockModels()
-	}
-
-	log.Printf("ListModels: 返回 %d 个模型", len(models))
-	return models
+	return models, nil
 }
 
 // getMockModels 返回模拟模型数据
@@ -724,49 +820,75 @@ func getString(m map[string]interface{}, key string) string {
 	return ""
 }
 
-// resolveModelName 解析模型名称，支持使用digest ID或模型名称
+// resolveModelName 解析模型名称，支持别名、路由规则、digest ID或模型名称
 func (a *App) resolveModelName(modelID string) string {
+	routing := a.getModelRouting()
+
+	// 别名和路由规则优先
+	if target, ok := routing.route(modelID); ok {
+		log.Printf("resolveModelName: 路由 %s -> %s", modelID, target)
+		modelID = target
+	}
+
 	// 如果模型ID包含冒号，说明是完整的模型名称
-	if strings.Contains(modelID, ":") {
+	if strings.Contains(modelID, ":") && routing.DefaultModel == "" {
 		return modelID
 	}
-	
+
 	// 获取本地模型列表
-	models := a.ListModels()
-	
+	if resolved, ok := matchLocalModel(a.ListModels(), modelID); ok {
+		return resolved
+	}
+
+	// 请求的模型不存在时使用默认模型
+	if routing.DefaultModel != "" {
+		log.Printf("resolveModelName: 模型 %s 不存在，使用默认模型 %s", modelID, routing.DefaultModel)
+		return routing.DefaultModel
+	}
+
+	// 如果都没匹配到，返回原始ID（让Ollama处理错误）
+	return modelID
+}
+
+// matchLocalModel 在本地模型列表中按名称、不带tag的名称或digest查找模型
+func matchLocalModel(models []ModelInfo, modelID string) (string, bool) {
 	// 首先尝试精确匹配模型名称
 	for _, model := range models {
 		if model.Name == modelID {
-			return modelID
+			return modelID, true
 		}
 	}
-	
+
+	// 完整的模型名称没有精确匹配时不再做模糊匹配
+	if strings.Contains(modelID, ":") {
+		return "", false
+	}
+
 	// 尝试匹配模型名称（不带tag）
 	for _, model := range models {
 		parts := strings.Split(model.Name, ":")
 		if len(parts) > 0 && parts[0] == modelID {
-			return model.Name
+			return model.Name, true
 		}
 	}
-	
+
 	// 尝试匹配digest（支持短digest）
 	for _, model := range models {
 		if model.Digest != "" {
 			// 完整digest匹配
 			if model.Digest == modelID {
-				return model.Name
+				return model.Name, true
 			}
 			// 短digest匹配（前12位）
 			if len(modelID) <= 12 && len(model.Digest) >= 12 {
 				if model.Digest[:12] == modelID {
-					return model.Name
+					return model.Name, true
 				}
 			}
 		}
 	}
-	
-	// 如果都没匹配到，返回原始ID（让Ollama处理错误）
-	return modelID
+
+	return "", false
 }
 
 // getInt64 从 map 中获取 int64 值
@@ -817,19 +939,19 @@ func getSizeString(m map[string]interface{}, key string) string {
 	return ""
 }
 
-// PullModel 拉取模型
+// PullModel 将模型加入拉取队列
 func (a *App) PullModel(name string) map[string]interface{} {
 	// 处理模型名称，确保包含tag
 	modelName := a.normalizeModelName(name)
 	log.Printf("PullModel: 原始名称=%s, 规范化名称=%s", name, modelName)
 
-	// 在 goroutine 中拉取模型以提供进度更新
-	go a.pullModelWithProgress(modelName)
+	job := a.enqueuePullJob(modelName)
 
 	return map[string]interface{}{
-		"message": fmt.Sprintf("开始拉取模型: %s", modelName),
+		"message": fmt.Sprintf("模型已加入拉取队列: %s", modelName),
 		"model":   modelName,
-		"status":  "started",
+		"job_id":  job.ID,
+		"status":  string(job.State),
 	}
 }
 
@@ -842,88 +964,48 @@ func (a *App) normalizeModelName(name string) string {
 		return name
 	}
 
-	// 常见的模型名称映射（纠正拼写错误）
-	modelNameCorrections := map[string]string{
-		"llama4":         "llama3.3",
-		"llama3.3":       "llama3.3",
-		"llama3.2":       "llama3.2",
-		"llama3.1":       "llama3.1",
-		"llama3":         "llama3",
-		"llama2":         "llama2",
-		"gemma3":         "gemma3",
-		"gemma2":         "gemma2",
-		"gemma":          "gemma",
-		"mistral":        "mistral",
-		"mixtral":        "mixtral",
-		"qwen3.5":        "qwen3.5",
-		"qwen3":          "qwen3",
-		"qwen2.5":        "qwen2.5",
-		"qwen2":          "qwen2",
-		"phi4":           "phi4",
-		"phi3":           "phi3",
-		"phi-3":          "phi3",
-		"codellama":      "codellama",
-		"code-llama":     "codellama",
-		"deepseek-coder": "deepseek-coder",
-		"deepseek":       "deepseek-r1",
-		"deepseek-r1":    "deepseek-r1",
-		"deepseek-v3":    "deepseek-v3",
-		"llava":          "llava",
-		"starling-lm":    "starling-lm",
-		"command-r":      "command-r",
-		"cohere":         "command-r",
-	}
-
-	// 检查是否需要纠正模型名称
+	// 纠正常见的模型名称错误，纠正表来自模型目录
 	lowerName := strings.ToLower(name)
-	if corrected, ok := modelNameCorrections[lowerName]; ok {
+	if corrected, ok := a.loadModelCatalog().NameCorrections[lowerName]; ok {
 		name = corrected
 	}
 
-	// 对于常见的模型，添加默认tag
-	defaultTags := map[string]string{
-		"llama3.3":       "latest",
-		"llama3.2":       "latest",
-		"llama3.1":       "latest",
-		"llama3":         "latest",
-		"llama2":         "latest",
-		"gemma3":         "latest",
-		"gemma2":         "latest",
-		"gemma":          "latest",
-		"mistral":        "latest",
-		"mixtral":        "latest",
-		"qwen3.5":        "latest",
-		"qwen3":          "latest",
-		"qwen2.5":        "latest",
-		"qwen2":          "latest",
-		"phi4":           "latest",
-		"phi3":           "latest",
-		"codellama":      "latest",
-		"deepseek-coder": "latest",
-		"deepseek-r1":    "latest",
-		"deepseek-v3":    "latest",
-		"llava":          "latest",
-		"starling-lm":    "latest",
-		"command-r":      "latest",
-	}
-
-	// 检查是否有预定义的默认tag
-	lowerName = strings.ToLower(name)
-	if tag, ok := defaultTags[lowerName]; ok {
-		return fmt.Sprintf("%s:%s", name, tag)
-	}
-
 	// 默认添加:latest tag
 	return fmt.Sprintf("%s:latest", name)
 }
 
-// pullModelWithProgress 拉取模型并发送进度更新
-func (a *App) pullModelWithProgress(modelName string) {
+// pullModelWithProgress 拉取模型并发送进度更新，由拉取队列调用
+// 优先通过 Ollama HTTP API 流式拉取，服务不可达时回退到命令行
+// 上下文被取消（暂停或取消）时返回 ctx.Err()，不发送错误事件
+func (a *App) pullModelWithProgress(ctx context.Context, modelName string) error {
 	log.Printf("开始拉取模型: %s", modelName)
 
 	// 发送开始事件
 	a.sendPullProgressEvent(modelName, "started", 0, "开始拉取模型")
 
+	err := a.pullModelViaAPI(ctx, modelName)
+	if err == nil {
+		return nil
+	}
+
+	// 暂停或取消由拉取队列发送事件
+	if ctx.Err() != nil {
+		log.Printf("模型拉取已中断: %s", modelName)
+		return ctx.Err()
+	}
+
+	if _, ok := err.(*pullUnreachableError); ok {
+		log.Printf("通过 HTTP API 拉取失败，回退到命令行: %v", err)
+		return a.pullModelViaCLI(ctx, modelName)
+	}
+
+	log.Printf("拉取模型失败: %v", err)
+	a.sendPullProgressEvent(modelName, "error", 0, err.Error())
+	return err
+}
+
+// pullModelViaCLI 通过 ollama pull 命令拉取模型，解析命令输出作为进度
+func (a *App) pullModelViaCLI(ctx context.Context, modelName string) error {
 	// 构建环境变量
 	env := os.Environ()
 
@@ -962,7 +1044,8 @@ func (a *App) pullModelWithProgress(modelName string) {
 	// 记录关键环境变量用于调试
 	log.Printf("模型拉取: %s, 环境变量数量: %d", modelName, len(env))
 
-	cmd := exec.Command(a.ollamaPath, "pull", modelName)
+	// 取消上下文时终止拉取进程
+	cmd := exec.CommandContext(ctx, a.ollamaPath, "pull", modelName)
 	cmd.Env = env
 
 	// 隐藏命令窗口
@@ -972,24 +1055,12 @@ func (a *App) pullModelWithProgress(modelName string) {
 		}
 	}
 
-	// 保存进程到map中，以便后续可以取消
-	a.pullProcessesMutex.Lock()
-	a.pullProcesses[modelName] = cmd
-	a.pullProcessesMutex.Unlock()
-
-	// 确保在函数结束时清理进程
-	defer func() {
-		a.pullProcessesMutex.Lock()
-		delete(a.pullProcesses, modelName)
-		a.pullProcessesMutex.Unlock()
-	}()
-
 	// 获取标准输出管道
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		log.Printf("创建标准输出管道失败: %v", err)
 		a.sendPullProgressEvent(modelName, "error", 0, fmt.Sprintf("创建管道失败: %v", err))
-		return
+		return fmt.Errorf("创建管道失败: %v", err)
 	}
 
 	// 获取标准错误管道
@@ -997,14 +1068,14 @@ func (a *App) pullModelWithProgress(modelName string) {
 	if err != nil {
 		log.Printf("创建标准错误管道失败: %v", err)
 		a.sendPullProgressEvent(modelName, "error", 0, fmt.Sprintf("创建管道失败: %v", err))
-		return
+		return fmt.Errorf("创建管道失败: %v", err)
 	}
 
 	// 启动命令
 	if err := cmd.Start(); err != nil {
 		log.Printf("启动命令失败: %v", err)
 		a.sendPullProgressEvent(modelName, "error", 0, fmt.Sprintf("启动命令失败: %v", err))
-		return
+		return fmt.Errorf("启动命令失败: %v", err)
 	}
 
 	// 使用通道来跟踪错误状态
@@ -1017,32 +1088,39 @@ func (a *App) pullModelWithProgress(modelName string) {
 	// 等待命令完成
 	err = cmd.Wait()
 
-	// 检查是否有错误发生
+	// 暂停或取消由拉取队列发送事件
+	if ctx.Err() != nil {
+		log.Printf("模型拉取已中断: %s", modelName)
+		return ctx.Err()
+	}
+
+	// 检查是否有错误发生（readOutputLines 已发送错误事件）
 	select {
 	case <-errorOccurred:
 		log.Printf("模型拉取过程中发生错误: %s", modelName)
-		return
+		return fmt.Errorf("模型拉取过程中发生错误")
 	default:
 	}
 
 	if err != nil {
 		log.Printf("拉取模型失败: %v", err)
-		// 检查是否是被取消的
-		if strings.Contains(err.Error(), "signal: killed") || strings.Contains(err.Error(), "signal: terminated") {
-			a.sendPullProgressEvent(modelName, "cancelled", 0, "模型拉取已取消")
-		} else {
-			a.sendPullProgressEvent(modelName, "error", 0, fmt.Sprintf("拉取模型失败: %v", err))
-		}
-		return
+		a.sendPullProgressEvent(modelName, "error", 0, fmt.Sprintf("拉取模型失败: %v", err))
+		return fmt.Errorf("拉取模型失败: %v", err)
 	}
 
 	// 发送完成事件
 	a.sendPullProgressEvent(modelName, "completed", 100, "模型拉取完成")
 	log.Printf("模型拉取完成: %s", modelName)
+	return nil
 }
 
 // sendPullProgressEvent 发送模型拉取进度事件
 func (a *App) sendPullProgressEvent(modelName, status string, progress float64, message string) {
+	a.sendPullProgressDetail(modelName, status, progress, message, nil)
+}
+
+// sendPullProgressDetail 发送带有字节级详情（分层进度、速度、剩余时间等）的拉取进度事件
+func (a *App) sendPullProgressDetail(modelName, status string, progress float64, message string, details map[string]interface{}) {
 	eventData := map[string]interface{}{
 		"model":    modelName,
 		"status":   status,
@@ -1050,6 +1128,12 @@ func (a *App) sendPullProgressEvent(modelName, status string, progress float64,
 		"message":  message,
 		"time":     time.Now().Format("2006-01-02 15:04:05"),
 	}
+	for key, value := range details {
+		eventData[key] = value
+	}
+
+	// 记录队列任务的最新进度
+	a.updatePullJobProgress(modelName, progress)
 
 	// 发送事件到前端
 	if a.ctx != nil {
@@ -1239,79 +1323,38 @@ func (a *App) parsePullProgress(line string) (float64, string) {
 
 // DeleteModel 删除模型
 func (a *App) DeleteModel(name string) map[string]interface{} {
-	// 通过命令行删除模型
-	go func() {
-		_, err := a.runOllamaCommand("rm", name)
-		if err != nil {
-			log.Printf("删除模型失败: %v", err)
-		}
-	}()
-
-	return map[string]interface{}{
-		"message": fmt.Sprintf("模型已删除: %s", name),
-	}
+	// 通过 /api/delete 同步删除，模型已加载到内存中时拒绝删除
+	return a.deleteModelResult(name, false)
 }
 
-// CancelPull 取消模型拉取
+// CancelPull 取消模型拉取，参数可以是模型名称或队列任务ID
 func (a *App) CancelPull(modelName string) map[string]interface{} {
 	log.Printf("CancelPull: 尝试取消模型拉取: %s", modelName)
 
-	// 规范化模型名称
-	normalizedName := a.normalizeModelName(modelName)
-
-	// 查找正在运行的拉取进程
-	a.pullProcessesMutex.Lock()
-	defer a.pullProcessesMutex.Unlock()
-
-	cmd, exists := a.pullProcesses[normalizedName]
-	if !exists {
-		log.Printf("CancelPull: 未找到正在运行的拉取进程: %s", normalizedName)
+	job, err := a.transitionPullJob(modelName, PullJobCancelled, "用户取消了模型拉取")
+	if err != nil {
+		log.Printf("CancelPull: %v", err)
 		return map[string]interface{}{
 			"success": false,
-			"message": "未找到正在运行的拉取进程",
+			"message": err.Error(),
 		}
 	}
 
-	// 终止进程
-	if cmd != nil && cmd.Process != nil {
-		log.Printf("CancelPull: 终止拉取进程: %s", normalizedName)
-		if err := cmd.Process.Kill(); err != nil {
-			log.Printf("CancelPull: 终止进程失败: %v", err)
-			return map[string]interface{}{
-				"success": false,
-				"message": fmt.Sprintf("终止进程失败: %v", err),
-			}
-		}
-
-		// 发送取消事件
-		a.sendPullProgressEvent(normalizedName, "cancelled", 0, "用户取消了模型拉取")
-
-		log.Printf("CancelPull: 成功取消模型拉取: %s", normalizedName)
-		return map[string]interface{}{
-			"success": true,
-			"message": "模型拉取已取消",
-		}
-	}
+	// 发送取消事件
+	a.sendPullProgressEvent(job.Model, "cancelled", 0, "用户取消了模型拉取")
 
+	log.Printf("CancelPull: 成功取消模型拉取: %s", job.Model)
 	return map[string]interface{}{
-		"success": false,
-		"message": "进程不存在",
+		"success": true,
+		"message": "模型拉取已取消",
 	}
 }
 
 // ShowModel 显示模型信息
 func (a *App) ShowModel(name string) map[string]interface{} {
-	// 使用 HTTP API 获取模型信息
-	client := &http.Client{Timeout: 10 * time.Second}
-	
-	// 使用 Ollama HTTP API 获取模型详情
-	url := "http://127.0.0.1:11434/api/show"
-	reqBody := map[string]string{"name": name}
-	jsonBody, _ := json.Marshal(reqBody)
-	
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	result, err := a.fetchModelShow(name)
 	if err != nil {
-		log.Printf("ShowModel: HTTP请求失败: %v", err)
+		log.Printf("ShowModel: %v", err)
 		return map[string]interface{}{
 			"license":   "...",
 			"modelfile": "# Modelfile generated by ollama...",
@@ -1321,28 +1364,81 @@ func (a *App) ShowModel(name string) map[string]interface{} {
 			"template": "{{ if .System }}...",
 		}
 	}
+
+	return result
+}
+
+// fetchModelShow 通过 /api/show 获取模型详情，模型不存在时返回 errModelNotFound
+func (a *App) fetchModelShow(name string) (map[string]interface{}, error) {
+	// 使用 HTTP API 获取模型信息
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	// 使用 Ollama HTTP API 获取模型详情
+	url := "http://127.0.0.1:11434/api/show"
+	reqBody := map[string]string{"name": name}
+	jsonBody, _ := json.Marshal(reqBody)
+
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP请求失败: %v", err)
+	}
 	defer resp.Body.Close()
-	
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errModelNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP 状态码错误: %d - %s", resp.StatusCode, string(body))
+	}
+
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("ShowModel: JSON解析失败: %v", err)
-		return map[string]interface{}{
-			"license":   "...",
-			"modelfile": "# Modelfile generated by ollama...",
-			"parameters": map[string]interface{}{
-				"num_ctx": 2048,
-			},
-			"template": "{{ if .System }}...",
-		}
+		return nil, fmt.Errorf("JSON解析失败: %v", err)
 	}
-	
-	return result
+
+	return result, nil
 }
 
 // ChatCompletion 聊天完成
 func (a *App) ChatCompletion(req ChatRequest) ChatResponse {
+	// 模型的默认聊天参数，请求中指定的参数优先，与 ChatStream 一致
+	if requestOptions, ok := req.Options.(map[string]interface{}); ok || req.Options == nil {
+		if options := a.mergeChatOptions(req.Model, requestOptions); options != nil {
+			req.Options = options
+		}
+	}
+	response, err := a.requestChatCompletion(req)
+	if err != nil {
+		log.Printf("ChatCompletion: 请求失败，返回模拟响应: %v", err)
+		// 如果失败，返回模拟响应
+		return mockChatResponse(req.Model)
+	}
+	return response
+}
+
+// mockChatResponse 返回服务不可用时的模拟响应
+func mockChatResponse(model string) ChatResponse {
+	return ChatResponse{
+		Model: model,
+		Message: ChatMessage{
+			Role:    "assistant",
+			Content: "这是来自 Ollama 英特尔优化版的模拟响应。在实际实现中，这里会连接到真实的 Ollama API。",
+		},
+		Done: true,
+	}
+}
+
+// requestChatCompletion 调用 Ollama /api/chat 并汇总流式响应，失败时返回错误
+func (a *App) requestChatCompletion(req ChatRequest) (ChatResponse, error) {
 	// 确保设置 stream: true 以支持流式响应
 	req.Stream = true
+	defer a.trackGeneration(req.Model)()
+	if req.KeepAlive == nil {
+		if keepAlive := a.keepAliveFor(req.Model); keepAlive != "" {
+			req.KeepAlive = keepAliveRequestValue(keepAlive)
+		}
+	}
 
 	// 使用 HTTP API 而不是命令行工具
 	client := &http.Client{Timeout: 60 * time.Second}
@@ -1350,42 +1446,19 @@ func (a *App) ChatCompletion(req ChatRequest) ChatResponse {
 	// 构建请求体
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		// 如果失败，返回模拟响应
-		return ChatResponse{
-			Model: req.Model,
-			Message: ChatMessage{
-				Role:    "assistant",
-				Content: "这是来自 Ollama 英特尔优化版的模拟响应。在实际实现中，这里会连接到真实的 Ollama API。",
-			},
-			Done: true,
-		}
+		return ChatResponse{}, fmt.Errorf("构建请求失败: %v", err)
 	}
 
 	// 发送请求
 	resp, err := client.Post("http://127.0.0.1:11434/api/chat", "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
-		// 如果失败，返回模拟响应
-		return ChatResponse{
-			Model: req.Model,
-			Message: ChatMessage{
-				Role:    "assistant",
-				Content: "这是来自 Ollama 英特尔优化版的模拟响应。在实际实现中，这里会连接到真实的 Ollama API。",
-			},
-			Done: true,
-		}
+		return ChatResponse{}, fmt.Errorf("连接Ollama服务失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		// 如果失败，返回模拟响应
-		return ChatResponse{
-			Model: req.Model,
-			Message: ChatMessage{
-				Role:    "assistant",
-				Content: "这是来自 Ollama 英特尔优化版的模拟响应。在实际实现中，这里会连接到真实的 Ollama API。",
-			},
-			Done: true,
-		}
+		body, _ := io.ReadAll(resp.Body)
+		return ChatResponse{}, fmt.Errorf("Ollama返回错误: %d - %s", resp.StatusCode, string(body))
 	}
 
 	// 处理流式响应
@@ -1409,6 +1482,10 @@ func (a *App) ChatCompletion(req ChatRequest) ChatResponse {
 			continue
 		}
 
+		if chunk.Error != "" {
+			return ChatResponse{}, fmt.Errorf("Ollama返回错误: %s", chunk.Error)
+		}
+
 		// 累积内容
 		if chunk.Message.Content != "" {
 			fullContent.WriteString(chunk.Message.Content)
@@ -1441,26 +1518,24 @@ func (a *App) ChatCompletion(req ChatRequest) ChatResponse {
 	}
 
 	if err := scanner.Err(); err != nil {
-		// 如果失败，返回模拟响应
-		return ChatResponse{
-			Model: req.Model,
-			Message: ChatMessage{
-				Role:    "assistant",
-				Content: "这是来自 Ollama 英特尔优化版的模拟响应。在实际实现中，这里会连接到真实的 Ollama API。",
-			},
-			Done: true,
-		}
+		return ChatResponse{}, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	if !response.Done {
+		return ChatResponse{}, fmt.Errorf("响应未完成")
 	}
 
 	// 返回最终响应
-	return response
+	return response, nil
 }
 
 // ChatStreamRequest 聊天流式请求
 type ChatStreamRequest struct {
-	Model    string        `json:"model"`
-	Messages []ChatMessage `json:"messages"`
-	Stream   bool          `json:"stream"`
+	Model     string                 `json:"model"`
+	Messages  []ChatMessage          `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"` // 指定时，对话完成后将问题和回答保存到该会话
 }
 
 // ChatStreamResult 聊天流式结果
@@ -1476,16 +1551,23 @@ type ChatStreamResult struct {
 // 使用事件推送实现真正的流式传输
 func (a *App) ChatStream(req ChatStreamRequest) *ChatStreamResult {
 	log.Printf("ChatStream: 模型=%s, 消息数=%d", req.Model, len(req.Messages))
+	defer a.trackGeneration(req.Model)()
 
 	// 使用 HTTP API 进行聊天
 	client := &http.Client{Timeout: 180 * time.Second}
 
 	// 构建请求体
-	reqBody, err := json.Marshal(map[string]interface{}{
+	body := map[string]interface{}{
 		"model":    req.Model,
 		"messages": req.Messages,
 		"stream":   true,
-	})
+	}
+	// 模型的默认聊天参数，请求中指定的参数优先
+	if options := a.mergeChatOptions(req.Model, req.Options); options != nil {
+		body["options"] = options
+	}
+	a.applyKeepAlive(body, req.Model)
+	reqBody, err := json.Marshal(body)
 	if err != nil {
 		return &ChatStreamResult{
 			Error: fmt.Sprintf("构建请求失败: %v", err),
@@ -1591,6 +1673,12 @@ func (a *App) ChatStream(req ChatStreamRequest) *ChatStreamResult {
 	}
 
 	log.Printf("ChatStream完成: 内容长度=%d", fullContent.Len())
+	if req.SessionID != "" {
+		if modelName == "" {
+			modelName = req.Model
+		}
+		a.recordChatExchange(req.SessionID, req.Messages, fullContent.String(), modelName)
+	}
 	return &ChatStreamResult{
 		Content:   fullContent.String(),
 		Done:      true,
@@ -1659,8 +1747,8 @@ func (a *App) GetOllamaPath() map[string]interface{} {
 	}
 }
 
-// getConfigPath 获取配置文件路径
-func (a *App) getConfigPath() string {
+// getConfigDir 获取应用配置目录
+func (a *App) getConfigDir() string {
 	var configDir string
 
 	if runtime.GOOS == "windows" {
@@ -1672,7 +1760,32 @@ func (a *App) getConfigPath() string {
 	// 确保配置目录存在
 	os.MkdirAll(configDir, 0755)
 
-	return filepath.Join(configDir, "config.json")
+	return configDir
+}
+
+// getConfigPath 获取配置文件路径
+func (a *App) getConfigPath() string {
+	return filepath.Join(a.getConfigDir(), "config.json")
+}
+
+// getOllamaModelsDir 获取 Ollama 模型存储目录
+// 优先使用用户配置或系统环境中的 OLLAMA_MODELS，否则使用默认的 ~/.ollama/models
+func (a *App) getOllamaModelsDir() string {
+	if value, ok := a.environmentVariables["OLLAMA_MODELS"].(string); ok && value != "" {
+		return value
+	}
+	if value := os.Getenv("OLLAMA_MODELS"); value != "" {
+		return value
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		userHome = os.Getenv("HOME")
+		if runtime.GOOS == "windows" {
+			userHome = os.Getenv("USERPROFILE")
+		}
+	}
+	return filepath.Join(userHome, ".ollama", "models")
 }
 
 // loadConfig 从文件加载配置
@@ -1710,6 +1823,30 @@ func (a *App) loadConfig() {
 		a.ollamaPath = ollamaPath
 		log.Printf("loadConfig: Ollama路径已加载: %s\n", a.ollamaPath)
 	}
+
+	// 加载模型路由配置
+	if routing, ok := config["modelRouting"]; ok {
+		var routingConfig ModelRoutingConfig
+		if raw, err := json.Marshal(routing); err == nil && json.Unmarshal(raw, &routingConfig) == nil {
+			routingConfig.prepare()
+			a.modelRoutingMutex.Lock()
+			a.modelRouting = routingConfig
+			a.modelRoutingMutex.Unlock()
+			log.Printf("loadConfig: 模型路由配置已加载: %d 个别名, %d 条规则\n", len(routingConfig.Aliases), len(routingConfig.Rules))
+		}
+	}
+
+	// 加载各模型的默认 keep_alive
+	if keepAlive, ok := config["modelKeepAlive"].(map[string]interface{}); ok {
+		a.modelKeepAliveMutex.Lock()
+		a.modelKeepAlive = make(map[string]string, len(keepAlive))
+		for model, value := range keepAlive {
+			if s, ok := value.(string); ok && s != "" {
+				a.modelKeepAlive[model] = s
+			}
+		}
+		a.modelKeepAliveMutex.Unlock()
+	}
 }
 
 // saveConfig 保存配置到文件
@@ -1720,6 +1857,8 @@ func (a *App) saveConfig() {
 	config := map[string]interface{}{
 		"environmentVariables": a.environmentVariables,
 		"ollamaPath":           a.ollamaPath,
+		"modelRouting":         a.getModelRouting(),
+		"modelKeepAlive":       a.getModelKeepAlive(),
 		"lastSaved":            time.Now().Format(time.RFC3339),
 	}
 
@@ -1743,7 +1882,18 @@ func (a *App) saveConfig() {
 func (a *App) SaveEnvironmentVariables(variables map[string]interface{}) map[string]interface{} {
 	log.Printf("SaveEnvironmentVariables: 保存环境变量配置: %+v\n", variables)
 
+	if source, ok := variables["OLLAMA_CATALOG_SOURCE"].(string); ok {
+		if err := validateCatalogSource(source); err != nil {
+			log.Printf("SaveEnvironmentVariables: %v\n", err)
+			return map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+		}
+	}
+
 	// 保存环境变量
+	previousGatewayConfig := a.gatewayConfigSignature()
 	a.environmentVariables = variables
 
 	// 检查是否设置了OLLAMA_EXECUTABLE_PATH
@@ -1763,9 +1913,24 @@ func (a *App) SaveEnvironmentVariables(variables map[string]interface{}) map[str
 	// 保存配置到文件
 	a.saveConfig()
 
+	// 网关监听地址或TLS配置变化时重启网关
+	if a.gatewayConfigSignature() != previousGatewayConfig {
+		log.Println("SaveEnvironmentVariables: 网关配置已变化，重启网关")
+		a.startGatewayServer(a.newGatewayMux())
+	}
+
+	// 并发数、限速和下载时段可能已变化，重新调度拉取队列
+	a.applyPullBandwidthLimit()
+	if a.pullAllowedNow() {
+		a.schedulePullJobs()
+	} else {
+		a.suspendRunningPullJobs("不在下载时段内，等待下一时段")
+	}
+
 	log.Println("SaveEnvironmentVariables: 环境变量配置已保存")
 
 	return map[string]interface{}{
+		"success":   true,
 		"message":   "环境变量配置已保存",
 		"variables": variables,
 		"path":      a.ollamaPath, // 返回当前路径
@@ -1860,7 +2025,7 @@ func (a *App) GetOnlineModels(page int, limit int) map[string]interface{} {
 		end = total
 	}
 
-	paginatedModels := models[start:end]
+	paginatedModels := a.annotateModelFit(models[start:end])
 
 	return map[string]interface{}{
 		"models": paginatedModels,
@@ -1910,7 +2075,7 @@ func (a *App) SearchOnlineModels(query string, page int, limit int) map[string]i
 		end = total
 	}
 
-	paginatedModels := models[start:end]
+	paginatedModels := a.annotateModelFit(models[start:end])
 
 	return map[string]interface{}{
 		"models": paginatedModels,
@@ -1945,142 +2110,17 @@ func (a *App) fetchOnlineModelsFromOllama() ([]map[string]interface{}, error) {
 	return a.getBuiltinOnlineModels(), nil
 }
 
-// fetchOnlineModelsFromAPI 从 Ollama 官方库页面获取模型列表
-// 通过解析 ollama.com/library 和 ollama.com/search 页面获取完整的模型列表
+// fetchOnlineModelsFromAPI 从设置的目录来源（默认为 ollama.com 官方模型库）获取模型列表
+// 结果缓存在磁盘上，离线时使用缓存
 func (a *App) fetchOnlineModelsFromAPI() ([]map[string]interface{}, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-
-	// 从 ollama.com/library 页面获取模型列表
-	resp, err := client.Get("https://ollama.com/library")
+	provider := a.catalogProvider()
+	models, err := provider.List()
 	if err != nil {
-		log.Printf("从 Ollama library 页面获取模型失败: %v", err)
+		log.Printf("从 %s 获取模型失败: %v", provider.Name(), err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("页面返回状态码: %d", resp.StatusCode)
-	}
-
-	// 读取页面内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析HTML获取模型列表
-	models := a.parseOllamaLibraryPage(string(body))
-	log.Printf("从 Ollama library 页面解析到 %d 个模型", len(models))
-
-	if len(models) == 0 {
-		return nil, fmt.Errorf("未能从页面解析到任何模型")
-	}
-
-	return models, nil
-}
-
-// parseOllamaLibraryPage 解析 Ollama library 页面获取模型列表
-func (a *App) parseOllamaLibraryPage(htmlContent string) []map[string]interface{} {
-	var models []map[string]interface{}
-	seenModels := make(map[string]bool)
-
-	// 使用正则表达式提取模型信息
-	// 匹配模型名称 - 在 <h2> 标签内的模型名称
-	nameRegex := regexp.MustCompile(`<h2[^>]*>\s*([a-zA-Z0-9_.-]+)\s*</h2>`)
-	// 匹配描述 - 在 class="text-neutral-400" 的段落中
-	descRegex := regexp.MustCompile(`<p[^>]*class="[^"]*text-neutral-400[^"]*"[^>]*>([^<]+)</p>`)
-	// 匹配下载量
-	pullsRegex := regexp.MustCompile(`(\d+\.?\d*[KM]?)\s*Pulls`)
-	// 匹配标签数量
-	tagsRegex := regexp.MustCompile(`(\d+)\s*Tags`)
-	// 匹配参数大小
-	paramRegex := regexp.MustCompile(`(\d+(?:\.\d+)?[bm]?)`)
-
-	// 提取所有模型名称
-	nameMatches := nameRegex.FindAllStringSubmatch(htmlContent, -1)
-	descMatches := descRegex.FindAllStringSubmatch(htmlContent, -1)
-
-	// 提取下载量
-	pullsMatches := pullsRegex.FindAllStringSubmatch(htmlContent, -1)
-	// 提取标签数量
-	tagsMatches := tagsRegex.FindAllStringSubmatch(htmlContent, -1)
-
-	// 合并模型信息
-	maxLen := len(nameMatches)
-	if len(descMatches) > maxLen {
-		maxLen = len(descMatches)
-	}
-
-	for i := 0; i < maxLen && i < len(nameMatches); i++ {
-		name := strings.TrimSpace(nameMatches[i][1])
-		if name == "" || seenModels[name] {
-			continue
-		}
-		seenModels[name] = true
-
-		description := ""
-		if i < len(descMatches) {
-			description = strings.TrimSpace(descMatches[i][1])
-		}
-
-		pulls := ""
-		if i < len(pullsMatches) {
-			pulls = strings.TrimSpace(pullsMatches[i][1]) + " Pulls"
-		}
-
-		tags := ""
-		if i < len(tagsMatches) {
-			tags = strings.TrimSpace(tagsMatches[i][1])
-		}
-
-		// 尝试从描述中提取参数大小
-		paramSize := ""
-		if matches := paramRegex.FindStringSubmatch(description); len(matches) > 1 {
-			paramSize = matches[1]
-		}
-
-		model := map[string]interface{}{
-			"name":        name,
-			"description": description,
-			"pulls":       pulls,
-			"tags_count":  tags,
-			"details": map[string]interface{}{
-				"parameter_size": paramSize,
-				"format":         "gguf",
-			},
-		}
-
-		models = append(models, model)
-	}
-
-	// 如果正则表达式没有匹配到足够的模型，尝试另一种方法
-	if len(models) < 50 {
-		// 使用更简单的正则匹配模型名称
-		simpleNameRegex := regexp.MustCompile(`href="/library/([a-zA-Z0-9_.-]+)"`)
-		simpleMatches := simpleNameRegex.FindAllStringSubmatch(htmlContent, -1)
-
-		for _, match := range simpleMatches {
-			name := strings.TrimSpace(match[1])
-			if name == "" || seenModels[name] || strings.Contains(name, "blog") {
-				continue
-			}
-			seenModels[name] = true
-
-			model := map[string]interface{}{
-				"name":        name,
-				"description": fmt.Sprintf("%s model from Ollama library", name),
-				"pulls":       "N/A",
-				"tags_count":  "N/A",
-				"details": map[string]interface{}{
-					"format": "gguf",
-				},
-			}
-
-			models = append(models, model)
-		}
-	}
-
-	return models
+	log.Printf("从 %s 获取到 %d 个模型", provider.Name(), len(models))
+	return catalogModelMaps(models), nil
 }
 
 // searchOnlineModelsWithOllama 搜索在线模型
@@ -2144,360 +2184,26 @@ func (a *App) searchOnlineModelsWithOllama(query string) ([]map[string]interface
 	return filtered, nil
 }
 
-// searchOnlineModelsFromWeb 从 Ollama 搜索页面搜索模型
+// searchOnlineModelsFromWeb 在设置的目录来源中搜索模型
 func (a *App) searchOnlineModelsFromWeb(query string) ([]map[string]interface{}, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-
-	// 从 ollama.com/search?q=query 页面获取搜索结果
-	searchURL := fmt.Sprintf("https://ollama.com/search?q=%s", query)
-	resp, err := client.Get(searchURL)
+	provider := a.catalogProvider()
+	models, err := provider.Search(query)
 	if err != nil {
-		log.Printf("从 Ollama 搜索页面获取结果失败: %v", err)
+		log.Printf("从 %s 搜索模型失败: %v", provider.Name(), err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("搜索页面返回状态码: %d", resp.StatusCode)
-	}
-
-	// 读取页面内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析搜索结果页面
-	models := a.parseOllamaSearchPage(string(body), query)
-	log.Printf("从 Ollama 搜索页面解析到 %d 个模型", len(models))
-
-	return models, nil
+	log.Printf("从 %s 搜索到 %d 个模型", provider.Name(), len(models))
+	return catalogModelMaps(models), nil
 }
 
-// parseOllamaSearchPage 解析 Ollama 搜索页面获取模型列表
-func (a *App) parseOllamaSearchPage(htmlContent string, query string) []map[string]interface{} {
-	var models []map[string]interface{}
-	seenModels := make(map[string]bool)
-
-	// 使用正则表达式提取模型信息
-	// 匹配模型名称 - 在 <h2> 标签内的模型名称
-	nameRegex := regexp.MustCompile(`<h2[^>]*>\s*([a-zA-Z0-9_.-]+)\s*</h2>`)
-	// 匹配描述 - 在 class="text-neutral-400" 的段落中
-	descRegex := regexp.MustCompile(`<p[^>]*class="[^"]*text-neutral-400[^"]*"[^>]*>([^<]+)</p>`)
-	// 匹配下载量
-	pullsRegex := regexp.MustCompile(`(\d+\.?\d*[KM]?)\s*Pulls`)
-	// 匹配标签数量
-	tagsRegex := regexp.MustCompile(`(\d+)\s*Tags`)
-
-	// 提取所有模型名称
-	nameMatches := nameRegex.FindAllStringSubmatch(htmlContent, -1)
-	descMatches := descRegex.FindAllStringSubmatch(htmlContent, -1)
-
-	// 提取下载量
-	pullsMatches := pullsRegex.FindAllStringSubmatch(htmlContent, -1)
-	// 提取标签数量
-	tagsMatches := tagsRegex.FindAllStringSubmatch(htmlContent, -1)
-
-	// 合并模型信息
-	maxLen := len(nameMatches)
-	if len(descMatches) > maxLen {
-		maxLen = len(descMatches)
-	}
-
-	lowerQuery := strings.ToLower(query)
-
-	for i := 0; i < maxLen && i < len(nameMatches); i++ {
-		name := strings.TrimSpace(nameMatches[i][1])
-		if name == "" || seenModels[name] {
-			continue
-		}
-
-		description := ""
-		if i < len(descMatches) {
-			description = strings.TrimSpace(descMatches[i][1])
-		}
-
-		// 检查是否匹配搜索关键词
-		if !strings.Contains(strings.ToLower(name), lowerQuery) &&
-			!strings.Contains(strings.ToLower(description), lowerQuery) {
-			continue
-		}
-
-		seenModels[name] = true
-
-		pulls := ""
-		if i < len(pullsMatches) {
-			pulls = strings.TrimSpace(pullsMatches[i][1]) + " Pulls"
-		}
-
-		tags := ""
-		if i < len(tagsMatches) {
-			tags = strings.TrimSpace(tagsMatches[i][1])
-		}
-
-		model := map[string]interface{}{
-			"name":        name,
-			"description": description,
-			"pulls":       pulls,
-			"tags_count":  tags,
-			"details": map[string]interface{}{
-				"format": "gguf",
-			},
-		}
-
-		models = append(models, model)
-	}
-
-	// 如果正则表达式没有匹配到足够的模型，尝试另一种方法
-	if len(models) < 10 {
-		// 使用更简单的正则匹配模型名称
-		simpleNameRegex := regexp.MustCompile(`href="/library/([a-zA-Z0-9_.-]+)"`)
-		simpleMatches := simpleNameRegex.FindAllStringSubmatch(htmlContent, -1)
-
-		for _, match := range simpleMatches {
-			name := strings.TrimSpace(match[1])
-			if name == "" || seenModels[name] || strings.Contains(name, "blog") {
-				continue
-			}
-
-			// 检查是否匹配搜索关键词
-			if !strings.Contains(strings.ToLower(name), lowerQuery) {
-				continue
-			}
-
-			seenModels[name] = true
-
-			model := map[string]interface{}{
-				"name":        name,
-				"description": fmt.Sprintf("%s model from Ollama library", name),
-				"pulls":       "N/A",
-				"tags_count":  "N/A",
-				"details": map[string]interface{}{
-					"format": "gguf",
-				},
-			}
-
-			models = append(models, model)
-		}
-	}
-
-	return models
-}
-
-// getBuiltinOnlineModels 返回内置的在线模型列表
+// getBuiltinOnlineModels 返回内置的在线模型列表，来自模型目录（见 model_catalog.go）
 func (a *App) getBuiltinOnlineModels() []map[string]interface{} {
-	// 从 Ollama 官方库中精选的模型列表
-	return []map[string]interface{}{
-		{
-			"name":        "llama3:8b",
-			"description": "Meta's latest 8 billion parameter model",
-			"size":        "4.7 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "8B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llama",
-			},
-		},
-		{
-			"name":        "llama3:70b",
-			"description": "Meta's powerful 70 billion parameter model",
-			"size":        "34.4 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "70B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llama",
-			},
-		},
-		{
-			"name":        "mistral:7b",
-			"description": "Mistral AI's 7 billion parameter model",
-			"size":        "4.1 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q5_K_M",
-				"format":             "gguf",
-				"family":             "transformer",
-			},
-		},
-		{
-			"name":        "mistral:7b-instruct",
-			"description": "Mistral AI's 7 billion parameter instruction-tuned model",
-			"size":        "4.1 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q5_K_M",
-				"format":             "gguf",
-				"family":             "transformer",
-			},
-		},
-		{
-			"name":        "gemma:2b",
-			"description": "Google's lightweight 2 billion parameter model",
-			"size":        "1.4 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "2B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "gemma",
-			},
-		},
-		{
-			"name":        "gemma:7b",
-			"description": "Google's 7 billion parameter model",
-			"size":        "4.2 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "gemma",
-			},
-		},
-		{
-			"name":        "qwen2:0.5b",
-			"description": "阿里巴巴通义千问 0.5B 模型",
-			"size":        "0.3 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "0.5B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "qwen",
-			},
-		},
-		{
-			"name":        "qwen2:1.5b",
-			"description": "阿里巴巴通义千问 1.5B 模型",
-			"size":        "0.9 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "1.5B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "qwen",
-			},
-		},
-		{
-			"name":        "qwen2:7b",
-			"description": "阿里巴巴通义千问 7B 模型",
-			"size":        "4.3 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "qwen",
-			},
-		},
-		{
-			"name":        "qwen2:72b",
-			"description": "阿里巴巴通义千问 72B 模型",
-			"size":        "35.1 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "72B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "qwen",
-			},
-		},
-		{
-			"name":        "phi3:mini",
-			"description": "微软轻量级 3.8B 参数模型",
-			"size":        "2.3 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "3.8B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "phi",
-			},
-		},
-		{
-			"name":        "phi3:small",
-			"description": "微软 7B 参数模型",
-			"size":        "4.1 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "phi",
-			},
-		},
-		{
-			"name":        "phi3:medium",
-			"description": "微软 14B 参数模型",
-			"size":        "8.3 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "14B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "phi",
-			},
-		},
-		{
-			"name":        "llava:1.5-7b",
-			"description": "多模态视觉语言模型",
-			"size":        "4.5 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llava",
-			},
-		},
-		{
-			"name":        "codellama:7b",
-			"description": "专为代码生成优化的模型",
-			"size":        "4.2 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llama",
-			},
-		},
-		{
-			"name":        "codellama:13b",
-			"description": "专为代码生成优化的 13B 参数模型",
-			"size":        "7.8 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "13B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llama",
-			},
-		},
-		{
-			"name":        "starling-lm:7b-alpha",
-			"description": "高性能语言模型",
-			"size":        "4.1 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "llama",
-			},
-		},
-		{
-			"name":        "deepseek-coder:6.7b-base",
-			"description": "专为代码生成优化的模型",
-			"size":        "4.0 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "6.7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "deepseek",
-			},
-		},
-		{
-			"name":        "deepseek-coder:6.7b-instruct",
-			"description": "专为代码生成优化的指令模型",
-			"size":        "4.0 GB",
-			"details": map[string]interface{}{
-				"parameter_size":     "6.7B",
-				"quantization_level": "Q4_K_M",
-				"format":             "gguf",
-				"family":             "deepseek",
-			},
-		},
+	catalog := a.loadModelCatalog()
+	models := make([]map[string]interface{}, 0, len(catalog.Models))
+	for _, entry := range catalog.Models {
+		models = append(models, catalogEntryMap(entry))
 	}
+	return models
 }
 
 // CheckOllamaAvailable 检查 Ollama 服务是否可用
@@ -2513,6 +2219,44 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getConfigString 从环境变量配置中获取字符串值
+func (a *App) getConfigString(key, defaultValue string) string {
+	if value, ok := a.environmentVariables[key].(string); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getConfigBool 从环境变量配置中获取布尔值，兼容字符串形式的 "true"/"false"
+func (a *App) getConfigBool(key string, defaultValue bool) bool {
+	switch v := a.environmentVariables[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getConfigInt 从环境变量配置中获取整数值，兼容 JSON 解析得到的 float64 和字符串
+func (a *App) getConfigInt(key string, defaultValue int) int {
+	switch v := a.environmentVariables[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
 // GetIntelOptimizationInfo 获取英特尔优化信息
 func (a *App) GetIntelOptimizationInfo() map[string]interface{} {
 	info := map[string]interface{}{
@@ -2629,15 +2373,18 @@ func (a *App) WebSocketChat(conn *websocket.Conn) {
 
 // handleWebSocketChat 处理WebSocket聊天请求
 func (a *App) handleWebSocketChat(conn *websocket.Conn, model string, messages []ChatMessage) {
+	defer a.trackGeneration(model)()
 	// 使用HTTP API进行聊天
 	client := &http.Client{Timeout: 60 * time.Second}
 
 	// 构建请求体
-	reqBody, err := json.Marshal(map[string]interface{}{
+	body := map[string]interface{}{
 		"model":    model,
 		"messages": messages,
 		"stream":   true,
-	})
+	}
+	a.applyKeepAlive(body, model)
+	reqBody, err := json.Marshal(body)
 	if err != nil {
 		// 发送错误响应
 		conn.WriteJSON(map[string]interface{}{
@@ -2880,6 +2627,9 @@ func (a *App) startOllamaService() error {
 		}
 	}
 
+	// 模型下载流量经过限速代理
+	env = append(env, a.pullProxyEnv()...)
+
 	// 确保 OLLAMA_DEBUG 为 false，避免服务以 debug 模式运行
 	cmd.Env = env
 	log.Printf("startOllamaService: 应用环境变量: %+v\n", a.environmentVariables)
@@ -3047,37 +2797,53 @@ func (a *App) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
 
 // 初始化HTTP服务器，添加WebSocket路由和OpenAI兼容API
 func (a *App) initHTTPServer() {
-	// 启动HTTP服务器，监听WebSocket连接
-	go func() {
-		// 注册WebSocket路由
-		http.HandleFunc("/ws/chat", a.WebSocketHandler)
-
-		// 注册OpenAI兼容API路由
-		http.HandleFunc("/v1/chat/completions", a.handleOpenAIChatCompletions)
-		http.HandleFunc("/v1/models", a.handleOpenAIModels)
-		http.HandleFunc("/v1/models/", a.handleOpenAIModel)
-
-		// 启动服务器，使用不同的端口以避免与Ollama服务冲突
-		log.Println("========================================")
-		log.Println("WebSocket服务器启动在 :11435")
-		log.Println("Web端访问地址: http://localhost:11435")
-		log.Println("OpenAI兼容API地址: http://localhost:11435/v1")
-		log.Println("========================================")
-		if err := http.ListenAndServe(":11435", nil); err != nil {
-			log.Printf("WebSocket服务器启动失败: %v", err)
-		}
-	}()
+	// WebSocket 与 CORS 共用同一份来源白名单
+	upgrader.CheckOrigin = func(r *http.Request) bool {
+		return a.isOriginAllowed(r.Header.Get("Origin"))
+	}
+
+	// 启动HTTP服务器，使用不同的端口以避免与Ollama服务冲突
+	a.startGatewayServer(a.newGatewayMux())
 
 	// 注意: Ollama 服务本身也内置 OpenAI 兼容 API (端口 11434)
 	// OpenAI 兼容 API 地址: http://localhost:11434/v1
 	log.Println("Ollama内置OpenAI兼容API地址: http://localhost:11434/v1")
 }
 
+// newGatewayMux 注册网关的所有路由
+func (a *App) newGatewayMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// 注册WebSocket路由
+	mux.HandleFunc("/ws/chat", a.WebSocketHandler)
+
+	// 注册OpenAI兼容API路由
+	mux.HandleFunc("/v1/chat/completions", a.handleOpenAIChatCompletions)
+	mux.HandleFunc("/v1/models", a.handleOpenAIModels)
+	mux.HandleFunc("/v1/models/", a.handleOpenAIModel)
+
+	// 注册健康检查路由（无需API密钥，供监控和负载均衡使用）
+	mux.HandleFunc("/healthz", a.handleHealthz)
+	mux.HandleFunc("/readyz", a.handleReadyz)
+
+	// 注册响应缓存统计路由
+	mux.HandleFunc("/cache/stats", a.handleResponseCacheStats)
+
+	// 注册模型加载管理路由
+	mux.HandleFunc("/admin/models/running", a.handleAdminRunningModels)
+	mux.HandleFunc("/admin/models/load", a.handleAdminLoadModel)
+	mux.HandleFunc("/admin/models/unload", a.handleAdminUnloadModel)
+	mux.HandleFunc("/admin/models/keep-alive", a.handleAdminKeepAlive)
+
+	return mux
+}
+
 // OpenAIChatRequest OpenAI兼容的聊天请求
 type OpenAIChatRequest struct {
 	Model       string                   `json:"model"`
 	Messages    []map[string]interface{} `json:"messages"`
-	Temperature float64                  `json:"temperature,omitempty"`
+	Temperature *float64                 `json:"temperature,omitempty"`
+	Seed        *int64                   `json:"seed,omitempty"`
 	MaxTokens   int                      `json:"max_tokens,omitempty"`
 	Stream      bool                     `json:"stream,omitempty"`
 	APIKey      string                   `json:"api_key,omitempty"`
@@ -3119,10 +2885,12 @@ type OpenAIStreamResponse struct {
 // OpenAIModelResponse OpenAI兼容的模型响应
 
 type OpenAIModelResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	OwnedBy string `json:"owned_by"`
+	ID      string               `json:"id"`
+	Object  string               `json:"object"`
+	Created int64                `json:"created"`
+	OwnedBy string               `json:"owned_by"`
+	Root    string               `json:"root,omitempty"`   // 别名指向的实际模型
+	Ollama  *OllamaModelMetadata `json:"ollama,omitempty"` // Ollama 模型扩展信息
 }
 
 // OpenAIModelsResponse OpenAI兼容的模型列表响应
@@ -3134,10 +2902,11 @@ type OpenAIModelsResponse struct {
 
 // handleOpenAIChatCompletions 处理OpenAI兼容的聊天完成请求
 func (a *App) handleOpenAIChatCompletions(w http.ResponseWriter, r *http.Request) {
-	// 设置CORS头，允许外部工具调用
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// 设置CORS头，只允许白名单中的来源调用
+	if !a.setCORSHeaders(w, r, "POST, OPTIONS") {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
 
 	// 处理预检请求
 	if r.Method == "OPTIONS" {
@@ -3179,11 +2948,12 @@ func (a *App) handleOpenAIChatCompletions(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// 解析模型名称（支持digest ID或模型名称）
+	// 解析模型名称（支持别名、路由规则、digest ID或模型名称）
 	resolvedModel := a.resolveModelName(req.Model)
 	if resolvedModel != req.Model {
 		log.Printf("[OpenAI API] 模型ID解析: %s -> %s", req.Model, resolvedModel)
 	}
+	fallbacks := a.getModelRouting().fallbackChain(req.Model, resolvedModel)
 
 	// 转换为Ollama聊天请求
 	ollamaMessages := make([]ChatMessage, 0, len(req.Messages))
@@ -3201,28 +2971,60 @@ func (a *App) handleOpenAIChatCompletions(w http.ResponseWriter, r *http.Request
 		Messages: ollamaMessages,
 		Stream:   req.Stream,
 	}
+	// 合并模型的默认聊天参数，缓存键也要包含合并后的参数
+	if options := a.mergeChatOptions(resolvedModel, buildOllamaOptions(req)); options != nil {
+		ollamaReq.Options = options
+	}
 
-	// 处理流式响应
+	// 确定性请求优先从响应缓存中返回
+	cacheKey, storeInCache, served := a.serveFromResponseCache(w, r, req, ollamaReq)
+	if served {
+		return
+	}
+
+	// 处理流式响应（仅缓存主模型生成的结果）
 	if req.Stream {
-		a.handleOpenAIStreamResponse(w, ollamaReq)
+		content, usedModel, ok := a.handleOpenAIStreamResponse(w, ollamaReq, fallbacks)
+		if ok && storeInCache && usedModel == ollamaReq.Model {
+			a.storeResponseCache(cacheKey, ollamaReq.Model, content)
+		}
 		return
 	}
 
 	// 处理非流式响应
-	response := a.handleOpenAINonStreamResponse(ollamaReq)
+	response, ok := a.handleOpenAINonStreamResponse(ollamaReq, fallbacks)
+	if ok && storeInCache && response.Model == ollamaReq.Model && len(response.Choices) > 0 {
+		content, _ := response.Choices[0].Message["content"].(string)
+		a.storeResponseCache(cacheKey, ollamaReq.Model, content)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// buildOllamaOptions 将 OpenAI 请求中的采样参数转换为 Ollama options
+func buildOllamaOptions(req OpenAIChatRequest) map[string]interface{} {
+	options := make(map[string]interface{})
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.Seed != nil {
+		options["seed"] = *req.Seed
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	return options
+}
+
 // handleOpenAIStreamResponse 处理OpenAI兼容的流式响应
-func (a *App) handleOpenAIStreamResponse(w http.ResponseWriter, req ChatRequest) {
+// 上游在开始输出前失败时依次尝试回退模型，返回完整的生成内容、实际使用的模型以及是否正常完成
+func (a *App) handleOpenAIStreamResponse(w http.ResponseWriter, req ChatRequest, fallbacks []string) (string, string, bool) {
 	log.Printf("[OpenAI API] 开始流式响应: 模型=%s", req.Model)
 
 	// 设置响应头
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// 使用HTTP API进行聊天，增加超时时间
 	client := &http.Client{
@@ -3233,36 +3035,57 @@ func (a *App) handleOpenAIStreamResponse(w http.ResponseWriter, req ChatRequest)
 		},
 	}
 
-	// 构建请求体
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		log.Printf("[OpenAI API] 构建请求失败: %v", err)
-		w.Write([]byte("data: {\"error\": \"Invalid request\"}\n\n"))
-		w.(http.Flusher).Flush()
-		return
-	}
+	// 依次尝试主模型和回退模型，直到上游成功返回
+	var resp *http.Response
+	lastError := "Invalid request"
+	for i, candidate := range append([]string{req.Model}, fallbacks...) {
+		req.Model = candidate
+		if i > 0 {
+			log.Printf("[OpenAI API] 尝试回退模型: %s", candidate)
+		}
+		req.KeepAlive = nil
+		if keepAlive := a.keepAliveFor(candidate); keepAlive != "" {
+			req.KeepAlive = keepAliveRequestValue(keepAlive)
+		}
 
-	log.Printf("[OpenAI API] 发送请求到Ollama服务")
+		// 构建请求体
+		reqBody, err := json.Marshal(req)
+		if err != nil {
+			log.Printf("[OpenAI API] 构建请求失败: %v", err)
+			break
+		}
 
-	// 发送请求
-	resp, err := client.Post("http://127.0.0.1:11434/api/chat", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		log.Printf("[OpenAI API] 连接Ollama服务失败: %v", err)
-		w.Write([]byte("data: {\"error\": \"Failed to connect to Ollama service\"}\n\n"))
-		w.(http.Flusher).Flush()
-		return
-	}
-	defer resp.Body.Close()
+		log.Printf("[OpenAI API] 发送请求到Ollama服务")
+
+		// 发送请求
+		candidateResp, err := client.Post("http://127.0.0.1:11434/api/chat", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			log.Printf("[OpenAI API] 连接Ollama服务失败: %v", err)
+			lastError = "Failed to connect to Ollama service"
+			continue
+		}
 
-	log.Printf("[OpenAI API] Ollama响应状态: %d", resp.StatusCode)
+		log.Printf("[OpenAI API] Ollama响应状态: %d", candidateResp.StatusCode)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("[OpenAI API] Ollama错误响应: %s", string(body))
-		w.Write([]byte(fmt.Sprintf("data: {\"error\": \"Ollama error: %d\"}\n\n", resp.StatusCode)))
+		if candidateResp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(candidateResp.Body)
+			candidateResp.Body.Close()
+			log.Printf("[OpenAI API] Ollama错误响应: %s", string(body))
+			lastError = fmt.Sprintf("Ollama error: %d", candidateResp.StatusCode)
+			continue
+		}
+
+		resp = candidateResp
+		break
+	}
+
+	if resp == nil {
+		w.Write([]byte(fmt.Sprintf("data: {\"error\": \"%s\"}\n\n", lastError)))
 		w.(http.Flusher).Flush()
-		return
+		return "", req.Model, false
 	}
+	defer resp.Body.Close()
+	defer a.trackGeneration(req.Model)()
 
 	// 处理流式响应
 	scanner := bufio.NewScanner(resp.Body)
@@ -3319,7 +3142,7 @@ func (a *App) handleOpenAIStreamResponse(w http.ResponseWriter, req ChatRequest)
 			w.Write([]byte("\n"))
 			w.Write([]byte("data: [DONE]\n\n"))
 			w.(http.Flusher).Flush()
-			return
+			return "", req.Model, false
 		}
 
 		// 累积内容
@@ -3383,33 +3206,60 @@ func (a *App) handleOpenAIStreamResponse(w http.ResponseWriter, req ChatRequest)
 			w.Write([]byte("\n"))
 			w.Write([]byte("data: [DONE]\n\n"))
 			w.(http.Flusher).Flush()
-			break
+			return fullContent.String(), req.Model, true
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		log.Printf("[OpenAI API] 读取响应失败: %v", err)
 	}
+	return fullContent.String(), req.Model, false
 }
 
 // handleOpenAINonStreamResponse 处理OpenAI兼容的非流式响应
-func (a *App) handleOpenAINonStreamResponse(req ChatRequest) OpenAIChatResponse {
+// 主模型失败时依次尝试回退模型，第二个返回值表示响应是否来自上游服务（而非失败时的模拟响应）
+func (a *App) handleOpenAINonStreamResponse(req ChatRequest, fallbacks []string) (OpenAIChatResponse, bool) {
 	log.Printf("[OpenAI API] 处理非流式响应: 模型=%s", req.Model)
-	
-	// 使用现有的ChatCompletion方法获取响应
-	ollamaResp := a.ChatCompletion(req)
 
-	// 构建OpenAI响应
-	responseID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
-	created := time.Now().Unix()
+	// 获取响应，全部失败时与ChatCompletion一样回退到模拟响应
+	ok := false
+	var ollamaResp ChatResponse
+	for i, candidate := range append([]string{req.Model}, fallbacks...) {
+		if i > 0 {
+			log.Printf("[OpenAI API] 尝试回退模型: %s", candidate)
+		}
+		candidateReq := req
+		candidateReq.Model = candidate
+
+		resp, err := a.requestChatCompletion(candidateReq)
+		if err != nil {
+			log.Printf("[OpenAI API] 非流式请求失败: 模型=%s, 错误=%v", candidate, err)
+			continue
+		}
+		req.Model = candidate
+		ollamaResp = resp
+		ok = true
+		break
+	}
+	if !ok {
+		ollamaResp = mockChatResponse(req.Model)
+	}
 
 	log.Printf("[OpenAI API] 非流式响应完成: 内容长度=%d", len(ollamaResp.Message.Content))
 
+	return newOpenAIChatResponse(req.Model, ollamaResp.Message.Role, ollamaResp.Message.Content), ok
+}
+
+// newOpenAIChatResponse 构建OpenAI兼容的非流式聊天响应
+func newOpenAIChatResponse(model, role, content string) OpenAIChatResponse {
+	responseID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+
 	return OpenAIChatResponse{
 		ID:      responseID,
 		Object:  "chat.completion",
 		Created: created,
-		Model:   req.Model,
+		Model:   model,
 		Choices: []struct {
 			Index        int                    `json:"index"`
 			Message      map[string]interface{} `json:"message"`
@@ -3418,8 +3268,8 @@ func (a *App) handleOpenAINonStreamResponse(req ChatRequest) OpenAIChatResponse
 			{
 				Index: 0,
 				Message: map[string]interface{}{
-					"role":    ollamaResp.Message.Role,
-					"content": ollamaResp.Message.Content,
+					"role":    role,
+					"content": content,
 				},
 				FinishReason: "stop",
 			},
@@ -3429,9 +3279,9 @@ func (a *App) handleOpenAINonStreamResponse(req ChatRequest) OpenAIChatResponse
 			CompletionTokens int `json:"completion_tokens"`
 			TotalTokens      int `json:"total_tokens"`
 		}{
-			PromptTokens:     len(ollamaResp.Message.Content),
-			CompletionTokens: len(ollamaResp.Message.Content),
-			TotalTokens:      len(ollamaResp.Message.Content) + len(ollamaResp.Message.Content),
+			PromptTokens:     len(content),
+			CompletionTokens: len(content),
+			TotalTokens:      len(content) + len(content),
 		},
 	}
 }
@@ -3439,9 +3289,10 @@ func (a *App) handleOpenAINonStreamResponse(req ChatRequest) OpenAIChatResponse
 // handleOpenAIModels 处理OpenAI兼容的模型列表请求
 func (a *App) handleOpenAIModels(w http.ResponseWriter, r *http.Request) {
 	// 设置CORS头
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	if !a.setCORSHeaders(w, r, "GET, OPTIONS") {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
 
 	// 处理预检请求
 	if r.Method == "OPTIONS" {
@@ -3469,21 +3320,35 @@ func (a *App) handleOpenAIModels(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 获取本地模型列表
-	models := a.ListModels()
+	models, err := a.fetchLocalModels()
+	if err != nil {
+		log.Printf("[OpenAI API] 获取模型列表失败: %v", err)
+		writeOpenAIError(w, http.StatusServiceUnavailable, "Failed to list models from Ollama service", "server_error", "")
+		return
+	}
 
 	log.Printf("[OpenAI API] 获取到 %d 个模型", len(models))
 
 	// 构建OpenAI模型响应 - 同时返回模型名称和digest作为ID
-	var modelResponses []OpenAIModelResponse
+	modelResponses := []OpenAIModelResponse{}
 	for _, model := range models {
+		created := parseModelCreated(model.Modified)
+		// 与单个模型的查询返回相同的扩展信息，包括上下文长度和能力
+		metadata, err := a.openAIModelMetadataFor(model)
+		if err != nil {
+			// 模型在获取列表后被删除
+			continue
+		}
+
 		// 主要使用模型名称
 		modelResponses = append(modelResponses, OpenAIModelResponse{
 			ID:      model.Name,
 			Object:  "model",
-			Created: time.Now().Unix(),
+			Created: created,
 			OwnedBy: "ollama",
+			Ollama:  metadata,
 		})
-		
+
 		// 如果有digest，也添加一个别名（方便某些工具使用）
 		if model.Digest != "" && model.Digest != model.Name {
 			shortDigest := model.Digest
@@ -3493,12 +3358,33 @@ func (a *App) handleOpenAIModels(w http.ResponseWriter, r *http.Request) {
 			modelResponses = append(modelResponses, OpenAIModelResponse{
 				ID:      shortDigest,
 				Object:  "model",
-				Created: time.Now().Unix(),
+				Created: created,
 				OwnedBy: "ollama",
+				Root:    model.Name,
 			})
 		}
 	}
 
+	// 添加配置的模型别名，便于工具发现；创建时间使用目标模型的修改时间，目标模型不存在时为 0
+	routing := a.getModelRouting()
+	for _, alias := range routing.sortedAliases() {
+		target := routing.Aliases[alias]
+		var created int64
+		for _, model := range models {
+			if sameModelName(model.Name, target) {
+				created = parseModelCreated(model.Modified)
+				break
+			}
+		}
+		modelResponses = append(modelResponses, OpenAIModelResponse{
+			ID:      alias,
+			Object:  "model",
+			Created: created,
+			OwnedBy: "alias",
+			Root:    target,
+		})
+	}
+
 	// 构建响应
 	response := OpenAIModelsResponse{
 		Object: "list",
@@ -3513,9 +3399,10 @@ func (a *App) handleOpenAIModels(w http.ResponseWriter, r *http.Request) {
 // handleOpenAIModel 处理OpenAI兼容的单个模型请求
 func (a *App) handleOpenAIModel(w http.ResponseWriter, r *http.Request) {
 	// 设置CORS头
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	if !a.setCORSHeaders(w, r, "GET, OPTIONS") {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
 
 	// 处理预检请求
 	if r.Method == "OPTIONS" {
@@ -3549,12 +3436,16 @@ func (a *App) handleOpenAIModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 构建模型响应
-	modelResp := OpenAIModelResponse{
-		ID:      modelID,
-		Object:  "model",
-		Created: time.Now().Unix(),
-		OwnedBy: "ollama",
+	modelResp, err := a.lookupOpenAIModel(modelID)
+	if errors.Is(err, errModelNotFound) {
+		writeOpenAIError(w, http.StatusNotFound,
+			fmt.Sprintf("The model '%s' does not exist", modelID), "invalid_request_error", "model_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("[OpenAI API] 获取模型信息失败: %v", err)
+		writeOpenAIError(w, http.StatusServiceUnavailable, "Failed to query Ollama service", "server_error", "")
+		return
 	}
 
 	// 发送响应
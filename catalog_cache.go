@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 在线模型库的默认地址、目录缓存的默认有效期（小时）和请求超时
+const (
+	defaultLibraryURL           = "https://ollama.com"
+	defaultCatalogCacheTTLHours = 24
+	catalogRequestTimeout       = 15 * time.Second
+)
+
+// catalogCacheEntry 缓存在磁盘上的一个页面或接口的数据
+type catalogCacheEntry struct {
+	Key       string          `json:"key"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// libraryURL 在线模型库地址，由 OLLAMA_LIBRARY_URL 设置，可以指向本地的镜像或测试服务器
+func (a *App) libraryURL() string {
+	return strings.TrimRight(a.getConfigString("OLLAMA_LIBRARY_URL", defaultLibraryURL), "/")
+}
+
+// getCatalogCacheDir 获取在线模型目录缓存目录
+func (a *App) getCatalogCacheDir() string {
+	return filepath.Join(a.getConfigDir(), "catalog_cache")
+}
+
+// catalogCachePath 缓存键对应的缓存文件
+func (a *App) catalogCachePath(key string) string {
+	return filepath.Join(a.getCatalogCacheDir(), sha256Hex([]byte(key))+".json")
+}
+
+// catalogCacheTTL 缓存有效期，由 OLLAMA_CATALOG_CACHE_TTL_HOURS 设置，0 表示每次都重新获取
+func (a *App) catalogCacheTTL() time.Duration {
+	hours := a.getConfigInt("OLLAMA_CATALOG_CACHE_TTL_HOURS", defaultCatalogCacheTTLHours)
+	if hours < 0 {
+		hours = 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// readCatalogCache 读取缓存，不存在或无法解析时返回 nil
+func (a *App) readCatalogCache(key string) *catalogCacheEntry {
+	data, err := os.ReadFile(a.catalogCachePath(key))
+	if err != nil {
+		return nil
+	}
+	var entry catalogCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil
+	}
+	return &entry
+}
+
+// writeCatalogCache 保存获取到的数据
+func (a *App) writeCatalogCache(key string, result json.RawMessage) {
+	data, err := json.Marshal(catalogCacheEntry{Key: key, FetchedAt: time.Now(), Data: result})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(a.getCatalogCacheDir(), 0755); err != nil {
+		log.Printf("writeCatalogCache: 创建缓存目录失败: %v", err)
+		return
+	}
+	if err := writeFileAtomic(a.catalogCachePath(key), data, 0644); err != nil {
+		log.Printf("writeCatalogCache: 保存缓存失败: %v", err)
+	}
+}
+
+// fetchCached 用 fetch 获取数据，结果保存到 out 指向的变量，key 为缓存的键（通常是页面地址）
+// 缓存未过期时直接使用缓存；获取失败（如离线、页面改版）时使用过期的缓存
+func (a *App) fetchCached(key string, out interface{}, fetch func() (interface{}, error)) error {
+	cached := a.readCatalogCache(key)
+	if cached != nil && time.Since(cached.FetchedAt) < a.catalogCacheTTL() {
+		return json.Unmarshal(cached.Data, out)
+	}
+
+	result, err := fetch()
+	if err != nil {
+		if cached != nil {
+			log.Printf("获取 %s 失败，使用 %s 的缓存: %v", key, cached.FetchedAt.Format("2006-01-02 15:04:05"), err)
+			return json.Unmarshal(cached.Data, out)
+		}
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	a.writeCatalogCache(key, data)
+	return json.Unmarshal(data, out)
+}
+
+// fetchCachedPage 下载页面并用 parse 解析，解析结果按 TTL 缓存，保存到 out 指向的变量
+func (a *App) fetchCachedPage(pageURL string, out interface{}, parse func(r io.Reader) (interface{}, error)) error {
+	return a.fetchCached(pageURL, out, func() (interface{}, error) {
+		return downloadPage(pageURL, parse)
+	})
+}
+
+// downloadPage 下载并解析页面
+func downloadPage(pageURL string, parse func(r io.Reader) (interface{}, error)) (interface{}, error) {
+	client := &http.Client{Timeout: catalogRequestTimeout}
+	resp, err := client.Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("页面返回状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parse(bytes.NewReader(body))
+}
+
+// fetchCatalogPage 获取并解析在线模型目录或搜索页面
+func (a *App) fetchCatalogPage(pageURL string) ([]CatalogModel, error) {
+	var models []CatalogModel
+	err := a.fetchCachedPage(pageURL, &models, func(r io.Reader) (interface{}, error) {
+		parsed := parseCatalogPage(r)
+		if len(parsed) == 0 {
+			return nil, fmt.Errorf("未能从页面解析到任何模型")
+		}
+		return parsed, nil
+	})
+	return models, err
+}
+
+// catalogModelMaps 转换为在线模型列表使用的 map 格式
+func catalogModelMaps(models []CatalogModel) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(models))
+	for _, model := range models {
+		result = append(result, catalogModelMap(model))
+	}
+	return result
+}
+
+// ClearCatalogCache 清除在线模型目录缓存，下次获取时重新下载
+func (a *App) ClearCatalogCache() map[string]interface{} {
+	if err := os.RemoveAll(a.getCatalogCacheDir()); err != nil {
+		log.Printf("清除在线模型目录缓存失败: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("清除缓存失败: %v", err),
+		}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"message": "在线模型目录缓存已清除",
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// CatalogModel 在线模型目录中的一个模型卡片
+type CatalogModel struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Capabilities []string `json:"capabilities,omitempty"` // 如 tools、vision、thinking、embedding
+	Sizes        []string `json:"sizes,omitempty"`        // 如 8b、70b
+	Pulls        string   `json:"pulls,omitempty"`        // 页面显示的下载量，如 91.2M
+	PullCount    int64    `json:"pull_count"`
+	TagCount     int      `json:"tag_count"`
+	Updated      string   `json:"updated,omitempty"`      // 如 2 weeks ago
+	Quantization string   `json:"quantization,omitempty"` // 量化类型，ollama.com 的目录页面不提供，只有魔搭社区模型名称或目录文件中有
+	Source       string   `json:"source,omitempty"`       // 目录来源，见 CatalogProvider
+}
+
+// 模型卡片链接，例如 /library/llama3.1
+var catalogLinkRegex = regexp.MustCompile(`^/library/([a-zA-Z0-9_.-]+)/?$`)
+
+// 页面没有 x-test-* 标记时从卡片文本中提取信息
+var (
+	catalogPullsTextRegex   = regexp.MustCompile(`(?i)([\d.]+\s*[KMB]?)\s*Pulls`)
+	catalogTagsTextRegex    = regexp.MustCompile(`(?i)(\d+)\s*Tags`)
+	catalogUpdatedTextRegex = regexp.MustCompile(`(?i)Updated\s+(.+?\bago)`)
+	catalogSizeTextRegex    = regexp.MustCompile(`(?i)^(?:\d+x)?\d+(?:\.\d+)?[bm]$`)
+)
+
+// 模型卡片中 x-test-* 属性对应的字段
+var catalogFieldAttrs = map[string]string{
+	"x-test-search-response-title": "name",
+	"x-test-capability":            "capability",
+	"x-test-size":                  "size",
+	"x-test-pull-count":            "pulls",
+	"x-test-tag-count":             "tags",
+	"x-test-updated":               "updated",
+}
+
+// 能力标签
+var catalogCapabilities = map[string]bool{
+	"tools": true, "vision": true, "thinking": true, "embedding": true, "cloud": true, "audio": true,
+}
+
+// 没有结束标签的元素
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// catalogCardParser 解析一个模型卡片
+type catalogCardParser struct {
+	model  CatalogModel
+	depth  int      // 卡片内元素的嵌套深度，回到 0 时卡片结束
+	fields []string // 每层元素对应的字段，文本归入最内层有字段的元素
+	text   []string // 卡片中的全部文本，用于没有 x-test-* 标记时的回退解析
+	values map[string][]string
+
+	descriptionDone bool // 只取第一个有文本的段落作为描述，后面的段落是下载量等统计信息
+}
+
+func newCatalogCardParser(name string) *catalogCardParser {
+	return &catalogCardParser{
+		model:  CatalogModel{Name: name},
+		values: make(map[string][]string),
+	}
+}
+
+// field 根据元素的属性和标签名判断其中的文本属于哪个字段
+func catalogField(token html.Token) string {
+	for _, attr := range token.Attr {
+		if field, ok := catalogFieldAttrs[attr.Key]; ok {
+			return field
+		}
+	}
+	switch token.Data {
+	case "h2":
+		return "name"
+	case "p":
+		return "description"
+	}
+	return ""
+}
+
+func (c *catalogCardParser) start(token html.Token) {
+	c.depth++
+	field := catalogField(token)
+	if field == "description" && c.descriptionDone {
+		field = ""
+	}
+	c.fields = append(c.fields, field)
+}
+
+// end 元素结束，返回卡片是否已结束
+func (c *catalogCardParser) end() bool {
+	c.depth--
+	if len(c.fields) > 0 {
+		if c.fields[len(c.fields)-1] == "description" && len(c.values["description"]) > 0 {
+			c.descriptionDone = true
+		}
+		c.fields = c.fields[:len(c.fields)-1]
+	}
+	return c.depth <= 0
+}
+
+func (c *catalogCardParser) addText(text string) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+	c.text = append(c.text, text)
+	for i := len(c.fields) - 1; i >= 0; i-- {
+		if c.fields[i] != "" {
+			c.values[c.fields[i]] = append(c.values[c.fields[i]], text)
+			return
+		}
+	}
+}
+
+// finish 整理卡片中提取到的字段
+func (c *catalogCardParser) finish() CatalogModel {
+	model := c.model
+	if names := c.values["name"]; len(names) > 0 {
+		model.Name = strings.Join(strings.Fields(strings.Join(names, "")), "")
+	}
+	model.Description = strings.Join(c.values["description"], " ")
+
+	for _, value := range c.values["capability"] {
+		model.Capabilities = append(model.Capabilities, strings.ToLower(value))
+	}
+	for _, value := range c.values["size"] {
+		model.Sizes = append(model.Sizes, strings.ToLower(value))
+	}
+	if values := c.values["pulls"]; len(values) > 0 {
+		model.Pulls = values[0]
+	}
+	if values := c.values["tags"]; len(values) > 0 {
+		model.TagCount, _ = strconv.Atoi(values[0])
+	}
+	if values := c.values["updated"]; len(values) > 0 {
+		model.Updated = values[0]
+	}
+
+	// 页面没有 x-test-* 标记时从文本中提取
+	joined := strings.Join(c.text, " ")
+	if model.Pulls == "" {
+		if m := catalogPullsTextRegex.FindStringSubmatch(joined); m != nil {
+			model.Pulls = strings.ReplaceAll(m[1], " ", "")
+		}
+	}
+	if model.TagCount == 0 {
+		if m := catalogTagsTextRegex.FindStringSubmatch(joined); m != nil {
+			model.TagCount, _ = strconv.Atoi(m[1])
+		}
+	}
+	if model.Updated == "" {
+		if m := catalogUpdatedTextRegex.FindStringSubmatch(joined); m != nil {
+			model.Updated = m[1]
+		}
+	}
+	if len(model.Capabilities) == 0 && len(model.Sizes) == 0 {
+		for _, text := range c.text {
+			lower := strings.ToLower(text)
+			switch {
+			case catalogCapabilities[lower]:
+				model.Capabilities = append(model.Capabilities, lower)
+			case catalogSizeTextRegex.MatchString(lower):
+				model.Sizes = append(model.Sizes, lower)
+			}
+		}
+	}
+
+	model.PullCount = parseCatalogCount(model.Pulls)
+	return model
+}
+
+// parseCatalogCount 解析 91.2M、1.5K 这样的数量
+func parseCatalogCount(value string) int64 {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(value, "K"):
+		factor = 1e3
+	case strings.HasSuffix(value, "M"):
+		factor = 1e6
+	case strings.HasSuffix(value, "B"):
+		factor = 1e9
+	}
+	number, err := strconv.ParseFloat(strings.TrimRight(value, "KMB"), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(number * factor)
+}
+
+// catalogCardStart 判断元素是否为模型卡片的开始，返回链接中的模型名称
+func catalogCardStart(token html.Token) (string, bool) {
+	isModelItem := false
+	href := ""
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "x-test-model":
+			isModelItem = true
+		case "href":
+			href = attr.Val
+		}
+	}
+	if isModelItem {
+		return "", true
+	}
+	if token.Data == "a" {
+		if m := catalogLinkRegex.FindStringSubmatch(href); m != nil {
+			return m[1], true
+		}
+	}
+	return "", false
+}
+
+// parseCatalogPage 用 HTML 分词器解析 ollama.com 的模型目录或搜索页面，每个模型卡片作为一个整体提取
+func parseCatalogPage(r io.Reader) []CatalogModel {
+	tokenizer := html.NewTokenizer(r)
+	var models []CatalogModel
+	seen := make(map[string]bool)
+	var card *catalogCardParser
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return models
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			if card == nil {
+				if name, ok := catalogCardStart(token); ok {
+					card = newCatalogCardParser(name)
+					card.start(token)
+				}
+				continue
+			}
+			// <li x-test-model> 中的链接提供模型名称
+			if card.model.Name == "" && token.Data == "a" {
+				if name, ok := catalogCardStart(token); ok {
+					card.model.Name = name
+				}
+			}
+			if htmlVoidElements[token.Data] {
+				continue
+			}
+			card.start(token)
+		case html.EndTagToken:
+			if card == nil {
+				continue
+			}
+			if card.end() {
+				model := card.finish()
+				card = nil
+				if model.Name != "" && !seen[model.Name] {
+					seen[model.Name] = true
+					models = append(models, model)
+				}
+			}
+		case html.TextToken:
+			if card != nil {
+				card.addText(string(tokenizer.Text()))
+			}
+		}
+	}
+}
+
+// catalogModelMap 转换为在线模型列表使用的 map 格式
+func catalogModelMap(model CatalogModel) map[string]interface{} {
+	details := map[string]interface{}{
+		"format": "gguf",
+	}
+	if len(model.Sizes) > 0 {
+		details["parameter_size"] = strings.ToUpper(model.Sizes[0])
+	}
+	if model.Quantization != "" {
+		details["quantization_level"] = strings.ToUpper(model.Quantization)
+	}
+
+	pulls := "N/A"
+	if model.Pulls != "" {
+		pulls = model.Pulls + " Pulls"
+	}
+	tags := "N/A"
+	if model.TagCount > 0 {
+		tags = strconv.Itoa(model.TagCount)
+	}
+
+	return map[string]interface{}{
+		"name":         model.Name,
+		"description":  model.Description,
+		"pulls":        pulls,
+		"pull_count":   model.PullCount,
+		"tags_count":   tags,
+		"capabilities": model.Capabilities,
+		"sizes":        model.Sizes,
+		"updated":      model.Updated,
+		"source":       model.Source,
+		"details":      details,
+	}
+}
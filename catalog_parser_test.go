@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseCatalogPage(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []CatalogModel
+	}{
+		{
+			fixture: "testdata/ollama_library.html",
+			want: []CatalogModel{
+				{
+					Name:         "llama3.1",
+					Description:  "Llama 3.1 is a new state-of-the-art model from Meta available in 8B, 70B and 405B parameter sizes.",
+					Capabilities: []string{"tools"},
+					Sizes:        []string{"8b", "70b", "405b"},
+					Pulls:        "103.4M",
+					PullCount:    103400000,
+					TagCount:     93,
+					Updated:      "10 months ago",
+				},
+				{
+					Name:         "qwen2.5vl",
+					Description:  "Flagship vision-language model of Qwen and also a significant leap from the previous Qwen2-VL.",
+					Capabilities: []string{"vision"},
+					Sizes:        []string{"3b", "7b", "32b", "72b"},
+					Pulls:        "1.1M",
+					PullCount:    1100000,
+					TagCount:     17,
+					Updated:      "3 months ago",
+				},
+				{
+					Name:         "nomic-embed-text",
+					Description:  "A high-performing open embedding model with a large token context window.",
+					Capabilities: []string{"embedding"},
+					Pulls:        "35.2M",
+					PullCount:    35200000,
+					TagCount:     3,
+					Updated:      "1 year ago",
+				},
+			},
+		},
+		{
+			// 第二个卡片没有 x-test-* 标记，从卡片文本中提取；页脚中的模型链接不是卡片
+			fixture: "testdata/ollama_search.html",
+			want: []CatalogModel{
+				{
+					Name:         "qwen3",
+					Description:  "Qwen3 is the latest generation of large language models in Qwen series, offering a comprehensive suite of dense and mixture-of-experts (MoE) models.",
+					Capabilities: []string{"tools", "thinking"},
+					Sizes:        []string{"0.6b", "14b", "30b", "235b"},
+					Pulls:        "4.6M",
+					PullCount:    4600000,
+					TagCount:     35,
+					Updated:      "2 weeks ago",
+				},
+				{
+					Name:         "qwen2.5-coder",
+					Description:  "The latest series of Code-Specific Qwen models, with significant improvements in code generation, code reasoning, and code fixing.",
+					Capabilities: []string{"tools"},
+					Sizes:        []string{"1.5b", "7b", "32b"},
+					Pulls:        "6.9M",
+					PullCount:    6900000,
+					TagCount:     199,
+					Updated:      "5 months ago",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got := parseCatalogPage(f)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d models, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("model %d:\n got  %+v\n want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCatalogCount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"91.2M", 91200000},
+		{"1.5K", 1500},
+		{"2B", 2000000000},
+		{"842", 842},
+		{" 3k ", 3000},
+		{"", 0},
+		{"N/A", 0},
+	}
+	for _, tt := range tests {
+		if got := parseCatalogCount(tt.in); got != tt.want {
+			t.Errorf("parseCatalogCount(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
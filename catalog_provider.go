@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 在线模型目录来源，由 OLLAMA_CATALOG_SOURCE 选择，未设置时跟随模型下载源 OLLAMA_MODEL_SOURCE
+const (
+	CatalogSourceOllama     = "ollama"     // ollama.com 官方模型库
+	CatalogSourceModelScope = "modelscope" // 魔搭社区的 GGUF 模型
+	CatalogSourceFile       = "file"       // 本地 JSON 文件，用于离线或内网环境
+)
+
+// 魔搭社区的默认地址和每次获取的模型数
+const (
+	defaultModelScopeURL   = "https://modelscope.cn"
+	modelScopeRegistryHost = "modelscope.cn"
+	modelScopePageSize     = 100
+)
+
+// CatalogProvider 在线模型目录的来源，返回的模型统一为 CatalogModel
+type CatalogProvider interface {
+	// Name 来源名称，与 OLLAMA_CATALOG_SOURCE 的取值一致
+	Name() string
+	// List 获取目录中的所有模型
+	List() ([]CatalogModel, error)
+	// Search 按关键词搜索模型
+	Search(query string) ([]CatalogModel, error)
+}
+
+// validateCatalogSource 校验 OLLAMA_CATALOG_SOURCE 的取值，空值表示跟随模型下载源
+func validateCatalogSource(source string) error {
+	switch source {
+	case "", CatalogSourceOllama, CatalogSourceModelScope, CatalogSourceFile:
+		return nil
+	}
+	return fmt.Errorf("未知的模型目录来源: %s（可选 %s、%s、%s）", source, CatalogSourceOllama, CatalogSourceModelScope, CatalogSourceFile)
+}
+
+// catalogSource 当前使用的目录来源名称
+// 未单独设置时跟随模型下载源：从魔搭社区下载模型时也从魔搭社区浏览模型，其他下载源使用 ollama.com。
+// 配置文件中的无效取值按未设置处理，由 GetCatalogSources 报告
+func (a *App) catalogSource() string {
+	if source := a.getConfigString("OLLAMA_CATALOG_SOURCE", ""); validateCatalogSource(source) == nil && source != "" {
+		return source
+	}
+	if a.getConfigString("OLLAMA_MODEL_SOURCE", "") == "modelscope" {
+		return CatalogSourceModelScope
+	}
+	return CatalogSourceOllama
+}
+
+// catalogProvider 根据设置返回当前使用的目录来源
+func (a *App) catalogProvider() CatalogProvider {
+	switch a.catalogSource() {
+	case CatalogSourceModelScope:
+		return &modelScopeCatalogProvider{app: a}
+	case CatalogSourceFile:
+		return &fileCatalogProvider{path: a.getConfigString("OLLAMA_CATALOG_FILE", "")}
+	default:
+		return &ollamaCatalogProvider{app: a}
+	}
+}
+
+// setCatalogSource 设置目录中每个模型的来源
+func setCatalogSource(models []CatalogModel, source string) []CatalogModel {
+	for i := range models {
+		models[i].Source = source
+	}
+	return models
+}
+
+// ollamaCatalogProvider 解析 ollama.com 的模型库和搜索页面
+type ollamaCatalogProvider struct {
+	app *App
+}
+
+func (p *ollamaCatalogProvider) Name() string {
+	return CatalogSourceOllama
+}
+
+func (p *ollamaCatalogProvider) List() ([]CatalogModel, error) {
+	models, err := p.app.fetchCatalogPage(p.app.libraryURL() + "/library")
+	return setCatalogSource(models, CatalogSourceOllama), err
+}
+
+func (p *ollamaCatalogProvider) Search(query string) ([]CatalogModel, error) {
+	models, err := p.app.fetchCatalogPage(p.app.libraryURL() + "/search?q=" + url.QueryEscape(query))
+	return setCatalogSource(models, CatalogSourceOllama), err
+}
+
+// modelScopeCatalogProvider 通过魔搭社区的模型搜索接口获取 GGUF 模型
+// 模型名称为 modelscope.cn/<组织>/<模型>，可以直接用 ollama pull 拉取
+type modelScopeCatalogProvider struct {
+	app *App
+}
+
+// modelScopeSearchResponse 魔搭社区模型搜索接口的响应
+type modelScopeSearchResponse struct {
+	Code    int    `json:"Code"`
+	Message string `json:"Message"`
+	Data    struct {
+		Model struct {
+			Models     []modelScopeModel `json:"Models"`
+			TotalCount int               `json:"TotalCount"`
+		} `json:"Model"`
+	} `json:"Data"`
+}
+
+// modelScopeModel 魔搭社区的一个模型
+type modelScopeModel struct {
+	Name            string `json:"Name"`
+	Path            string `json:"Path"` // 组织名称
+	ChineseName     string `json:"ChineseName"`
+	Description     string `json:"Description"`
+	Downloads       int64  `json:"Downloads"`
+	LastUpdatedTime int64  `json:"LastUpdatedTime"`
+	Tasks           []struct {
+		Name string `json:"Name"`
+	} `json:"Tasks"`
+}
+
+// 魔搭社区的任务类型对应的能力标签
+var modelScopeTaskCapabilities = map[string]string{
+	"image-text-to-text":        "vision",
+	"visual-question-answering": "vision",
+	"sentence-embedding":        "embedding",
+	"feature-extraction":        "embedding",
+	"sentence-similarity":       "embedding",
+}
+
+// 从模型名称中提取参数规模，例如 Qwen2.5-7B-Instruct-GGUF -> 7b
+var modelNameSizeRegex = regexp.MustCompile(`(?i)(?:^|[-_])((?:\d+x)?\d+(?:\.\d+)?[bm])(?:[-_]|$)`)
+
+// 从模型名称中提取量化类型，例如 Qwen2.5-7B-Instruct-Q4_K_M-GGUF -> q4_k_m
+var modelNameQuantizationRegex = regexp.MustCompile(`(?i)(?:^|[-_.])(i?q\d(?:_[a-z0-9]+)*|fp16|bf16|f16|f32)(?:[-_.]|$)`)
+
+func (p *modelScopeCatalogProvider) Name() string {
+	return CatalogSourceModelScope
+}
+
+func (p *modelScopeCatalogProvider) List() ([]CatalogModel, error) {
+	return p.Search("")
+}
+
+func (p *modelScopeCatalogProvider) Search(query string) ([]CatalogModel, error) {
+	endpoint := strings.TrimRight(p.app.getConfigString("OLLAMA_MODELSCOPE_URL", defaultModelScopeURL), "/") + "/api/v1/dolphin/models"
+	var models []CatalogModel
+	err := p.app.fetchCached(endpoint+"?q="+url.QueryEscape(query), &models, func() (interface{}, error) {
+		return searchModelScope(endpoint, query)
+	})
+	return models, err
+}
+
+// searchModelScope 调用魔搭社区的模型搜索接口，只返回 GGUF 格式的模型
+func searchModelScope(endpoint, query string) ([]CatalogModel, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"PageSize":   modelScopePageSize,
+		"PageNumber": 1,
+		"SortBy":     "Default",
+		"Target":     "",
+		"Name":       query,
+		"Criterion": []map[string]interface{}{
+			{"category": "libraries", "predicate": "contains", "values": []string{"GGUF"}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: catalogRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("魔搭社区返回状态码: %d", resp.StatusCode)
+	}
+
+	var result modelScopeSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析魔搭社区响应失败: %v", err)
+	}
+	if result.Code != 0 && result.Code != http.StatusOK {
+		return nil, fmt.Errorf("魔搭社区返回错误: %s", result.Message)
+	}
+
+	models := make([]CatalogModel, 0, len(result.Data.Model.Models))
+	for _, item := range result.Data.Model.Models {
+		if item.Path == "" || item.Name == "" {
+			continue
+		}
+		model := CatalogModel{
+			Name:        modelScopeRegistryHost + "/" + item.Path + "/" + item.Name,
+			Description: item.Description,
+			PullCount:   item.Downloads,
+			Pulls:       formatCatalogCount(item.Downloads),
+			Source:      CatalogSourceModelScope,
+		}
+		if model.Description == "" {
+			model.Description = item.ChineseName
+		}
+		if m := modelNameSizeRegex.FindStringSubmatch(item.Name); m != nil {
+			model.Sizes = []string{strings.ToLower(m[1])}
+		}
+		model.Quantization = modelNameQuantization(item.Name)
+		for _, task := range item.Tasks {
+			if capability, ok := modelScopeTaskCapabilities[task.Name]; ok && !containsString(model.Capabilities, capability) {
+				model.Capabilities = append(model.Capabilities, capability)
+			}
+		}
+		if item.LastUpdatedTime > 0 {
+			model.Updated = time.Unix(item.LastUpdatedTime, 0).Format("2006-01-02")
+		}
+		models = append(models, model)
+	}
+	if len(models) == 0 && query == "" {
+		return nil, fmt.Errorf("魔搭社区没有返回任何模型")
+	}
+	return models, nil
+}
+
+// modelNameQuantization 从模型名称中提取量化类型，名称中没有时返回空字符串
+func modelNameQuantization(name string) string {
+	if m := modelNameQuantizationRegex.FindStringSubmatch(name); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// formatCatalogCount 将数量格式化为 1.5K、91.2M 这样的形式，与 ollama.com 的显示一致
+func formatCatalogCount(count int64) string {
+	switch {
+	case count >= 1e9:
+		return strconv.FormatFloat(float64(count)/1e9, 'f', 1, 64) + "B"
+	case count >= 1e6:
+		return strconv.FormatFloat(float64(count)/1e6, 'f', 1, 64) + "M"
+	case count >= 1e3:
+		return strconv.FormatFloat(float64(count)/1e3, 'f', 1, 64) + "K"
+	default:
+		return strconv.FormatInt(count, 10)
+	}
+}
+
+// fileCatalogProvider 从本地 JSON 文件读取模型目录，文件内容为 CatalogModel 数组或 {"models": [...]}
+type fileCatalogProvider struct {
+	path string
+}
+
+func (p *fileCatalogProvider) Name() string {
+	return CatalogSourceFile
+}
+
+func (p *fileCatalogProvider) List() ([]CatalogModel, error) {
+	if p.path == "" {
+		return nil, fmt.Errorf("未设置模型目录文件 OLLAMA_CATALOG_FILE")
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取模型目录文件失败: %v", err)
+	}
+
+	var models []CatalogModel
+	if err := json.Unmarshal(data, &models); err != nil {
+		var wrapped struct {
+			Models []CatalogModel `json:"models"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("解析模型目录文件失败: %v", err)
+		}
+		models = wrapped.Models
+	}
+
+	valid := models[:0]
+	for _, model := range models {
+		if strings.TrimSpace(model.Name) == "" {
+			continue
+		}
+		if model.PullCount == 0 {
+			model.PullCount = parseCatalogCount(model.Pulls)
+		}
+		valid = append(valid, model)
+	}
+	return setCatalogSource(valid, CatalogSourceFile), nil
+}
+
+func (p *fileCatalogProvider) Search(query string) ([]CatalogModel, error) {
+	models, err := p.List()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matched []CatalogModel
+	for _, model := range models {
+		if strings.Contains(strings.ToLower(model.Name), query) || strings.Contains(strings.ToLower(model.Description), query) {
+			matched = append(matched, model)
+		}
+	}
+	return matched, nil
+}
+
+// GetCatalogSources 获取可选的在线模型目录来源和当前使用的来源
+func (a *App) GetCatalogSources() map[string]interface{} {
+	configured := a.getConfigString("OLLAMA_CATALOG_SOURCE", "")
+	result := map[string]interface{}{
+		"current":             a.catalogProvider().Name(),
+		"follow_model_source": configured == "",
+		"sources": []map[string]string{
+			{"name": CatalogSourceOllama, "description": "Ollama 官方模型库 (ollama.com)"},
+			{"name": CatalogSourceModelScope, "description": "魔搭社区 GGUF 模型 (modelscope.cn)"},
+			{"name": CatalogSourceFile, "description": "本地 JSON 模型目录文件 (OLLAMA_CATALOG_FILE)"},
+		},
+	}
+	if err := validateCatalogSource(configured); err != nil {
+		result["follow_model_source"] = true
+		result["error"] = fmt.Sprintf("%v，已跟随模型下载源", err)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestModelNameQuantization(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Qwen2.5-7B-Instruct-GGUF", ""},
+		{"Qwen2.5-7B-Instruct-Q4_K_M-GGUF", "q4_k_m"},
+		{"Meta-Llama-3.1-8B-Instruct.Q8_0", "q8_0"},
+		{"gemma-2-9b-it-IQ4_XS", "iq4_xs"},
+		{"DeepSeek-R1-Distill-Qwen-7B-fp16-GGUF", "fp16"},
+		{"Qwen3-8B-GGUF", ""},
+	}
+	for _, tt := range tests {
+		if got := modelNameQuantization(tt.name); got != tt.want {
+			t.Errorf("modelNameQuantization(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		modelSource string
+		want        string
+		wantErr     bool
+	}{
+		{"未设置", "", "", CatalogSourceOllama, false},
+		{"跟随模型下载源", "", "modelscope", CatalogSourceModelScope, false},
+		{"魔搭社区", "modelscope", "", CatalogSourceModelScope, false},
+		{"本地文件", "file", "modelscope", CatalogSourceFile, false},
+		{"无效取值跟随模型下载源", "ollama.com", "modelscope", CatalogSourceModelScope, true},
+		{"取值区分大小写", "Ollama", "", CatalogSourceOllama, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCatalogSource(tt.source); (err != nil) != tt.wantErr {
+				t.Errorf("validateCatalogSource(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			}
+			a := &App{environmentVariables: map[string]interface{}{
+				"OLLAMA_CATALOG_SOURCE": tt.source,
+				"OLLAMA_MODEL_SOURCE":   tt.modelSource,
+			}}
+			if got := a.catalogSource(); got != tt.want {
+				t.Errorf("catalogSource() = %q, want %q", got, tt.want)
+			}
+			if _, reported := a.GetCatalogSources()["error"]; reported != tt.wantErr {
+				t.Errorf("GetCatalogSources() error reported = %v, want %v", reported, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// OnlineModelTag 在线模型库中一个模型的标签（版本）
+type OnlineModelTag struct {
+	Name          string    `json:"name"` // 完整名称，如 qwen3:14b，可直接用于 PullModel
+	Tag           string    `json:"tag"`
+	Size          string    `json:"size,omitempty"` // 页面显示的大小，如 9.3GB
+	SizeBytes     int64     `json:"size_bytes"`
+	Quantization  string    `json:"quantization,omitempty"`
+	ContextLength string    `json:"context_length,omitempty"` // 如 40K
+	Digest        string    `json:"digest,omitempty"`         // 清单 digest 的前 12 位，相同表示同一个模型文件
+	Updated       string    `json:"updated,omitempty"`
+	Fit           *ModelFit `json:"fit,omitempty"`
+}
+
+// 标签页面中每一行的信息
+var (
+	tagDigestRegex  = regexp.MustCompile(`\b([0-9a-f]{12})\b`)
+	tagSizeRegex    = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?\s*[KMGT]B)\b`)
+	tagContextRegex = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?[KM])\s+context`)
+	tagCountRegex   = regexp.MustCompile(`^\d+(?:\.\d+)?[KM]$`)
+	tagUpdatedRegex = regexp.MustCompile(`(?i)\b((?:\d+|an?|about \d+)\s+\w+\s+ago|yesterday)\b`)
+	// 标签末尾的量化类型，如 14b-instruct-q4_K_M、7b-fp16
+	tagQuantizationRegex = regexp.MustCompile(`(?i)(?:^|-)((?:i?q\d[a-z0-9_]*)|fp16|bf16|fp32|f16|f32)$`)
+)
+
+// onlineModelPath 模型在在线模型库中的路径，官方模型为 /library/<model>，用户模型为 /<namespace>/<model>
+func onlineModelPath(name string) (string, error) {
+	ref := parseModelReference(name)
+	if ref.Host != defaultModelRegistry {
+		return "", fmt.Errorf("只支持 Ollama 官方模型库中的模型: %s", name)
+	}
+	return "/" + ref.Namespace + "/" + ref.Model, nil
+}
+
+// tagRow 标签页面中一个标签的文本
+type tagRow struct {
+	tag  string
+	text []string
+}
+
+// parseModelTagsPage 用 HTML 分词器解析模型的标签页面
+// 每个指向 <path>:<tag> 的链接开始一行，直到下一个标签链接之前的文本都属于这一行
+func parseModelTagsPage(r io.Reader, modelPath string) []OnlineModelTag {
+	tokenizer := html.NewTokenizer(r)
+	rows := make(map[string]*tagRow)
+	var order []string
+	var current *tagRow
+	inFooter := false
+
+	prefix := modelPath + ":"
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return buildModelTags(rows, order, modelPath)
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			// 页脚中可能也有指向其他标签的链接，不属于标签列表
+			if token.Data == "footer" {
+				current = nil
+				inFooter = true
+				continue
+			}
+			if token.Data != "a" || inFooter {
+				continue
+			}
+			for _, attr := range token.Attr {
+				if attr.Key != "href" || !strings.HasPrefix(attr.Val, prefix) {
+					continue
+				}
+				tag := strings.TrimPrefix(attr.Val, prefix)
+				if tag == "" || strings.ContainsAny(tag, "/?#") {
+					continue
+				}
+				// 页面可能为不同屏幕宽度重复同一行，合并到已有的行
+				row, ok := rows[tag]
+				if !ok {
+					row = &tagRow{tag: tag}
+					rows[tag] = row
+					order = append(order, tag)
+				}
+				current = row
+			}
+		case html.TextToken:
+			if current == nil {
+				continue
+			}
+			if text := strings.Join(strings.Fields(string(tokenizer.Text())), " "); text != "" {
+				current.text = append(current.text, text)
+			}
+		}
+	}
+}
+
+// buildModelTags 从每一行的文本中提取标签信息
+func buildModelTags(rows map[string]*tagRow, order []string, modelPath string) []OnlineModelTag {
+	name := strings.TrimPrefix(modelPath, "/library/")
+	name = strings.TrimPrefix(name, "/")
+
+	tags := make([]OnlineModelTag, 0, len(order))
+	quantizationByDigest := make(map[string]string)
+	for _, tag := range order {
+		row := rows[tag]
+		// 第一段文本通常是标签名称本身，不参与提取
+		var texts []string
+		for _, text := range row.text {
+			if text != name+":"+tag && text != tag {
+				texts = append(texts, text)
+			}
+		}
+		joined := strings.Join(texts, " ")
+
+		item := OnlineModelTag{Name: name + ":" + tag, Tag: tag}
+		if m := tagDigestRegex.FindStringSubmatch(joined); m != nil {
+			item.Digest = m[1]
+		}
+		if m := tagSizeRegex.FindStringSubmatch(joined); m != nil {
+			item.Size = strings.ReplaceAll(m[1], " ", "")
+			if size, err := parseByteSize(item.Size); err == nil {
+				item.SizeBytes = size
+			}
+		}
+		if m := tagContextRegex.FindStringSubmatch(joined); m != nil {
+			item.ContextLength = strings.ToUpper(m[1])
+		} else {
+			for _, text := range texts {
+				if tagCountRegex.MatchString(text) {
+					item.ContextLength = strings.ToUpper(text)
+					break
+				}
+			}
+		}
+		if m := tagUpdatedRegex.FindStringSubmatch(joined); m != nil {
+			item.Updated = m[1]
+		}
+		if m := tagQuantizationRegex.FindStringSubmatch(tag); m != nil {
+			item.Quantization = m[1]
+			if item.Digest != "" {
+				quantizationByDigest[item.Digest] = m[1]
+			}
+		}
+		tags = append(tags, item)
+	}
+
+	// latest、14b 这样的标签是其他标签的别名，从 digest 相同的标签得到量化类型
+	for i := range tags {
+		if tags[i].Quantization == "" && tags[i].Digest != "" {
+			tags[i].Quantization = quantizationByDigest[tags[i].Digest]
+		}
+	}
+	return tags
+}
+
+// GetOnlineModelTags 获取在线模型的所有标签及其大小、量化类型、上下文长度和 digest
+func (a *App) GetOnlineModelTags(name string) map[string]interface{} {
+	name, err := validateModelName(name)
+	if err == nil {
+		// 只需要模型名称，忽略传入的标签
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			name = name[:i]
+		}
+	}
+	var modelPath string
+	if err == nil {
+		modelPath, err = onlineModelPath(name)
+	}
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+
+	pageURL := a.libraryURL() + modelPath + "/tags"
+	var tags []OnlineModelTag
+	err = a.fetchCachedPage(pageURL, &tags, func(r io.Reader) (interface{}, error) {
+		parsed := parseModelTagsPage(r, modelPath)
+		if len(parsed) == 0 {
+			return nil, fmt.Errorf("未能从页面解析到任何标签")
+		}
+		return parsed, nil
+	})
+	if err != nil {
+		log.Printf("获取模型标签失败: %s, %v", name, err)
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("获取模型标签失败: %v", err),
+		}
+	}
+
+	profile := a.getHardwareProfile()
+	for i := range tags {
+		shape := modelShape{
+			FileSize:     tags[i].SizeBytes,
+			Quantization: tags[i].Quantization,
+			Parameters:   parseParameterSize(strings.Split(tags[i].Tag, "-")[0]),
+		}
+		fit := a.estimateModelFit(shape, profile)
+		tags[i].Fit = &fit
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"model":   name,
+		"tags":    tags,
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseModelTagsPage(t *testing.T) {
+	f, err := os.Open("testdata/ollama_tags.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// latest 与 8b-q4_K_M 的 digest 相同，量化类型从后者得到；页脚中的链接不是标签
+	want := []OnlineModelTag{
+		{Name: "qwen3:latest", Tag: "latest", Size: "5.2GB", SizeBytes: 5200000000, Quantization: "q4_K_M", ContextLength: "40K", Digest: "500a1f067a9f", Updated: "3 months ago"},
+		{Name: "qwen3:14b", Tag: "14b", Size: "9.3GB", SizeBytes: 9300000000, ContextLength: "40K", Digest: "bdbd181c33f2", Updated: "3 months ago"},
+		{Name: "qwen3:8b-q4_K_M", Tag: "8b-q4_K_M", Size: "5.2GB", SizeBytes: 5200000000, Quantization: "q4_K_M", ContextLength: "40K", Digest: "500a1f067a9f", Updated: "3 months ago"},
+		{Name: "qwen3:14b-fp16", Tag: "14b-fp16", Size: "30GB", SizeBytes: 30000000000, Quantization: "fp16", ContextLength: "40K", Digest: "7a3ccd5b6cb8", Updated: "2 weeks ago"},
+	}
+
+	got := parseModelTagsPage(f, "/library/qwen3")
+	if len(got) != len(want) {
+		t.Fatalf("got %d tags, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tag %d:\n got  %+v\n want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOnlineModelPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "qwen3", want: "/library/qwen3"},
+		{name: "library/qwen3", want: "/library/qwen3"},
+		{name: "jmorgan/llava", want: "/jmorgan/llava"},
+		{name: "hf.co/bartowski/Llama-3.2-1B-Instruct-GGUF", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := onlineModelPath(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("onlineModelPath(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
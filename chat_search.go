@@ -0,0 +1,476 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 搜索结果片段在匹配内容前后保留的字符数
+const (
+	chatSnippetBefore = 30
+	chatSnippetLength = 120
+)
+
+// 带引号的短语，例如 "context window"
+var chatPhraseRegex = regexp.MustCompile(`"([^"]+)"`)
+
+// ChatSearchQuery 聊天记录搜索条件
+type ChatSearchQuery struct {
+	Query     string `json:"query"`      // 关键词，多个关键词同时匹配，引号中的内容按短语匹配
+	SessionID string `json:"session_id"` // 只搜索指定会话
+	Model     string `json:"model"`      // 只搜索指定模型的消息
+	Role      string `json:"role"`       // 只搜索指定角色的消息，如 user、assistant
+	From      string `json:"from"`       // 开始日期，如 2024-05-01
+	To        string `json:"to"`         // 结束日期（包含当天）
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
+
+// ChatSearchHit 一条匹配的消息
+type ChatSearchHit struct {
+	SessionID   string   `json:"session_id"`
+	SessionName string   `json:"session_name"`
+	MessageID   string   `json:"message_id"`
+	Role        string   `json:"role"`
+	Model       string   `json:"model"`
+	CreatedAt   int64    `json:"created_at"`
+	Snippet     string   `json:"snippet"`
+	Highlights  [][2]int `json:"highlights"` // 片段中匹配内容的起止位置（按字符计）
+	Score       float64  `json:"score"`
+
+	doc int // 索引中的消息编号，用于生成片段
+}
+
+// searchToken 分词结果，pos 为词的位置，start 和 end 为在原文中的字符位置
+type searchToken struct {
+	term       string
+	pos        int
+	start, end int
+}
+
+// chatSearchDoc 索引中的一条消息
+type chatSearchDoc struct {
+	sessionID string
+	messageID string
+	role      string
+	model     string
+	createdAt int64
+	content   string
+	terms     []string // 消息包含的词，删除时使用
+}
+
+// chatSearchIndex 聊天记录的倒排索引，由 chatSessionsMutex 保护
+type chatSearchIndex struct {
+	docs        map[int]*chatSearchDoc
+	nextID      int
+	postings    map[string]map[int][]int // 词 -> 消息 -> 词在消息中的位置
+	sessionDocs map[string][]int
+}
+
+func newChatSearchIndex() *chatSearchIndex {
+	return &chatSearchIndex{
+		docs:        make(map[int]*chatSearchDoc),
+		postings:    make(map[string]map[int][]int),
+		sessionDocs: make(map[string][]int),
+	}
+}
+
+// isCJK 中日韩文字没有空格分词，按相邻两个字（bigram）索引
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenizeSearchText 分词：中日韩文字按 bigram 切分，其他文字按字母和数字组成的词切分，统一转为小写
+// 索引时中日韩文字同时索引单字，以便搜索单个字；查询时只有单字的词才使用单字
+// 每个中日韩文字占一个位置，每个词占一个位置，短语匹配时要求位置连续
+func tokenizeSearchText(text string, query bool) []searchToken {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var tokens []searchToken
+	pos := 0
+	for i := 0; i < len(lower); {
+		r := lower[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(lower) && isCJK(lower[j]) {
+				j++
+			}
+			for k := i; k < j; k++ {
+				p := pos + k - i
+				if k+1 < j {
+					tokens = append(tokens, searchToken{term: string(lower[k : k+2]), pos: p, start: k, end: k + 2})
+				}
+				if !query || j-i == 1 {
+					tokens = append(tokens, searchToken{term: string(lower[k]), pos: p, start: k, end: k + 1})
+				}
+			}
+			pos += j - i
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(lower) && (unicode.IsLetter(lower[j]) || unicode.IsDigit(lower[j])) && !isCJK(lower[j]) {
+				j++
+			}
+			tokens = append(tokens, searchToken{term: string(lower[i:j]), pos: pos, start: i, end: j})
+			pos++
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// chatPhrase 查询中的一个关键词或短语，offsets 为各个词相对于第一个词的位置
+type chatPhrase struct {
+	terms   []string
+	offsets []int
+}
+
+// parseChatQuery 解析查询：引号中的内容为短语，其余按空格分成关键词
+// 中文关键词切分出的多个 bigram 同样按短语匹配，保证匹配的是连续的文字
+func parseChatQuery(query string) []chatPhrase {
+	var parts []string
+	for _, m := range chatPhraseRegex.FindAllStringSubmatch(query, -1) {
+		parts = append(parts, m[1])
+	}
+	parts = append(parts, strings.Fields(chatPhraseRegex.ReplaceAllString(query, " "))...)
+
+	var phrases []chatPhrase
+	for _, part := range parts {
+		tokens := tokenizeSearchText(part, true)
+		if len(tokens) == 0 {
+			continue
+		}
+		var phrase chatPhrase
+		for _, token := range tokens {
+			phrase.terms = append(phrase.terms, token.term)
+			phrase.offsets = append(phrase.offsets, token.pos-tokens[0].pos)
+		}
+		phrases = append(phrases, phrase)
+	}
+	return phrases
+}
+
+// removeSession 从索引中删除会话的所有消息
+func (idx *chatSearchIndex) removeSession(sessionID string) {
+	for _, id := range idx.sessionDocs[sessionID] {
+		doc := idx.docs[id]
+		for _, term := range doc.terms {
+			delete(idx.postings[term], id)
+			if len(idx.postings[term]) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+		delete(idx.docs, id)
+	}
+	delete(idx.sessionDocs, sessionID)
+}
+
+// updateSession 重新索引会话的消息
+func (idx *chatSearchIndex) updateSession(session *ChatSession, messages []SessionMessage) {
+	idx.removeSession(session.ID)
+	for _, message := range messages {
+		if strings.TrimSpace(message.Content) == "" {
+			continue
+		}
+		id := idx.nextID
+		idx.nextID++
+
+		doc := &chatSearchDoc{
+			sessionID: session.ID,
+			messageID: message.ID,
+			role:      message.Role,
+			model:     message.Model,
+			createdAt: message.CreatedAt,
+			content:   message.Content,
+		}
+		if doc.model == "" {
+			doc.model = session.Model
+		}
+		for _, token := range tokenizeSearchText(message.Content, false) {
+			positions, ok := idx.postings[token.term]
+			if !ok {
+				positions = make(map[int][]int)
+				idx.postings[token.term] = positions
+			}
+			if _, seen := positions[id]; !seen {
+				doc.terms = append(doc.terms, token.term)
+			}
+			positions[id] = append(positions[id], token.pos)
+		}
+		idx.docs[id] = doc
+		idx.sessionDocs[session.ID] = append(idx.sessionDocs[session.ID], id)
+	}
+}
+
+// containsPosition 判断有序的位置列表中是否包含指定位置
+func containsPosition(positions []int, pos int) bool {
+	i := sort.SearchInts(positions, pos)
+	return i < len(positions) && positions[i] == pos
+}
+
+// matchPhrase 返回短语在各条消息中出现的次数
+func (idx *chatSearchIndex) matchPhrase(phrase chatPhrase) map[int]int {
+	// 从出现次数最少的词开始查找候选消息
+	rarest := 0
+	for i, term := range phrase.terms {
+		if len(idx.postings[term]) < len(idx.postings[phrase.terms[rarest]]) {
+			rarest = i
+		}
+	}
+
+	matches := make(map[int]int)
+	for id, positions := range idx.postings[phrase.terms[rarest]] {
+		count := 0
+		for _, pos := range positions {
+			start := pos - phrase.offsets[rarest]
+			matched := true
+			for i, term := range phrase.terms {
+				if i != rarest && !containsPosition(idx.postings[term][id], start+phrase.offsets[i]) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				count++
+			}
+		}
+		if count > 0 {
+			matches[id] = count
+		}
+	}
+	return matches
+}
+
+// idf 短语的逆文档频率，按其中最少见的词计算
+func (idx *chatSearchIndex) idf(phrase chatPhrase) float64 {
+	df := len(idx.docs)
+	for _, term := range phrase.terms {
+		if n := len(idx.postings[term]); n < df {
+			df = n
+		}
+	}
+	return math.Log(1 + float64(len(idx.docs))/float64(df+1))
+}
+
+// chatSnippet 截取消息中第一处匹配附近的内容，并返回片段中所有匹配的位置
+func chatSnippet(content string, phrases []chatPhrase) (string, [][2]int) {
+	tokens := tokenizeSearchText(content, false)
+	byPos := make(map[int]map[string]searchToken)
+	for _, token := range tokens {
+		if byPos[token.pos] == nil {
+			byPos[token.pos] = make(map[string]searchToken)
+		}
+		byPos[token.pos][token.term] = token
+	}
+
+	var ranges [][2]int
+	for _, phrase := range phrases {
+		for _, first := range tokens {
+			if first.term != phrase.terms[0] {
+				continue
+			}
+			start, end := first.start, first.end
+			matched := true
+			for i := 1; i < len(phrase.terms); i++ {
+				token, ok := byPos[first.pos+phrase.offsets[i]][phrase.terms[i]]
+				if !ok {
+					matched = false
+					break
+				}
+				if token.end > end {
+					end = token.end
+				}
+			}
+			if matched {
+				ranges = append(ranges, [2]int{start, end})
+			}
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	// 合并重叠的匹配
+	var merged [][2]int
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			if r[1] > merged[n-1][1] {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	runes := []rune(strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(content))
+	start := 0
+	if len(merged) > 0 && merged[0][0] > chatSnippetBefore {
+		start = merged[0][0] - chatSnippetBefore
+	}
+	end := start + chatSnippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(runes) {
+		suffix = "…"
+	}
+	offset := len([]rune(prefix)) - start
+
+	highlights := [][2]int{}
+	for _, r := range merged {
+		if r[0] >= end {
+			break
+		}
+		if r[1] > end {
+			r[1] = end
+		}
+		highlights = append(highlights, [2]int{r[0] + offset, r[1] + offset})
+	}
+	return prefix + string(runes[start:end]) + suffix, highlights
+}
+
+// parseChatSearchDate 解析日期筛选条件，返回当天零点的毫秒时间戳
+func parseChatSearchDate(value string) (int64, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return 0, false, fmt.Errorf("无效的日期: %s，格式应为 2006-01-02", value)
+	}
+	return t.UnixMilli(), true, nil
+}
+
+// ensureChatSearchIndexLocked 首次搜索时从会话文件建立索引，调用方需持有 chatSessionsMutex
+func (a *App) ensureChatSearchIndexLocked() *chatSearchIndex {
+	if a.chatSearchIndex != nil {
+		return a.chatSearchIndex
+	}
+	a.loadChatSessionsLocked()
+
+	index := newChatSearchIndex()
+	for _, session := range a.chatSessions {
+		messages, err := a.readSessionMessagesLocked(session.ID)
+		if err != nil {
+			continue
+		}
+		index.updateSession(session, messages)
+	}
+	a.chatSearchIndex = index
+	return index
+}
+
+// SearchChatHistory 全文搜索聊天记录，支持短语、按会话、模型、角色和日期筛选，返回带高亮位置的片段
+func (a *App) SearchChatHistory(q ChatSearchQuery) map[string]interface{} {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = 20
+	}
+	phrases := parseChatQuery(q.Query)
+	if len(phrases) == 0 {
+		return sessionResult(fmt.Errorf("搜索关键词不能为空"), nil)
+	}
+	from, hasFrom, err := parseChatSearchDate(q.From)
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	to, hasTo, err := parseChatSearchDate(q.To)
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	// 结束日期包含当天
+	to += int64(24 * time.Hour / time.Millisecond)
+
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+	index := a.ensureChatSearchIndexLocked()
+
+	// 每条消息需要匹配所有关键词，得分为各关键词的出现次数按逆文档频率加权之和
+	scores := make(map[int]float64)
+	for i, phrase := range phrases {
+		matches := index.matchPhrase(phrase)
+		idf := index.idf(phrase)
+		next := make(map[int]float64)
+		for id, count := range matches {
+			if _, ok := scores[id]; i > 0 && !ok {
+				continue
+			}
+			next[id] = scores[id] + (1+math.Log(float64(count)))*idf
+		}
+		scores = next
+	}
+
+	role := strings.ToLower(strings.TrimSpace(q.Role))
+	var hits []ChatSearchHit
+	for id, score := range scores {
+		doc := index.docs[id]
+		switch {
+		case q.SessionID != "" && doc.sessionID != q.SessionID,
+			q.Model != "" && !sameModelName(doc.model, q.Model),
+			role != "" && doc.role != role,
+			hasFrom && doc.createdAt < from,
+			hasTo && doc.createdAt >= to:
+			continue
+		}
+		hit := ChatSearchHit{
+			SessionID: doc.sessionID,
+			MessageID: doc.messageID,
+			Role:      doc.role,
+			Model:     doc.model,
+			CreatedAt: doc.createdAt,
+			Score:     math.Round(score*1000) / 1000,
+			doc:       id,
+		}
+		if session, ok := a.chatSessions[doc.sessionID]; ok {
+			hit.SessionName = session.Name
+		}
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt > hits[j].CreatedAt
+	})
+
+	total := len(hits)
+	start := (q.Page - 1) * q.Limit
+	if start > total {
+		start = total
+	}
+	end := start + q.Limit
+	if end > total {
+		end = total
+	}
+	page := hits[start:end]
+
+	// 只为当前页生成片段
+	for i := range page {
+		page[i].Snippet, page[i].Highlights = chatSnippet(index.docs[page[i].doc].content, phrases)
+	}
+
+	return sessionResult(nil, map[string]interface{}{
+		"hits":  page,
+		"total": total,
+		"page":  q.Page,
+		"limit": q.Limit,
+	})
+}
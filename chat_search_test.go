@@ -0,0 +1,179 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tokenTerms(tokens []searchToken) []string {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.term
+	}
+	return terms
+}
+
+func TestTokenizeSearchText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query bool
+		want  []searchToken
+	}{
+		{
+			name: "混合中英文",
+			text: "用Qwen3回答",
+			want: []searchToken{
+				{term: "用", pos: 0, start: 0, end: 1},
+				{term: "qwen3", pos: 1, start: 1, end: 6},
+				{term: "回答", pos: 2, start: 6, end: 8},
+				{term: "回", pos: 2, start: 6, end: 7},
+				{term: "答", pos: 3, start: 7, end: 8},
+			},
+		},
+		{
+			name:  "查询时中文只用 bigram",
+			text:  "上下文",
+			query: true,
+			want: []searchToken{
+				{term: "上下", pos: 0, start: 0, end: 2},
+				{term: "下文", pos: 1, start: 1, end: 3},
+			},
+		},
+		{
+			name:  "查询单个字",
+			text:  "窗",
+			query: true,
+			want:  []searchToken{{term: "窗", pos: 0, start: 0, end: 1}},
+		},
+		{
+			name: "标点和大小写",
+			text: "Hello, World! num_ctx",
+			want: []searchToken{
+				{term: "hello", pos: 0, start: 0, end: 5},
+				{term: "world", pos: 1, start: 7, end: 12},
+				{term: "num", pos: 2, start: 14, end: 17},
+				{term: "ctx", pos: 3, start: 18, end: 21},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenizeSearchText(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChatQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []chatPhrase
+	}{
+		{
+			query: `"context window" gpu`,
+			want: []chatPhrase{
+				{terms: []string{"context", "window"}, offsets: []int{0, 1}},
+				{terms: []string{"gpu"}, offsets: []int{0}},
+			},
+		},
+		{
+			query: "上下文窗口",
+			want: []chatPhrase{
+				{terms: []string{"上下", "下文", "文窗", "窗口"}, offsets: []int{0, 1, 2, 3}},
+			},
+		},
+		{
+			query: "窗",
+			want:  []chatPhrase{{terms: []string{"窗"}, offsets: []int{0}}},
+		},
+		{query: `  "" , `, want: nil},
+	}
+	for _, tt := range tests {
+		if got := parseChatQuery(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChatQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestChatSearchIndexMatchPhrase(t *testing.T) {
+	idx := newChatSearchIndex()
+	idx.updateSession(&ChatSession{ID: "s1"}, []SessionMessage{
+		{ID: "m1", Role: "user", Content: "如何设置上下文窗口大小？"},
+		{ID: "m2", Role: "assistant", Content: "The context window is 2048. Window of context is different."},
+		{ID: "m3", Role: "user", Content: "上下文 窗口"},
+	})
+	messageIDs := func(matches map[int]int) map[string]int {
+		ids := make(map[string]int)
+		for id, count := range matches {
+			ids[idx.docs[id].messageID] = count
+		}
+		return ids
+	}
+
+	tests := []struct {
+		query string
+		want  map[string]int
+	}{
+		// 中文短语要求文字连续，中间有空格的 m3 不匹配
+		{query: "上下文窗口", want: map[string]int{"m1": 1}},
+		{query: "窗", want: map[string]int{"m1": 1, "m3": 1}},
+		{query: `"context window"`, want: map[string]int{"m2": 1}},
+		{query: "context", want: map[string]int{"m2": 2}},
+		{query: "missing", want: map[string]int{}},
+	}
+	for _, tt := range tests {
+		phrases := parseChatQuery(tt.query)
+		if got := messageIDs(idx.matchPhrase(phrases[0])); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchPhrase(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// 重新索引会话时替换旧消息
+	idx.updateSession(&ChatSession{ID: "s1"}, []SessionMessage{{ID: "m4", Role: "user", Content: "context"}})
+	if got := messageIDs(idx.matchPhrase(parseChatQuery("context")[0])); !reflect.DeepEqual(got, map[string]int{"m4": 1}) {
+		t.Errorf("after update: %v", got)
+	}
+	idx.removeSession("s1")
+	if len(idx.docs) != 0 || len(idx.postings) != 0 {
+		t.Errorf("after remove: %d docs, %d terms", len(idx.docs), len(idx.postings))
+	}
+}
+
+func TestChatSnippet(t *testing.T) {
+	highlighted := func(snippet string, highlights [][2]int) []string {
+		runes := []rune(snippet)
+		var parts []string
+		for _, h := range highlights {
+			parts = append(parts, string(runes[h[0]:h[1]]))
+		}
+		return parts
+	}
+
+	long := "这是一段很长的开头文字，用来把匹配的位置推到片段开始之后，确保片段需要省略号。接着才提到上下文窗口的大小，以及 context window 的设置。"
+	tests := []struct {
+		name       string
+		content    string
+		query      string
+		wantPrefix bool
+		want       []string
+	}{
+		{name: "无省略号", content: "如何设置上下文窗口大小？", query: "上下文窗口", want: []string{"上下文窗口"}},
+		{name: "有省略号", content: long, query: "上下文窗口", wantPrefix: true, want: []string{"上下文窗口"}},
+		{name: "多个短语", content: long, query: `上下文 "context window"`, wantPrefix: true, want: []string{"上下文", "context window"}},
+		{name: "单个字", content: "窗口和窗户", query: "窗", want: []string{"窗", "窗"}},
+		{name: "大小写不同", content: "The Context Window", query: "context", want: []string{"Context"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, highlights := chatSnippet(tt.content, parseChatQuery(tt.query))
+			if hasPrefix := []rune(snippet)[0] == '…'; hasPrefix != tt.wantPrefix {
+				t.Errorf("snippet %q: prefix %v, want %v", snippet, hasPrefix, tt.wantPrefix)
+			}
+			if got := highlighted(snippet, highlights); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snippet %q: highlighted %q, want %q", snippet, got, tt.want)
+			}
+		})
+	}
+}
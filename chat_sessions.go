@@ -0,0 +1,543 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var errSessionNotFound = errors.New("会话不存在")
+
+// 会话 ID 用作文件名，只允许字母、数字、_ 和 -，与前端生成的 session_<时间>_<随机串> 兼容
+var sessionIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// 会话预览的长度，与前端一致
+const sessionPreviewRunes = 50
+
+// ChatSession 聊天会话，字段名与前端 sessionStore 一致，时间为毫秒时间戳
+type ChatSession struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Model        string `json:"model"`
+	Preview      string `json:"preview"`
+	MessageCount int    `json:"messageCount"`
+	CreatedAt    int64  `json:"createdAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+}
+
+// SessionMessage 会话中的一条消息
+type SessionMessage struct {
+	ID        string          `json:"id"`
+	Role      string          `json:"role"` // user、assistant、system 或 search
+	Content   string          `json:"content"`
+	Model     string          `json:"model,omitempty"`
+	Timestamp string          `json:"timestamp,omitempty"` // 前端显示的时间
+	CreatedAt int64           `json:"createdAt"`
+	Results   json.RawMessage `json:"results,omitempty"` // 联网搜索消息的搜索结果
+}
+
+// LocalSessionExport 前端从 localStorage 导出的会话数据，用于一次性迁移
+type LocalSessionExport struct {
+	Sessions []ChatSession               `json:"sessions"`
+	Messages map[string][]SessionMessage `json:"messages"` // 按会话 ID 索引
+}
+
+// getSessionsDir 获取会话存储目录
+func (a *App) getSessionsDir() string {
+	return filepath.Join(a.getConfigDir(), "sessions")
+}
+
+// sessionMessagesPath 会话消息文件
+func (a *App) sessionMessagesPath(id string) string {
+	return filepath.Join(a.getSessionsDir(), id+".json")
+}
+
+// sessionMigrationMarker 完成 localStorage 迁移后创建的标记文件
+func (a *App) sessionMigrationMarker() string {
+	return filepath.Join(a.getSessionsDir(), ".migrated")
+}
+
+// newSessionID 生成会话或消息 ID
+func newSessionID(prefix string) string {
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	return fmt.Sprintf("%s_%d_%s", prefix, time.Now().UnixMilli(), hex.EncodeToString(buf))
+}
+
+// sessionPreview 会话预览：最后一条用户消息，没有时为第一条用户消息，与前端的逻辑一致
+func sessionPreview(messages []SessionMessage) string {
+	preview := ""
+	if len(messages) > 0 && messages[len(messages)-1].Role == "user" {
+		preview = messages[len(messages)-1].Content
+	} else {
+		for _, message := range messages {
+			if message.Role == "user" {
+				preview = message.Content
+				break
+			}
+		}
+	}
+	if runes := []rune(preview); len(runes) > sessionPreviewRunes {
+		preview = string(runes[:sessionPreviewRunes])
+	}
+	return preview
+}
+
+// loadChatSessionsLocked 首次使用时加载会话列表，调用方需持有 chatSessionsMutex
+func (a *App) loadChatSessionsLocked() {
+	if a.chatSessions != nil {
+		return
+	}
+	a.chatSessions = make(map[string]*ChatSession)
+
+	data, err := os.ReadFile(filepath.Join(a.getSessionsDir(), "index.json"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("loadChatSessions: 读取会话列表失败: %v", err)
+		}
+		return
+	}
+	var sessions []*ChatSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		log.Printf("loadChatSessions: 解析会话列表失败: %v", err)
+		return
+	}
+	for _, session := range sessions {
+		a.chatSessions[session.ID] = session
+	}
+}
+
+// saveChatSessionsLocked 保存会话列表，调用方需持有 chatSessionsMutex
+func (a *App) saveChatSessionsLocked() error {
+	sessions := a.sortedChatSessionsLocked()
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.getSessionsDir(), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(a.getSessionsDir(), "index.json"), data, 0644)
+}
+
+// sortedChatSessionsLocked 按最近更新时间排序的会话列表副本
+func (a *App) sortedChatSessionsLocked() []ChatSession {
+	sessions := make([]ChatSession, 0, len(a.chatSessions))
+	for _, session := range a.chatSessions {
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].UpdatedAt != sessions[j].UpdatedAt {
+			return sessions[i].UpdatedAt > sessions[j].UpdatedAt
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+// readSessionMessagesLocked 读取会话的消息，调用方需持有 chatSessionsMutex
+func (a *App) readSessionMessagesLocked(id string) ([]SessionMessage, error) {
+	data, err := os.ReadFile(a.sessionMessagesPath(id))
+	if os.IsNotExist(err) {
+		return []SessionMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []SessionMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("解析会话消息失败: %v", err)
+	}
+	return messages, nil
+}
+
+// writeSessionMessagesLocked 保存会话的消息并更新会话的预览和消息数，调用方需持有 chatSessionsMutex
+func (a *App) writeSessionMessagesLocked(session *ChatSession, messages []SessionMessage) error {
+	if messages == nil {
+		messages = []SessionMessage{}
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.getSessionsDir(), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(a.sessionMessagesPath(session.ID), data, 0644); err != nil {
+		return err
+	}
+
+	session.MessageCount = len(messages)
+	if preview := sessionPreview(messages); preview != "" {
+		session.Preview = preview
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Model != "" {
+			session.Model = messages[i].Model
+			break
+		}
+	}
+	session.UpdatedAt = time.Now().UnixMilli()
+	if err := a.saveChatSessionsLocked(); err != nil {
+		return err
+	}
+	if a.chatSearchIndex != nil {
+		a.chatSearchIndex.updateSession(session, messages)
+	}
+	return nil
+}
+
+// prepareSessionMessages 为新消息补全 ID 和创建时间
+func prepareSessionMessages(messages []SessionMessage) []SessionMessage {
+	now := time.Now().UnixMilli()
+	for i := range messages {
+		if messages[i].ID == "" {
+			messages[i].ID = newSessionID("msg")
+		}
+		if messages[i].CreatedAt == 0 {
+			messages[i].CreatedAt = now
+		}
+	}
+	return messages
+}
+
+// lookupChatSessionLocked 查找会话，调用方需持有 chatSessionsMutex
+func (a *App) lookupChatSessionLocked(id string) (*ChatSession, error) {
+	a.loadChatSessionsLocked()
+	session, ok := a.chatSessions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errSessionNotFound, id)
+	}
+	return session, nil
+}
+
+// modifySessionMessages 读取、修改并保存会话的消息
+func (a *App) modifySessionMessages(id string, modify func(messages []SessionMessage) ([]SessionMessage, error)) (*ChatSession, []SessionMessage, error) {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+
+	session, err := a.lookupChatSessionLocked(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := a.readSessionMessagesLocked(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if messages, err = modify(messages); err != nil {
+		return nil, nil, err
+	}
+	if err := a.writeSessionMessagesLocked(session, messages); err != nil {
+		return nil, nil, err
+	}
+	copied := *session
+	return &copied, messages, nil
+}
+
+// recordChatExchange 将流式对话的最后一个问题和模型的回答追加到会话
+// 前端已经保存了该问题时不重复添加；保存失败只记录日志，不影响对话结果
+func (a *App) recordChatExchange(id string, request []ChatMessage, reply, model string) {
+	var question *ChatMessage
+	if n := len(request); n > 0 && request[n-1].Role == "user" {
+		question = &request[n-1]
+	}
+	_, _, err := a.modifySessionMessages(id, func(messages []SessionMessage) ([]SessionMessage, error) {
+		var added []SessionMessage
+		if question != nil {
+			n := len(messages)
+			if n == 0 || messages[n-1].Role != "user" || messages[n-1].Content != question.Content {
+				added = append(added, SessionMessage{Role: question.Role, Content: question.Content})
+			}
+		}
+		if reply != "" {
+			added = append(added, SessionMessage{Role: "assistant", Content: reply, Model: model})
+		}
+		return append(messages, prepareSessionMessages(added)...), nil
+	})
+	if err != nil {
+		log.Printf("recordChatExchange: 保存对话到会话 %s 失败: %v", id, err)
+		return
+	}
+	a.emitChatSessionsChanged(id)
+}
+
+// emitChatSessionsChanged 通知前端会话列表已变化
+func (a *App) emitChatSessionsChanged(id string) {
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "chat_sessions_changed", map[string]interface{}{
+			"session_id": id,
+		})
+	}
+}
+
+// sessionResult 返回会话操作的结果
+func sessionResult(err error, result map[string]interface{}) map[string]interface{} {
+	if err != nil {
+		code := "error"
+		if errors.Is(err, errSessionNotFound) {
+			code = "not_found"
+		}
+		return map[string]interface{}{
+			"success": false,
+			"code":    code,
+			"message": err.Error(),
+		}
+	}
+	result["success"] = true
+	return result
+}
+
+// ListChatSessions 获取所有会话，最近更新的在前
+func (a *App) ListChatSessions() []ChatSession {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+	a.loadChatSessionsLocked()
+	return a.sortedChatSessionsLocked()
+}
+
+// CreateChatSession 创建会话，名称为空时使用“新会话 N”
+func (a *App) CreateChatSession(name string) map[string]interface{} {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+	a.loadChatSessionsLocked()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = fmt.Sprintf("新会话 %d", len(a.chatSessions)+1)
+	}
+	now := time.Now().UnixMilli()
+	session := &ChatSession{
+		ID:        newSessionID("session"),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	a.chatSessions[session.ID] = session
+	err := a.writeSessionMessagesLocked(session, nil)
+	if err != nil {
+		delete(a.chatSessions, session.ID)
+	} else {
+		a.emitChatSessionsChanged(session.ID)
+	}
+	return sessionResult(err, map[string]interface{}{"session": *session})
+}
+
+// GetChatSession 获取会话及其全部消息
+func (a *App) GetChatSession(id string) map[string]interface{} {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+
+	session, err := a.lookupChatSessionLocked(id)
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	messages, err := a.readSessionMessagesLocked(id)
+	return sessionResult(err, map[string]interface{}{
+		"session":  *session,
+		"messages": messages,
+	})
+}
+
+// RenameChatSession 重命名会话
+func (a *App) RenameChatSession(id, name string) map[string]interface{} {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return sessionResult(fmt.Errorf("会话名称不能为空"), nil)
+	}
+
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+
+	session, err := a.lookupChatSessionLocked(id)
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	previous := session.Name
+	session.Name = name
+	session.UpdatedAt = time.Now().UnixMilli()
+	if err := a.saveChatSessionsLocked(); err != nil {
+		session.Name = previous
+		return sessionResult(err, nil)
+	}
+	a.emitChatSessionsChanged(id)
+	return sessionResult(nil, map[string]interface{}{"session": *session})
+}
+
+// DeleteChatSession 删除会话及其消息
+func (a *App) DeleteChatSession(id string) map[string]interface{} {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+
+	if _, err := a.lookupChatSessionLocked(id); err != nil {
+		return sessionResult(err, nil)
+	}
+	delete(a.chatSessions, id)
+	if err := a.saveChatSessionsLocked(); err != nil {
+		return sessionResult(err, nil)
+	}
+	if err := os.Remove(a.sessionMessagesPath(id)); err != nil && !os.IsNotExist(err) {
+		log.Printf("DeleteChatSession: 删除会话消息失败: %v", err)
+	}
+	if a.chatSearchIndex != nil {
+		a.chatSearchIndex.removeSession(id)
+	}
+	a.emitChatSessionsChanged(id)
+	return sessionResult(nil, map[string]interface{}{"message": "会话已删除"})
+}
+
+// AppendChatMessage 在会话末尾添加一条消息，返回补全了 ID 的消息
+func (a *App) AppendChatMessage(id string, message SessionMessage) map[string]interface{} {
+	if message.Role == "" {
+		return sessionResult(fmt.Errorf("消息角色不能为空"), nil)
+	}
+	message.ID = ""
+	added := prepareSessionMessages([]SessionMessage{message})[0]
+	session, _, err := a.modifySessionMessages(id, func(messages []SessionMessage) ([]SessionMessage, error) {
+		return append(messages, added), nil
+	})
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	a.emitChatSessionsChanged(id)
+	return sessionResult(nil, map[string]interface{}{"session": *session, "chat_message": added})
+}
+
+// UpdateChatMessage 修改消息内容，用于流式回复过程中更新占位消息
+func (a *App) UpdateChatMessage(id, messageID, content string) map[string]interface{} {
+	var updated SessionMessage
+	session, _, err := a.modifySessionMessages(id, func(messages []SessionMessage) ([]SessionMessage, error) {
+		for i := range messages {
+			if messages[i].ID == messageID {
+				messages[i].Content = content
+				updated = messages[i]
+				return messages, nil
+			}
+		}
+		return nil, fmt.Errorf("消息不存在: %s", messageID)
+	})
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	return sessionResult(nil, map[string]interface{}{"session": *session, "chat_message": updated})
+}
+
+// DeleteChatMessage 删除会话中的一条消息
+func (a *App) DeleteChatMessage(id, messageID string) map[string]interface{} {
+	session, _, err := a.modifySessionMessages(id, func(messages []SessionMessage) ([]SessionMessage, error) {
+		for i := range messages {
+			if messages[i].ID == messageID {
+				return append(messages[:i], messages[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("消息不存在: %s", messageID)
+	})
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	a.emitChatSessionsChanged(id)
+	return sessionResult(nil, map[string]interface{}{"session": *session})
+}
+
+// SaveChatMessages 用给定的消息替换会话的全部消息，与前端 saveMessages 的用法一致
+func (a *App) SaveChatMessages(id string, messages []SessionMessage) map[string]interface{} {
+	messages = prepareSessionMessages(messages)
+	session, saved, err := a.modifySessionMessages(id, func([]SessionMessage) ([]SessionMessage, error) {
+		return messages, nil
+	})
+	if err != nil {
+		return sessionResult(err, nil)
+	}
+	return sessionResult(nil, map[string]interface{}{"session": *session, "messages": saved})
+}
+
+// estimateMessageTimes 为没有创建时间的消息估算时间
+// 前端只保存了显示用的时间，按消息顺序均匀分布在会话的创建时间和最后更新时间之间，使按日期筛选时结果仍然可信
+func estimateMessageTimes(messages []SessionMessage, createdAt, updatedAt int64) []SessionMessage {
+	if updatedAt < createdAt {
+		updatedAt = createdAt
+	}
+	for i := range messages {
+		if messages[i].CreatedAt != 0 {
+			continue
+		}
+		messages[i].CreatedAt = updatedAt
+		if n := len(messages); n > 1 {
+			messages[i].CreatedAt = createdAt + (updatedAt-createdAt)*int64(i)/int64(n-1)
+		}
+	}
+	return messages
+}
+
+// MigrateLocalSessions 导入前端 localStorage 中的会话，只执行一次
+// 已存在的会话不会被覆盖；迁移完成后再次调用直接返回
+func (a *App) MigrateLocalSessions(data LocalSessionExport) map[string]interface{} {
+	a.chatSessionsMutex.Lock()
+	defer a.chatSessionsMutex.Unlock()
+
+	if _, err := os.Stat(a.sessionMigrationMarker()); err == nil {
+		return sessionResult(nil, map[string]interface{}{
+			"migrated": false,
+			"message":  "会话已迁移过",
+		})
+	}
+	a.loadChatSessionsLocked()
+
+	imported, skipped := 0, 0
+	for _, session := range data.Sessions {
+		if !sessionIDRegex.MatchString(session.ID) {
+			log.Printf("MigrateLocalSessions: 跳过无效的会话 ID: %q", session.ID)
+			skipped++
+			continue
+		}
+		if _, exists := a.chatSessions[session.ID]; exists {
+			skipped++
+			continue
+		}
+
+		copied := session
+		if copied.CreatedAt == 0 {
+			copied.CreatedAt = copied.UpdatedAt
+		}
+		if copied.CreatedAt == 0 {
+			copied.CreatedAt = time.Now().UnixMilli()
+		}
+		updatedAt := copied.UpdatedAt
+		messages := estimateMessageTimes(data.Messages[session.ID], copied.CreatedAt, updatedAt)
+		a.chatSessions[copied.ID] = &copied
+		if err := a.writeSessionMessagesLocked(&copied, prepareSessionMessages(messages)); err != nil {
+			delete(a.chatSessions, copied.ID)
+			return sessionResult(fmt.Errorf("迁移会话 %s 失败: %v", session.ID, err), nil)
+		}
+		// 保留原来的更新时间，使会话的顺序不变
+		if updatedAt != 0 {
+			copied.UpdatedAt = updatedAt
+		}
+		imported++
+	}
+
+	if err := a.saveChatSessionsLocked(); err != nil {
+		return sessionResult(err, nil)
+	}
+	if err := writeFileAtomic(a.sessionMigrationMarker(), []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		return sessionResult(err, nil)
+	}
+	log.Printf("MigrateLocalSessions: 导入 %d 个会话，跳过 %d 个", imported, skipped)
+	a.emitChatSessionsChanged("")
+	return sessionResult(nil, map[string]interface{}{
+		"migrated": true,
+		"imported": imported,
+		"skipped":  skipped,
+		"message":  fmt.Sprintf("已导入 %d 个会话", imported),
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEstimateMessageTimes(t *testing.T) {
+	tests := []struct {
+		name       string
+		createdAt  []int64
+		start, end int64
+		want       []int64
+	}{
+		{name: "均匀分布", createdAt: []int64{0, 0, 0}, start: 1000, end: 3000, want: []int64{1000, 2000, 3000}},
+		{name: "保留已有时间", createdAt: []int64{0, 1500, 0}, start: 1000, end: 3000, want: []int64{1000, 1500, 3000}},
+		{name: "单条消息", createdAt: []int64{0}, start: 1000, end: 3000, want: []int64{3000}},
+		{name: "没有更新时间", createdAt: []int64{0, 0}, start: 1000, end: 0, want: []int64{1000, 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := make([]SessionMessage, len(tt.createdAt))
+			for i, createdAt := range tt.createdAt {
+				messages[i].CreatedAt = createdAt
+			}
+			var got []int64
+			for _, message := range estimateMessageTimes(messages, tt.start, tt.end) {
+				got = append(got, message.CreatedAt)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestSessionApp 使用临时配置目录的 App
+func newTestSessionApp(t *testing.T) *App {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("APPDATA", dir)
+	return &App{}
+}
+
+func TestChatSessionLifecycle(t *testing.T) {
+	app := newTestSessionApp(t)
+
+	created := app.CreateChatSession("  ")
+	if created["success"] != true {
+		t.Fatalf("CreateChatSession = %v", created)
+	}
+	session := created["session"].(ChatSession)
+	if session.Name != "新会话 1" || !sessionIDRegex.MatchString(session.ID) {
+		t.Errorf("session = %+v", session)
+	}
+
+	saved := app.SaveChatMessages(session.ID, []SessionMessage{
+		{ID: "msg_1_aaaaaaaaaaaa", Role: "user", Content: "你好"},
+		{Role: "assistant", Content: "你好！", Model: "qwen3:8b"},
+	})
+	if saved["success"] != true {
+		t.Fatalf("SaveChatMessages = %v", saved)
+	}
+	messages := saved["messages"].([]SessionMessage)
+	if messages[0].ID != "msg_1_aaaaaaaaaaaa" || messages[1].ID == "" || messages[1].CreatedAt == 0 {
+		t.Errorf("messages = %+v, want existing ID kept and missing ID filled", messages)
+	}
+
+	got := app.GetChatSession(session.ID)
+	if got["success"] != true {
+		t.Fatalf("GetChatSession = %v", got)
+	}
+	stored := got["session"].(ChatSession)
+	if stored.MessageCount != 2 || stored.Preview != "你好" || stored.Model != "qwen3:8b" {
+		t.Errorf("stored session = %+v", stored)
+	}
+	if !reflect.DeepEqual(got["messages"], messages) {
+		t.Errorf("messages = %+v, want %+v", got["messages"], messages)
+	}
+
+	// 重新加载后会话仍然存在
+	if sessions := (&App{}).ListChatSessions(); len(sessions) != 1 || sessions[0].ID != session.ID {
+		t.Errorf("reloaded sessions = %+v", sessions)
+	}
+
+	if deleted := app.DeleteChatSession(session.ID); deleted["success"] != true {
+		t.Fatalf("DeleteChatSession = %v", deleted)
+	}
+	if sessions := app.ListChatSessions(); len(sessions) != 0 {
+		t.Errorf("sessions after delete = %+v", sessions)
+	}
+}
+
+func TestChatSessionNotFound(t *testing.T) {
+	app := newTestSessionApp(t)
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+	}{
+		{"获取", app.GetChatSession("session_missing")},
+		{"重命名", app.RenameChatSession("session_missing", "新名称")},
+		{"删除", app.DeleteChatSession("session_missing")},
+		{"保存消息", app.SaveChatMessages("session_missing", nil)},
+		{"删除消息", app.DeleteChatMessage("session_missing", "msg_1")},
+	}
+	for _, tt := range tests {
+		if tt.result["success"] != false || tt.result["code"] != "not_found" {
+			t.Errorf("%s: result = %v, want not_found", tt.name, tt.result)
+		}
+	}
+}
+
+func TestMigrateLocalSessions(t *testing.T) {
+	app := newTestSessionApp(t)
+	existing := app.CreateChatSession("已有会话")["session"].(ChatSession)
+
+	data := LocalSessionExport{
+		Sessions: []ChatSession{
+			{ID: "session_1_abc", Name: "旧会话", CreatedAt: 1000, UpdatedAt: 3000},
+			{ID: existing.ID, Name: "同 ID 的旧会话"},
+			{ID: "../escape", Name: "无效 ID"},
+			{ID: "", Name: "没有 ID"},
+		},
+		Messages: map[string][]SessionMessage{
+			"session_1_abc": {{Role: "user", Content: "问题"}, {Role: "assistant", Content: "回答"}},
+			existing.ID:     {{Role: "user", Content: "不应覆盖"}},
+		},
+	}
+
+	result := app.MigrateLocalSessions(data)
+	if result["success"] != true || result["migrated"] != true || result["imported"] != 1 || result["skipped"] != 3 {
+		t.Fatalf("MigrateLocalSessions = %v", result)
+	}
+
+	got := app.GetChatSession("session_1_abc")
+	if got["success"] != true {
+		t.Fatalf("GetChatSession = %v", got)
+	}
+	if session := got["session"].(ChatSession); session.Name != "旧会话" || session.UpdatedAt != 3000 {
+		t.Errorf("migrated session = %+v", session)
+	}
+	messages := got["messages"].([]SessionMessage)
+	if len(messages) != 2 || messages[0].CreatedAt != 1000 || messages[1].CreatedAt != 3000 || messages[0].ID == "" {
+		t.Errorf("migrated messages = %+v", messages)
+	}
+
+	// 已有会话没有被覆盖
+	if kept := app.GetChatSession(existing.ID); kept["session"].(ChatSession).Name != "已有会话" || len(kept["messages"].([]SessionMessage)) != 0 {
+		t.Errorf("existing session = %v", kept)
+	}
+	if _, err := os.Stat(filepath.Join(app.getSessionsDir(), "escape.json")); !os.IsNotExist(err) {
+		t.Errorf("invalid session ID was written: %v", err)
+	}
+
+	// 有标记文件时再次迁移不会导入
+	data.Sessions = append(data.Sessions, ChatSession{ID: "session_2_def", Name: "新的旧会话"})
+	again := app.MigrateLocalSessions(data)
+	if again["success"] != true || again["migrated"] != false {
+		t.Fatalf("second MigrateLocalSessions = %v", again)
+	}
+	if missing := app.GetChatSession("session_2_def"); missing["code"] != "not_found" {
+		t.Errorf("session imported after migration: %v", missing)
+	}
+	if _, err := os.Stat(app.sessionMigrationMarker()); err != nil {
+		t.Errorf("migration marker: %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// cliCommand 命令行子命令
+type cliCommand struct {
+	Usage       string
+	Description string
+	Run         func(a *App, args []string) error
+}
+
+// cliCommands 支持的命令行子命令
+var cliCommands = map[string]cliCommand{
+	"schedule": {
+		Usage:       "schedule [set <HH:MM-HH:MM[,...]> | clear]",
+		Description: "查看或设置允许下载模型的时段",
+		Run:         cliSchedule,
+	},
+	"bandwidth": {
+		Usage:       "bandwidth [set <限速，如 10MB/s> | clear]",
+		Description: "查看或设置模型下载带宽限制",
+		Run:         cliBandwidth,
+	},
+	"cp": {
+		Usage:       "cp <源模型> <目标模型>",
+		Description: "复制模型",
+		Run:         cliCopy,
+	},
+	"mv": {
+		Usage:       "mv <源模型> <目标模型>",
+		Description: "重命名模型",
+		Run:         cliRename,
+	},
+	"retag": {
+		Usage:       "retag [--move] <新标签> <模型>...",
+		Description: "批量为模型设置新标签，--move 删除原标签",
+		Run:         cliRetag,
+	},
+	"import": {
+		Usage:       "import <文件.gguf> [模型名称]",
+		Description: "将本地 GGUF 文件导入为模型",
+		Run:         cliImport,
+	},
+	"export": {
+		Usage:       "export <归档文件.tar> <模型>...",
+		Description: "将模型导出为可离线迁移的归档文件",
+		Run:         cliExport,
+	},
+	"restore": {
+		Usage:       "restore <归档文件.tar>",
+		Description: "从归档文件恢复模型，已存在的数据文件不会重复写入",
+		Run:         cliRestore,
+	},
+	"storage": {
+		Usage:       "storage",
+		Description: "显示模型目录的磁盘占用、共享层、孤立和下载残留文件",
+		Run:         cliStorage,
+	},
+	"prune": {
+		Usage:       "prune [--confirm]",
+		Description: "列出可清理的孤立和下载残留文件，--confirm 时删除",
+		Run:         cliPrune,
+	},
+}
+
+// runCLI 处理命令行子命令，不是已知子命令时返回 false 并继续启动图形界面
+func runCLI(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		attachParentConsole()
+		printCLIUsage()
+		return 0, true
+	}
+	command, ok := cliCommands[name]
+	if !ok {
+		return 0, false
+	}
+
+	attachParentConsole()
+	// 命令行模式下只输出命令结果
+	log.SetOutput(io.Discard)
+
+	a := NewApp()
+	a.initDefaultConfig()
+	a.loadConfig()
+
+	if err := command.Run(a, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1, true
+	}
+	return 0, true
+}
+
+// printCLIUsage 输出命令行帮助
+func printCLIUsage() {
+	fmt.Println("用法: ollama-desktop-intel <命令> [参数]")
+	fmt.Println()
+	fmt.Println("命令:")
+
+	names := make([]string, 0, len(cliCommands))
+	for name := range cliCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command := cliCommands[name]
+		fmt.Printf("  %-50s %s\n", command.Usage, command.Description)
+	}
+	fmt.Println()
+	fmt.Println("不带命令运行时启动图形界面。")
+}
+
+// cliSetConfig 修改配置并保存，运行中的应用需要重新启动或在设置中保存后生效
+func cliSetConfig(a *App, key string, value interface{}) {
+	a.environmentVariables[key] = value
+	a.saveConfig()
+	fmt.Printf("已保存到 %s\n", a.getConfigPath())
+	fmt.Println("如果应用正在运行，请重新启动应用使设置生效。")
+}
+
+// cliSchedule 查看或设置下载时段
+func cliSchedule(a *App, args []string) error {
+	if len(args) == 0 {
+		status := a.GetPullSchedule()
+		schedule, _ := status["schedule"].(string)
+		if schedule == "" {
+			schedule = "不限时段"
+		}
+		fmt.Printf("下载时段: %s\n", schedule)
+		if allowed, _ := status["allowed_now"].(bool); allowed {
+			fmt.Println("当前: 允许下载")
+		} else {
+			fmt.Println("当前: 不在下载时段内")
+		}
+		if next, ok := status["next_window"].(string); ok {
+			fmt.Printf("下一时段开始: %s\n", next)
+		}
+		if errMsg, ok := status["error"].(string); ok {
+			fmt.Printf("配置无效: %s\n", errMsg)
+		}
+		cliPrintThrottleStatus(status)
+		return nil
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("缺少时段参数，例如: schedule set 19:00-07:00")
+		}
+		schedule := strings.Join(args[1:], ",")
+		if _, err := parsePullSchedule(schedule); err != nil {
+			return err
+		}
+		cliSetConfig(a, "OLLAMA_PULL_SCHEDULE", schedule)
+		return nil
+	case "clear":
+		cliSetConfig(a, "OLLAMA_PULL_SCHEDULE", "")
+		return nil
+	default:
+		return fmt.Errorf("未知的参数: %s，可用参数: set, clear", args[0])
+	}
+}
+
+// cliBandwidth 查看或设置下载带宽限制
+func cliBandwidth(a *App, args []string) error {
+	if len(args) == 0 {
+		status := a.GetPullSchedule()
+		fmt.Printf("下载限速: %s\n", status["bandwidth_text"])
+		cliPrintThrottleStatus(status)
+		return nil
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("缺少限速参数，例如: bandwidth set 10MB/s")
+		}
+		if _, err := parseBandwidth(args[1]); err != nil {
+			return err
+		}
+		cliSetConfig(a, "OLLAMA_PULL_BANDWIDTH_LIMIT", args[1])
+		return nil
+	case "clear":
+		cliSetConfig(a, "OLLAMA_PULL_BANDWIDTH_LIMIT", "")
+		return nil
+	default:
+		return fmt.Errorf("未知的参数: %s，可用参数: set, clear", args[0])
+	}
+}
+
+// cliPrintThrottleStatus 设置了下载限速但未生效时输出原因
+func cliPrintThrottleStatus(status map[string]interface{}) {
+	if limit, _ := status["bandwidth_limit"].(int64); limit <= 0 {
+		return
+	}
+	if status["throttle_status"] == "inactive" {
+		fmt.Printf("限速状态: 未生效（%s）\n", status["throttle_reason"])
+	}
+}
+
+// cliPrintResult 输出绑定方法返回的结果，失败时返回错误
+func cliPrintResult(result map[string]interface{}) error {
+	message, _ := result["message"].(string)
+	if success, _ := result["success"].(bool); !success {
+		return fmt.Errorf("%s", message)
+	}
+	fmt.Println(message)
+	return nil
+}
+
+// cliCopy 复制模型
+func cliCopy(a *App, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("用法: cp <源模型> <目标模型>")
+	}
+	return cliPrintResult(a.CopyModel(args[0], args[1]))
+}
+
+// cliRename 重命名模型
+func cliRename(a *App, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("用法: mv <源模型> <目标模型>")
+	}
+	return cliPrintResult(a.RenameModel(args[0], args[1]))
+}
+
+// cliRetag 批量设置模型标签
+func cliRetag(a *App, args []string) error {
+	move := false
+	if len(args) > 0 && args[0] == "--move" {
+		move = true
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("用法: retag [--move] <新标签> <模型>...")
+	}
+
+	result := a.RetagModels(args[1:], args[0], move)
+	if results, ok := result["results"].([]map[string]interface{}); ok {
+		for _, item := range results {
+			status := "成功"
+			if success, _ := item["success"].(bool); !success {
+				status = fmt.Sprintf("失败: %v", item["message"])
+			}
+			fmt.Printf("  %v -> %v  %s\n", item["source"], item["destination"], status)
+		}
+	}
+	return cliPrintResult(result)
+}
+
+// cliImport 导入 GGUF 文件
+func cliImport(a *App, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("用法: import <文件.gguf> [模型名称]")
+	}
+	name := ""
+	if len(args) == 2 {
+		name = args[1]
+	}
+
+	info, model, err := a.prepareGGUFImport(args[0], name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("文件:       %s (%s)\n", info.Path, info.FileSizeText)
+	fmt.Printf("架构:       %s\n", info.Architecture)
+	fmt.Printf("参数量:     %s\n", info.ParameterSize)
+	if info.Quantization != "" {
+		fmt.Printf("量化:       %s\n", info.Quantization)
+	}
+	if info.ContextLength > 0 {
+		fmt.Printf("上下文长度: %d\n", info.ContextLength)
+	}
+	if info.ChatTemplate != "" {
+		fmt.Println("对话模板:   已包含")
+	}
+	fmt.Printf("导入为:     %s\n", model)
+
+	a.cliProgress = func(event map[string]interface{}) {
+		if progress, _ := event["progress"].(float64); progress > 0 && event["status"] != "completed" {
+			fmt.Printf("\r%-40v %5.1f%%", event["message"], progress)
+			return
+		}
+		fmt.Printf("\r%-48v\n", event["message"])
+	}
+	if err := a.importGGUF(context.Background(), info, model); err != nil {
+		fmt.Println()
+		return err
+	}
+	return nil
+}
+
+// cliArchiveProgress 在命令行输出归档进度
+func cliArchiveProgress(event map[string]interface{}) {
+	if event["status"] == "writing" {
+		fmt.Printf("\r已处理 %s / %s  %5.1f%%", formatBytes(event["completed"].(int64)), formatBytes(event["total"].(int64)), event["progress"])
+		return
+	}
+	fmt.Printf("\r%-48v\n", event["message"])
+}
+
+// cliExport 导出模型到归档文件
+func cliExport(a *App, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: export <归档文件.tar> <模型>...")
+	}
+
+	a.cliProgress = cliArchiveProgress
+	result, err := a.exportModels(context.Background(), args[1:], args[0])
+	if err != nil {
+		fmt.Println()
+		return err
+	}
+	fmt.Printf("已导出 %d 个模型: %s (%s)\n", len(result.Models), strings.Join(result.Models, ", "), formatBytes(result.Bytes))
+	return nil
+}
+
+// cliRestore 从归档文件恢复模型
+func cliRestore(a *App, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: restore <归档文件.tar>")
+	}
+
+	a.cliProgress = cliArchiveProgress
+	result, err := a.importModelArchive(context.Background(), args[0])
+	if err != nil {
+		fmt.Println()
+		return err
+	}
+	if len(result.Models) > 0 {
+		fmt.Printf("已恢复模型: %s\n", strings.Join(result.Models, ", "))
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("已存在相同模型，跳过: %s\n", strings.Join(result.Skipped, ", "))
+	}
+	fmt.Printf("写入 %d 个数据文件 (%s)，跳过 %d 个已存在的数据文件\n", result.BlobsWritten, formatBytes(result.Bytes), result.BlobsSkipped)
+	return nil
+}
+
+// cliStorage 输出磁盘占用报告
+func cliStorage(a *App, args []string) error {
+	report, err := a.inspectStorage()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("模型目录: %s\n", report.ModelsDir)
+	fmt.Printf("总占用:   %s（%d 个文件）\n", report.TotalSizeText, report.BlobCount)
+	fmt.Println()
+	fmt.Printf("  %-40s %12s %12s %12s\n", "模型", "大小", "独占", "共享")
+	for _, model := range report.Models {
+		if model.Invalid {
+			fmt.Printf("  %-40s 清单无法解析\n", model.Name)
+			continue
+		}
+		fmt.Printf("  %-40s %12s %12s %12s\n", model.Name, model.SizeText, formatBytes(model.ExclusiveSize), formatBytes(model.SharedSize))
+		for _, digest := range model.MissingBlobs {
+			fmt.Printf("    缺少数据文件: %s\n", digest)
+		}
+	}
+	if len(report.SharedBlobs) > 0 {
+		fmt.Println()
+		fmt.Println("共享层:")
+		for _, blob := range report.SharedBlobs {
+			fmt.Printf("  %-12s %s\n", blob.SizeText, strings.Join(blob.Models, ", "))
+		}
+	}
+	for _, group := range []struct {
+		title string
+		blobs []StorageBlob
+	}{{"孤立文件", report.OrphanedBlobs}, {"下载残留文件", report.PartialBlobs}} {
+		if len(group.blobs) == 0 {
+			continue
+		}
+		fmt.Println()
+		fmt.Printf("%s:\n", group.title)
+		for _, blob := range group.blobs {
+			note := ""
+			if blob.Recent {
+				note = "（最近修改，清理时跳过）"
+			}
+			fmt.Printf("  %-12s %s  %s%s\n", blob.SizeText, blob.Modified, blob.File, note)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("可清理: %s\n", report.ReclaimableText)
+	return nil
+}
+
+// cliPrune 清理孤立和下载残留文件，不带 --confirm 时只列出
+func cliPrune(a *App, args []string) error {
+	confirm := len(args) == 1 && args[0] == "--confirm"
+	if len(args) > 1 || (len(args) == 1 && !confirm) {
+		return fmt.Errorf("用法: prune [--confirm]")
+	}
+
+	files, size, err := a.pruneStorage(!confirm)
+	for _, blob := range files {
+		fmt.Printf("  %-12s %s\n", blob.SizeText, blob.File)
+	}
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		fmt.Printf("将删除 %d 个文件，释放空间 %s，使用 prune --confirm 执行删除\n", len(files), formatBytes(size))
+		return nil
+	}
+	fmt.Printf("已删除 %d 个文件，释放空间 %s\n", len(files), formatBytes(size))
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// attachParentConsole 非 Windows 平台的程序始终可以直接输出到终端
+func attachParentConsole() {}
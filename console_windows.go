@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var procAttachConsole = syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+
+// attachParentConsole 图形界面程序默认没有控制台，命令行模式下附加到父进程的控制台以输出结果
+func attachParentConsole() {
+	const attachParentProcess = ^uint32(0) // ATTACH_PARENT_PROCESS (DWORD)-1
+	if ret, _, _ := procAttachConsole.Call(uintptr(attachParentProcess)); ret == 0 {
+		return
+	}
+	if out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = out
+		os.Stderr = out
+	}
+}
@@ -1,78 +1,139 @@
 import { ref, computed } from 'vue'
-import { reactive } from 'vue'
+import {
+  ListChatSessions,
+  CreateChatSession,
+  RenameChatSession,
+  DeleteChatSession,
+  GetChatSession,
+  SaveChatMessages,
+  MigrateLocalSessions
+} from '../../wailsjs/go/main/App'
+import { EventsOn } from '../../wailsjs/runtime/runtime'
 
+// 旧版本保存在 localStorage 中的会话，只在迁移时读取
 const SESSIONS_STORAGE_KEY = 'ollama-chat-sessions'
-const CURRENT_SESSION_KEY = 'ollama-current-session'
 const MESSAGES_PREFIX = 'ollama-chat-messages-'
+// 当前选中的会话只是界面状态，仍保存在 localStorage
+const CURRENT_SESSION_KEY = 'ollama-current-session'
+
+// 流式输出时消息变化很频繁，合并后再保存到后端
+const SAVE_DELAY = 300
 
 const sessions = ref([])
 const currentSessionId = ref(null)
 
-const generateId = () => {
-  return `session_${Date.now()}_${Math.random().toString(36).substr(2, 9)}`
-}
+// 会话 ID -> 等待保存的消息
+const pendingSaves = new Map()
+let migration = null
 
-const loadSessions = () => {
+const readLocalSessions = () => {
+  const data = { sessions: [], messages: {} }
   try {
     const stored = localStorage.getItem(SESSIONS_STORAGE_KEY)
     if (stored) {
-      sessions.value = JSON.parse(stored)
+      data.sessions = JSON.parse(stored)
     }
-    
-    const currentId = localStorage.getItem(CURRENT_SESSION_KEY)
-    if (currentId && sessions.value.find(s => s.id === currentId)) {
-      currentSessionId.value = currentId
-    } else if (sessions.value.length > 0) {
-      currentSessionId.value = sessions.value[0].id
+    for (const session of data.sessions) {
+      const messages = localStorage.getItem(`${MESSAGES_PREFIX}${session.id}`)
+      data.messages[session.id] = messages ? JSON.parse(messages) : []
     }
   } catch (error) {
-    console.error('加载会话列表失败:', error)
-    sessions.value = []
+    console.error('读取本地会话失败:', error)
   }
+  return data
 }
 
-const saveSessions = () => {
+// 将 localStorage 中的会话一次性导入后端，后端记录迁移状态，重复调用不会重复导入
+const migrateLocalSessions = () => {
+  if (!migration) {
+    migration = (async () => {
+      const data = readLocalSessions()
+      if (data.sessions.length === 0) return
+      try {
+        const result = await MigrateLocalSessions(data)
+        if (!result.success) {
+          console.error('迁移本地会话失败:', result.message)
+        } else if (result.migrated) {
+          console.log(result.message)
+        }
+      } catch (error) {
+        console.error('迁移本地会话失败:', error)
+      }
+    })()
+  }
+  return migration
+}
+
+const saveCurrentSession = () => {
   try {
-    localStorage.setItem(SESSIONS_STORAGE_KEY, JSON.stringify(sessions.value))
     if (currentSessionId.value) {
       localStorage.setItem(CURRENT_SESSION_KEY, currentSessionId.value)
+    } else {
+      localStorage.removeItem(CURRENT_SESSION_KEY)
     }
   } catch (error) {
-    console.error('保存会话列表失败:', error)
+    console.error('保存当前会话失败:', error)
+  }
+}
+
+// 用后端返回的会话更新列表
+const applySession = (session) => {
+  if (!session) return
+  const index = sessions.value.findIndex(s => s.id === session.id)
+  if (index > -1) {
+    sessions.value[index] = session
+  } else {
+    sessions.value.unshift(session)
+  }
+}
+
+const refreshSessions = async () => {
+  try {
+    sessions.value = (await ListChatSessions()) || []
+  } catch (error) {
+    console.error('加载会话列表失败:', error)
+  }
+}
+
+const loadSessions = async () => {
+  await migrateLocalSessions()
+  await refreshSessions()
+
+  const currentId = localStorage.getItem(CURRENT_SESSION_KEY)
+  if (currentId && sessions.value.find(s => s.id === currentId)) {
+    currentSessionId.value = currentId
+  } else if (sessions.value.length > 0) {
+    currentSessionId.value = sessions.value[0].id
+  } else {
+    currentSessionId.value = null
   }
 }
 
-const createSession = (name = null) => {
+const createSession = async (name = null) => {
   const sessionName = name || `新会话 ${sessions.value.length + 1}`
-  const newSession = {
-    id: generateId(),
-    name: sessionName,
-    createdAt: Date.now(),
-    updatedAt: Date.now(),
-    messageCount: 0,
-    model: '',
-    preview: ''
-  }
-  
-  sessions.value.unshift(newSession)
+  const result = await CreateChatSession(sessionName)
+  if (!result.success) {
+    throw new Error(result.message)
+  }
+  const newSession = result.session
+
+  applySession(newSession)
   currentSessionId.value = newSession.id
-  saveSessions()
-  
-  localStorage.setItem(`${MESSAGES_PREFIX}${newSession.id}`, JSON.stringify([]))
-  
+  saveCurrentSession()
+
   window.dispatchEvent(new CustomEvent('sessionCreated', {
     detail: { session: newSession }
   }))
-  
+
   return newSession
 }
 
 const selectSession = (sessionId) => {
   if (currentSessionId.value === sessionId) return
-  
+
   currentSessionId.value = sessionId
-  saveSessions()
-  
+  saveCurrentSession()
+
   window.dispatchEvent(new CustomEvent('sessionChanged', {
     detail: { sessionId }
   }))
@@ -82,101 +143,121 @@ const getCurrentSession = computed(() => {
   return sessions.value.find(s => s.id === currentSessionId.value) || null
 })
 
+// 只更新界面上的会话信息，模型和预览由后端根据消息计算
 const updateSession = (sessionId, updates) => {
   const session = sessions.value.find(s => s.id === sessionId)
   if (session) {
     Object.assign(session, updates)
-    session.updatedAt = Date.now()
-    saveSessions()
-  }
-}
-
-const updateSessionPreview = (sessionId, preview, model) => {
-  const session = sessions.value.find(s => s.id === sessionId)
-  if (session) {
-    if (preview) session.preview = preview.substring(0, 50)
-    if (model) session.model = model
-    session.updatedAt = Date.now()
-    saveSessions()
   }
 }
 
-const incrementMessageCount = (sessionId) => {
-  const session = sessions.value.find(s => s.id === sessionId)
-  if (session) {
-    session.messageCount = (session.messageCount || 0) + 1
-    session.updatedAt = Date.now()
-    saveSessions()
+const deleteSession = async (sessionId) => {
+  const result = await DeleteChatSession(sessionId)
+  if (!result.success && result.code !== 'not_found') {
+    throw new Error(result.message)
   }
-}
+  pendingSaves.delete(sessionId)
 
-const deleteSession = (sessionId) => {
   const index = sessions.value.findIndex(s => s.id === sessionId)
   if (index > -1) {
     sessions.value.splice(index, 1)
-    localStorage.removeItem(`${MESSAGES_PREFIX}${sessionId}`)
-    
-    if (currentSessionId.value === sessionId) {
-      if (sessions.value.length > 0) {
-        currentSessionId.value = sessions.value[0].id
-      } else {
-        currentSessionId.value = null
-      }
-    }
-    
-    saveSessions()
+  }
+  if (currentSessionId.value === sessionId) {
+    currentSessionId.value = sessions.value.length > 0 ? sessions.value[0].id : null
+    saveCurrentSession()
   }
 }
 
-const renameSession = (sessionId, newName) => {
-  const session = sessions.value.find(s => s.id === sessionId)
-  if (session) {
-    session.name = newName
-    session.updatedAt = Date.now()
-    saveSessions()
+const renameSession = async (sessionId, newName) => {
+  const result = await RenameChatSession(sessionId, newName)
+  if (!result.success) {
+    throw new Error(result.message)
   }
+  applySession(result.session)
 }
 
-const getMessages = (sessionId) => {
+const getMessages = async (sessionId) => {
+  // 还没保存的消息比后端的更新
+  if (pendingSaves.has(sessionId)) {
+    return pendingSaves.get(sessionId).messages
+  }
   try {
-    const stored = localStorage.getItem(`${MESSAGES_PREFIX}${sessionId}`)
-    return stored ? JSON.parse(stored) : []
+    const result = await GetChatSession(sessionId)
+    if (pendingSaves.has(sessionId)) {
+      return pendingSaves.get(sessionId).messages
+    }
+    if (!result.success) {
+      console.error('加载消息失败:', result.message)
+      return []
+    }
+    return result.messages || []
   } catch (error) {
     console.error('加载消息失败:', error)
     return []
   }
 }
 
-const saveMessages = (sessionId, messages) => {
+const flushMessages = async (sessionId) => {
+  const pending = pendingSaves.get(sessionId)
+  if (!pending) return
+  pendingSaves.delete(sessionId)
+  clearTimeout(pending.timer)
+
   try {
-    localStorage.setItem(`${MESSAGES_PREFIX}${sessionId}`, JSON.stringify(messages))
-    
-    if (messages.length > 0) {
-      const lastMsg = messages[messages.length - 1]
-      const preview = lastMsg.role === 'user' ? lastMsg.content : 
-                      messages.find(m => m.role === 'user')?.content || ''
-      updateSessionPreview(sessionId, preview, null)
-    }
-    
-    const session = sessions.value.find(s => s.id === sessionId)
-    if (session) {
-      session.messageCount = messages.length
-      session.updatedAt = Date.now()
-      saveSessions()
+    const result = await SaveChatMessages(sessionId, pending.messages)
+    if (!result.success) {
+      console.error('保存消息失败:', result.message)
+      return
     }
+    applySession(result.session)
   } catch (error) {
     console.error('保存消息失败:', error)
   }
 }
 
-const ensureSession = () => {
+// 与后端相同格式的消息 ID：msg_<毫秒时间>_<12位十六进制>
+const newMessageId = () => {
+  const bytes = crypto.getRandomValues(new Uint8Array(6))
+  const random = Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('')
+  return `msg_${Date.now()}_${random}`
+}
+
+const saveMessages = (sessionId, messages) => {
+  // 消息 ID 在前端生成，后端保留已有的 ID，每次保存后 ID 不变，搜索结果和消息操作才能找到对应的消息
+  // 同时记录消息的创建时间，按日期搜索聊天记录时使用
+  const now = Date.now()
+  for (const message of messages) {
+    if (!message.id) {
+      message.id = newMessageId()
+    }
+    if (!message.createdAt) {
+      message.createdAt = now
+    }
+  }
+
+  const pending = pendingSaves.get(sessionId)
+  if (pending) {
+    clearTimeout(pending.timer)
+  }
+  pendingSaves.set(sessionId, {
+    messages,
+    timer: setTimeout(() => flushMessages(sessionId), SAVE_DELAY)
+  })
+}
+
+const ensureSession = async () => {
   if (!currentSessionId.value) {
-    const session = createSession()
+    const session = await createSession()
     return session.id
   }
   return currentSessionId.value
 }
 
+// 后端的会话变化（例如流式对话自动保存）后刷新列表
+EventsOn('chat_sessions_changed', () => {
+  refreshSessions()
+})
+
 loadSessions()
 
 export const useSessionStore = () => {
@@ -187,14 +268,12 @@ export const useSessionStore = () => {
     createSession,
     selectSession,
     updateSession,
-    updateSessionPreview,
-    incrementMessageCount,
     deleteSession,
     renameSession,
     getMessages,
     saveMessages,
+    flushMessages,
     ensureSession,
-    loadSessions,
-    saveSessions
+    loadSessions
   }
 }
@@ -12,8 +12,14 @@ export function ChatStream(arg1:main.ChatStreamRequest):Promise<main.ChatStreamR
 
 export function CheckOllamaAvailable():Promise<boolean>;
 
+export function CreateChatSession(arg1:string):Promise<Record<string, any>>;
+
+export function DeleteChatSession(arg1:string):Promise<Record<string, any>>;
+
 export function DeleteModel(arg1:string):Promise<Record<string, any>>;
 
+export function GetChatSession(arg1:string):Promise<Record<string, any>>;
+
 export function GetEnvironmentInfo():Promise<Record<string, any>>;
 
 export function GetEnvironmentVariables():Promise<Record<string, any>>;
@@ -30,10 +36,18 @@ export function GetServiceStatus():Promise<Record<string, any>>;
 
 export function GetStats():Promise<Record<string, any>>;
 
+export function ListChatSessions():Promise<Array<main.ChatSession>>;
+
 export function ListModels():Promise<Array<main.ModelInfo>>;
 
+export function MigrateLocalSessions(arg1:main.LocalSessionExport):Promise<Record<string, any>>;
+
 export function PullModel(arg1:string):Promise<Record<string, any>>;
 
+export function RenameChatSession(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function SaveChatMessages(arg1:string,arg2:Array<main.SessionMessage>):Promise<Record<string, any>>;
+
 export function SaveEnvironmentVariables(arg1:Record<string, any>):Promise<Record<string, any>>;
 
 export function SearchOnlineModels(arg1:string,arg2:number,arg3:number):Promise<Record<string, any>>;
@@ -18,10 +18,22 @@ export function CheckOllamaAvailable() {
   return window['go']['main']['App']['CheckOllamaAvailable']();
 }
 
+export function CreateChatSession(arg1) {
+  return window['go']['main']['App']['CreateChatSession'](arg1);
+}
+
+export function DeleteChatSession(arg1) {
+  return window['go']['main']['App']['DeleteChatSession'](arg1);
+}
+
 export function DeleteModel(arg1) {
   return window['go']['main']['App']['DeleteModel'](arg1);
 }
 
+export function GetChatSession(arg1) {
+  return window['go']['main']['App']['GetChatSession'](arg1);
+}
+
 export function GetEnvironmentInfo() {
   return window['go']['main']['App']['GetEnvironmentInfo']();
 }
@@ -54,14 +66,30 @@ export function GetStats() {
   return window['go']['main']['App']['GetStats']();
 }
 
+export function ListChatSessions() {
+  return window['go']['main']['App']['ListChatSessions']();
+}
+
 export function ListModels() {
   return window['go']['main']['App']['ListModels']();
 }
 
+export function MigrateLocalSessions(arg1) {
+  return window['go']['main']['App']['MigrateLocalSessions'](arg1);
+}
+
 export function PullModel(arg1) {
   return window['go']['main']['App']['PullModel'](arg1);
 }
 
+export function RenameChatSession(arg1, arg2) {
+  return window['go']['main']['App']['RenameChatSession'](arg1, arg2);
+}
+
+export function SaveChatMessages(arg1, arg2) {
+  return window['go']['main']['App']['SaveChatMessages'](arg1, arg2);
+}
+
 export function SaveEnvironmentVariables(arg1) {
   return window['go']['main']['App']['SaveEnvironmentVariables'](arg1);
 }
@@ -217,6 +217,30 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ChatSession {
+	    id: string;
+	    name: string;
+	    model: string;
+	    preview: string;
+	    messageCount: number;
+	    createdAt: number;
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.model = source["model"];
+	        this.preview = source["preview"];
+	        this.messageCount = source["messageCount"];
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
 	export class ChatStreamRequest {
 	    model: string;
 	    messages: ChatMessage[];
@@ -271,6 +295,38 @@ export namespace main {
 	        this.total_time = source["total_time"];
 	    }
 	}
+	export class LocalSessionExport {
+	    sessions: ChatSession[];
+	    messages: Record<string, Array<SessionMessage>>;
+	
+	    static createFrom(source: any = {}) {
+	        return new LocalSessionExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessions = this.convertValues(source["sessions"], ChatSession);
+	        this.messages = this.convertValues(source["messages"], Array<SessionMessage>, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ModelInfo {
 	    name: string;
 	    model: string;
@@ -293,6 +349,30 @@ export namespace main {
 	        this.modified_at = source["modified_at"];
 	    }
 	}
+	export class SessionMessage {
+	    id: string;
+	    role: string;
+	    content: string;
+	    model?: string;
+	    timestamp?: string;
+	    createdAt: number;
+	    results?: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.role = source["role"];
+	        this.content = source["content"];
+	        this.model = source["model"];
+	        this.timestamp = source["timestamp"];
+	        this.createdAt = source["createdAt"];
+	        this.results = source["results"];
+	    }
+	}
 
 }
 
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 网关HTTP服务器超时设置
+const (
+	gatewayReadHeaderTimeout = 10 * time.Second
+	gatewayReadTimeout       = 60 * time.Second
+	gatewayWriteTimeout      = 10 * time.Minute // 流式生成可能持续较长时间
+	gatewayIdleTimeout       = 120 * time.Second
+	gatewayShutdownTimeout   = 5 * time.Second
+)
+
+// gatewayAddress 获取网关监听地址
+func (a *App) gatewayAddress() string {
+	host := a.getConfigString("OLLAMA_GATEWAY_HOST", "0.0.0.0")
+	port := a.getConfigInt("OLLAMA_GATEWAY_PORT", 11435)
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// gatewayURL 获取用于日志展示的网关访问地址
+func (a *App) gatewayURL() string {
+	host := a.getConfigString("OLLAMA_GATEWAY_HOST", "0.0.0.0")
+	if host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	scheme := "http"
+	if a.getConfigBool("OLLAMA_GATEWAY_TLS", false) {
+		scheme = "https"
+	}
+	port := a.getConfigInt("OLLAMA_GATEWAY_PORT", 11435)
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// gatewayConfigSignature 需要重启网关才能生效的配置
+func (a *App) gatewayConfigSignature() string {
+	return strings.Join([]string{
+		a.gatewayAddress(),
+		strconv.FormatBool(a.getConfigBool("OLLAMA_GATEWAY_TLS", false)),
+		a.getConfigString("OLLAMA_GATEWAY_TLS_CERT", ""),
+		a.getConfigString("OLLAMA_GATEWAY_TLS_KEY", ""),
+	}, "|")
+}
+
+// startGatewayServer 启动网关HTTP服务器，已有服务器时先关闭
+func (a *App) startGatewayServer(handler http.Handler) {
+	a.stopGatewayServer()
+
+	server := &http.Server{
+		Addr:              a.gatewayAddress(),
+		Handler:           handler,
+		ReadHeaderTimeout: gatewayReadHeaderTimeout,
+		ReadTimeout:       gatewayReadTimeout,
+		WriteTimeout:      gatewayWriteTimeout,
+		IdleTimeout:       gatewayIdleTimeout,
+	}
+
+	useTLS := a.getConfigBool("OLLAMA_GATEWAY_TLS", false)
+	certFile := a.getConfigString("OLLAMA_GATEWAY_TLS_CERT", "")
+	keyFile := a.getConfigString("OLLAMA_GATEWAY_TLS_KEY", "")
+	if useTLS && (certFile == "" || keyFile == "") {
+		var err error
+		certFile, keyFile, err = a.ensureSelfSignedCertificate()
+		if err != nil {
+			log.Printf("startGatewayServer: 生成自签名证书失败，网关未启动: %v", err)
+			return
+		}
+	}
+
+	a.httpServerMutex.Lock()
+	a.httpServer = server
+	a.httpServerMutex.Unlock()
+
+	baseURL := a.gatewayURL()
+	go func() {
+		log.Println("========================================")
+		log.Printf("WebSocket服务器启动在 %s", server.Addr)
+		log.Printf("Web端访问地址: %s", baseURL)
+		log.Printf("OpenAI兼容API地址: %s/v1", baseURL)
+		log.Printf("健康检查地址: %s/healthz, /readyz", baseURL)
+		log.Println("========================================")
+
+		var err error
+		if useTLS {
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("WebSocket服务器启动失败: %v", err)
+		}
+	}()
+}
+
+// stopGatewayServer 优雅关闭网关HTTP服务器
+func (a *App) stopGatewayServer() {
+	a.httpServerMutex.Lock()
+	server := a.httpServer
+	a.httpServer = nil
+	a.httpServerMutex.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gatewayShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("stopGatewayServer: 关闭网关失败: %v", err)
+		server.Close()
+	}
+}
+
+// allowedOrigins 获取来源白名单，"*" 表示允许所有来源
+func (a *App) allowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(a.getConfigString("OLLAMA_GATEWAY_ALLOWED_ORIGINS", "*"), ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// isOriginAllowed 检查请求来源是否在白名单中
+// 没有 Origin 头的请求（命令行工具、服务端调用）不受限制
+func (a *App) isOriginAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	origin = strings.TrimRight(origin, "/")
+	for _, allowed := range a.allowedOrigins() {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// setCORSHeaders 按来源白名单设置CORS头，来源不被允许时返回 false
+func (a *App) setCORSHeaders(w http.ResponseWriter, r *http.Request, methods string) bool {
+	origin := r.Header.Get("Origin")
+	if !a.isOriginAllowed(origin) {
+		log.Printf("[Gateway] 拒绝来源: %s", origin)
+		return false
+	}
+
+	allowAll := false
+	for _, allowed := range a.allowedOrigins() {
+		if allowed == "*" {
+			allowAll = true
+			break
+		}
+	}
+
+	if allowAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+	w.Header().Set("Access-Control-Allow-Methods", methods)
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Cache-Control")
+	return true
+}
+
+// ensureSelfSignedCertificate 获取网关自签名证书，不存在或即将过期时重新生成
+func (a *App) ensureSelfSignedCertificate() (string, string, error) {
+	tlsDir := filepath.Join(a.getConfigDir(), "tls")
+	certFile := filepath.Join(tlsDir, "gateway-cert.pem")
+	keyFile := filepath.Join(tlsDir, "gateway-key.pem")
+
+	if certPEM, err := os.ReadFile(certFile); err == nil {
+		if _, err := os.Stat(keyFile); err == nil {
+			if block, _ := pem.Decode(certPEM); block != nil {
+				if cert, err := x509.ParseCertificate(block.Bytes); err == nil && time.Until(cert.NotAfter) > 24*time.Hour {
+					return certFile, keyFile, nil
+				}
+			}
+		}
+	}
+
+	log.Printf("ensureSelfSignedCertificate: 生成自签名证书: %s", certFile)
+	if err := os.MkdirAll(tlsDir, 0700); err != nil {
+		return "", "", err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "ollama-intel gateway", Organization: []string{"ollama-intel"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if host := a.getConfigString("OLLAMA_GATEWAY_HOST", ""); host != "" {
+		if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// GGUF 文件魔数 "GGUF"
+const ggufMagic = 0x46554747
+
+// 数组元数据最多保留的元素数，词表等大数组只记录长度
+const ggufMaxArrayValues = 64
+
+// GGUF 元数据值类型
+const (
+	ggufTypeUint8   = 0
+	ggufTypeInt8    = 1
+	ggufTypeUint16  = 2
+	ggufTypeInt16   = 3
+	ggufTypeUint32  = 4
+	ggufTypeInt32   = 5
+	ggufTypeFloat32 = 6
+	ggufTypeBool    = 7
+	ggufTypeString  = 8
+	ggufTypeArray   = 9
+	ggufTypeUint64  = 10
+	ggufTypeInt64   = 11
+	ggufTypeFloat64 = 12
+)
+
+// ggufFileTypes general.file_type 对应的量化类型
+var ggufFileTypes = map[uint64]string{
+	0: "F32", 1: "F16", 2: "Q4_0", 3: "Q4_1", 7: "Q8_0", 8: "Q5_0", 9: "Q5_1",
+	10: "Q2_K", 11: "Q3_K_S", 12: "Q3_K_M", 13: "Q3_K_L", 14: "Q4_K_S", 15: "Q4_K_M",
+	16: "Q5_K_S", 17: "Q5_K_M", 18: "Q6_K", 19: "IQ2_XXS", 20: "IQ2_XS", 21: "Q2_K_S",
+	22: "IQ3_XS", 23: "IQ3_XXS", 24: "IQ1_S", 25: "IQ4_NL", 26: "IQ3_S", 27: "IQ3_M",
+	28: "IQ2_S", 29: "IQ2_M", 30: "IQ4_XS", 31: "IQ1_M", 32: "BF16",
+}
+
+// GGUFInfo GGUF 文件头中的模型信息，用于导入前预览
+type GGUFInfo struct {
+	Path           string `json:"path"`
+	FileSize       int64  `json:"file_size"`
+	FileSizeText   string `json:"file_size_text"`
+	Version        uint32 `json:"version"`
+	Name           string `json:"name,omitempty"`
+	Architecture   string `json:"architecture"`
+	ParameterCount uint64 `json:"parameter_count"`
+	ParameterSize  string `json:"parameter_size"`
+	Quantization   string `json:"quantization,omitempty"`
+	ContextLength  uint64 `json:"context_length,omitempty"`
+	ChatTemplate   string `json:"chat_template,omitempty"`
+	TensorCount    uint64 `json:"tensor_count"`
+	// 用于估算 KV 缓存大小的结构参数
+	BlockCount      uint64 `json:"block_count,omitempty"`
+	EmbeddingLength uint64 `json:"embedding_length,omitempty"`
+	HeadCount       uint64 `json:"head_count,omitempty"`
+	HeadCountKV     uint64 `json:"head_count_kv,omitempty"`
+}
+
+// ggufReader 按 GGUF 版本读取小端序数据
+type ggufReader struct {
+	r       *bufio.Reader
+	version uint32
+}
+
+func (g *ggufReader) read(v interface{}) error {
+	return binary.Read(g.r, binary.LittleEndian, v)
+}
+
+// readCount 读取长度或数量，GGUF v1 使用 32 位，之后的版本使用 64 位
+func (g *ggufReader) readCount() (uint64, error) {
+	if g.version == 1 {
+		var n uint32
+		err := g.read(&n)
+		return uint64(n), err
+	}
+	var n uint64
+	err := g.read(&n)
+	return n, err
+}
+
+func (g *ggufReader) readString() (string, error) {
+	n, err := g.readCount()
+	if err != nil {
+		return "", err
+	}
+	if n > 64*1024*1024 {
+		return "", fmt.Errorf("字符串长度异常: %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(g.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readValue 读取一个元数据值，数组只保留前 ggufMaxArrayValues 个元素
+func (g *ggufReader) readValue(valueType uint32) (interface{}, error) {
+	switch valueType {
+	case ggufTypeUint8:
+		var v uint8
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return uint64(v), nil
+	case ggufTypeInt8:
+		var v int8
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return int64(v), nil
+	case ggufTypeUint16:
+		var v uint16
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return uint64(v), nil
+	case ggufTypeInt16:
+		var v int16
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return int64(v), nil
+	case ggufTypeUint32:
+		var v uint32
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return uint64(v), nil
+	case ggufTypeInt32:
+		var v int32
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return int64(v), nil
+	case ggufTypeFloat32:
+		var v uint32
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(v)), nil
+	case ggufTypeBool:
+		var v uint8
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return v != 0, nil
+	case ggufTypeString:
+		return g.readString()
+	case ggufTypeUint64:
+		var v uint64
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case ggufTypeInt64:
+		var v int64
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case ggufTypeFloat64:
+		var v float64
+		if err := g.read(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case ggufTypeArray:
+		var elemType uint32
+		if err := g.read(&elemType); err != nil {
+			return nil, err
+		}
+		n, err := g.readCount()
+		if err != nil {
+			return nil, err
+		}
+		var values []interface{}
+		for i := uint64(0); i < n; i++ {
+			v, err := g.readValue(elemType)
+			if err != nil {
+				return nil, err
+			}
+			if i < ggufMaxArrayValues {
+				values = append(values, v)
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("未知的元数据类型: %d", valueType)
+	}
+}
+
+// readGGUFInfo 读取 GGUF 文件头中的元数据和张量信息
+func readGGUFInfo(path string) (*GGUFInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &ggufReader{r: bufio.NewReaderSize(f, 1024*1024)}
+	var magic uint32
+	if err := g.read(&magic); err != nil {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	if magic != ggufMagic {
+		return nil, fmt.Errorf("不是有效的 GGUF 文件: %s", path)
+	}
+	if err := g.read(&g.version); err != nil {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	if g.version < 1 || g.version > 3 {
+		return nil, fmt.Errorf("不支持的 GGUF 版本: %d", g.version)
+	}
+
+	tensorCount, err := g.readCount()
+	if err != nil {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	kvCount, err := g.readCount()
+	if err != nil {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	if tensorCount > 1<<24 || kvCount > 1<<20 {
+		return nil, fmt.Errorf("文件头数据异常，文件可能已损坏: %s", path)
+	}
+
+	metadata := make(map[string]interface{}, kvCount)
+	for i := uint64(0); i < kvCount; i++ {
+		key, err := g.readString()
+		if err != nil {
+			return nil, fmt.Errorf("读取元数据失败: %v", err)
+		}
+		var valueType uint32
+		if err := g.read(&valueType); err != nil {
+			return nil, fmt.Errorf("读取元数据失败: %v", err)
+		}
+		value, err := g.readValue(valueType)
+		if err != nil {
+			return nil, fmt.Errorf("读取元数据 %s 失败: %v", key, err)
+		}
+		metadata[key] = value
+	}
+
+	// 元数据中没有参数量时根据张量形状计算
+	var parameterCount uint64
+	for i := uint64(0); i < tensorCount; i++ {
+		if _, err := g.readString(); err != nil {
+			return nil, fmt.Errorf("读取张量信息失败: %v", err)
+		}
+		var dims uint32
+		if err := g.read(&dims); err != nil {
+			return nil, fmt.Errorf("读取张量信息失败: %v", err)
+		}
+		elements := uint64(1)
+		for d := uint32(0); d < dims; d++ {
+			size, err := g.readCount()
+			if err != nil {
+				return nil, fmt.Errorf("读取张量信息失败: %v", err)
+			}
+			elements *= size
+		}
+		var tensorType uint32
+		var offset uint64
+		if err := g.read(&tensorType); err != nil {
+			return nil, fmt.Errorf("读取张量信息失败: %v", err)
+		}
+		if err := g.read(&offset); err != nil {
+			return nil, fmt.Errorf("读取张量信息失败: %v", err)
+		}
+		parameterCount += elements
+	}
+
+	info := &GGUFInfo{
+		Path:           path,
+		FileSize:       stat.Size(),
+		FileSizeText:   formatBytes(stat.Size()),
+		Version:        g.version,
+		TensorCount:    tensorCount,
+		ParameterCount: parameterCount,
+	}
+	info.Architecture, _ = metadata["general.architecture"].(string)
+	info.Name, _ = metadata["general.name"].(string)
+	info.ChatTemplate, _ = metadata["tokenizer.chat_template"].(string)
+	if count, ok := metadata["general.parameter_count"].(uint64); ok && count > 0 {
+		info.ParameterCount = count
+	}
+	if fileType, ok := metadata["general.file_type"].(uint64); ok {
+		info.Quantization = ggufFileTypes[fileType]
+	}
+	if length, ok := metadata[info.Architecture+".context_length"].(uint64); ok {
+		info.ContextLength = length
+	}
+	info.BlockCount, _ = metadata[info.Architecture+".block_count"].(uint64)
+	info.EmbeddingLength, _ = metadata[info.Architecture+".embedding_length"].(uint64)
+	info.HeadCount, _ = metadata[info.Architecture+".attention.head_count"].(uint64)
+	info.HeadCountKV, _ = metadata[info.Architecture+".attention.head_count_kv"].(uint64)
+	info.ParameterSize = formatParameterCount(info.ParameterCount)
+	return info, nil
+}
+
+// formatParameterCount 将参数量格式化为 7.2B、500M 这样的形式
+func formatParameterCount(count uint64) string {
+	switch {
+	case count >= 1e9:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(count)/1e9), ".0") + "B"
+	case count >= 1e6:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(count)/1e6), ".0") + "M"
+	case count >= 1e3:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(count)/1e3), ".0") + "K"
+	}
+	return fmt.Sprintf("%d", count)
+}
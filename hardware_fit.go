@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 模型能否在本机运行的标签
+const (
+	ModelFitGPU      = "fits-gpu"
+	ModelFitCPU      = "fits-cpu"
+	ModelFitTooLarge = "too-large"
+	ModelFitUnknown  = "unknown"
+)
+
+// 估算内存需求时使用的常量
+const (
+	// 运行时的固定开销（计算图、CUDA/SYCL 上下文等）
+	modelRuntimeOverhead = 512 << 20
+	// 不知道模型结构时，8B 模型每个 token 的 KV 缓存（FP16，32 层，8 个 KV 头 × 128 维）
+	referenceKVBytesPerToken = 2 * 32 * 1024 * 2
+	referenceKVParameters    = 8e9
+	// 为系统和其他程序保留的内存比例
+	systemMemoryReserve = 0.2
+	// 显存中可用于模型的比例
+	gpuMemoryUsable = 0.9
+	// 集成显卡与系统共享内存，Windows 最多分配一半的物理内存
+	sharedGPUMemoryRatio = 0.5
+	// 专用显存小于该值时视为集成显卡（驱动只报告少量预留显存）
+	dedicatedGPUMemoryMin = 2 << 30
+	// 未知量化类型时使用 Ollama 默认的 Q4_K_M
+	defaultBitsPerWeight = 4.85
+)
+
+// quantizationBits 各量化类型每个权重的平均位数
+var quantizationBits = map[string]float64{
+	"F32": 32, "F16": 16, "BF16": 16,
+	"Q8_0": 8.5, "Q6_K": 6.56,
+	"Q5_0": 5.5, "Q5_1": 6, "Q5_K_S": 5.54, "Q5_K_M": 5.69,
+	"Q4_0": 4.55, "Q4_1": 5, "Q4_K_S": 4.58, "Q4_K_M": 4.85,
+	"Q3_K_S": 3.5, "Q3_K_M": 3.91, "Q3_K_L": 4.27,
+	"Q2_K": 3.35, "Q2_K_S": 2.96,
+	"IQ4_XS": 4.25, "IQ4_NL": 4.5, "IQ3_S": 3.44, "IQ3_M": 3.66, "IQ3_XS": 3.3, "IQ3_XXS": 3.06,
+	"IQ2_S": 2.5, "IQ2_M": 2.7, "IQ2_XS": 2.31, "IQ2_XXS": 2.06, "IQ1_S": 1.56, "IQ1_M": 1.75,
+}
+
+// 参数量，例如 8B、1.5B、500M、8x7B
+var parameterSizeRegex = regexp.MustCompile(`(?i)^(?:(\d+)\s*x\s*)?(\d+(?:\.\d+)?)\s*([bmk])$`)
+
+// gpuDevice 检测到的显卡
+type gpuDevice struct {
+	Name   string
+	Memory uint64 // 专用显存（字节）
+}
+
+// HardwareProfile 本机用于运行模型的内存和显存
+type HardwareProfile struct {
+	TotalRAM      uint64 `json:"total_ram"`
+	AvailableRAM  uint64 `json:"available_ram"`
+	GPUName       string `json:"gpu_name,omitempty"`
+	GPUMemory     uint64 `json:"gpu_memory"`   // 可用于模型的显存，集成显卡为共享内存
+	GPUShared     bool   `json:"gpu_shared"`   // 集成显卡，与系统共享内存
+	GPUOverridden bool   `json:"gpu_override"` // 显存来自 OLLAMA_GPU_MEMORY 设置
+	TotalRAMText  string `json:"total_ram_text"`
+	GPUMemoryText string `json:"gpu_memory_text"`
+}
+
+// ModelFit 模型的内存需求估算和能否运行的判断
+type ModelFit struct {
+	Label         string `json:"label"`
+	RequiredBytes int64  `json:"required_bytes"`
+	RequiredText  string `json:"required_text"`
+	WeightsBytes  int64  `json:"weights_bytes"`
+	KVCacheBytes  int64  `json:"kv_cache_bytes"`
+	ContextLength int    `json:"context_length"`
+	Message       string `json:"message"`
+}
+
+// modelShape 估算内存需求所需的模型信息，未知的字段为 0 或空
+type modelShape struct {
+	Parameters      float64
+	Quantization    string
+	FileSize        int64 // 已知的模型文件大小，优先于参数量和量化推算
+	ContextLength   int   // 模型支持的最大上下文长度
+	BlockCount      uint64
+	EmbeddingLength uint64
+	HeadCount       uint64
+	HeadCountKV     uint64
+}
+
+var (
+	gpuDevicesOnce sync.Once
+	gpuDevices     []gpuDevice
+)
+
+// parseParameterSize 解析参数量字符串，例如 8B -> 8e9，8x7B -> 56e9
+func parseParameterSize(value string) float64 {
+	matches := parameterSizeRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0
+	}
+	number, err := strconv.ParseFloat(matches[2], 64)
+	if err != nil {
+		return 0
+	}
+	if matches[1] != "" {
+		experts, _ := strconv.Atoi(matches[1])
+		number *= float64(experts)
+	}
+	switch strings.ToLower(matches[3]) {
+	case "b":
+		return number * 1e9
+	case "m":
+		return number * 1e6
+	default:
+		return number * 1e3
+	}
+}
+
+// 文件大小的单位，KB、MB、GB 为十进制（与 ollama.com 和 ModelScope 显示的大小一致），KiB、MiB、GiB 为二进制
+var byteSizeUnits = []struct {
+	suffix string
+	factor float64
+}{
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"K", 1e3},
+	{"B", 1},
+}
+
+// parseByteSize 解析文件或显存大小，例如 "4.7GB" -> 4.7e9，"8GiB" -> 8*2^30，没有单位时为字节数
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	factor := 1.0
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			factor = unit.factor
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("无效的大小: %s", value)
+	}
+	return int64(math.Round(number * factor)), nil
+}
+
+// kvBytesPerToken 每个 token 的 KV 缓存大小（FP16），没有模型结构时按参数量从 8B 模型推算
+func (s modelShape) kvBytesPerToken() float64 {
+	if s.BlockCount > 0 && s.EmbeddingLength > 0 && s.HeadCount > 0 {
+		headCountKV := s.HeadCountKV
+		if headCountKV == 0 {
+			headCountKV = s.HeadCount
+		}
+		headDim := float64(s.EmbeddingLength) / float64(s.HeadCount)
+		return 2 * float64(s.BlockCount) * float64(headCountKV) * headDim * 2
+	}
+	return referenceKVBytesPerToken * math.Sqrt(s.Parameters/referenceKVParameters)
+}
+
+// estimateModelMemory 估算模型在给定上下文长度下需要的内存（权重 + KV 缓存 + 运行时开销）
+func estimateModelMemory(shape modelShape, contextLength int) (weights, kvCache, total int64, ok bool) {
+	weights = shape.FileSize
+	if weights <= 0 && shape.Parameters > 0 {
+		bits, found := quantizationBits[strings.ToUpper(shape.Quantization)]
+		if !found {
+			bits = defaultBitsPerWeight
+		}
+		weights = int64(shape.Parameters * bits / 8)
+	}
+	if weights <= 0 {
+		return 0, 0, 0, false
+	}
+	if shape.Parameters <= 0 && shape.BlockCount == 0 {
+		// 只知道文件大小时按 Q4_K_M 反推参数量，用于估算 KV 缓存
+		shape.Parameters = float64(weights) * 8 / defaultBitsPerWeight
+	}
+
+	if shape.ContextLength > 0 && shape.ContextLength < contextLength {
+		contextLength = shape.ContextLength
+	}
+	kvCache = int64(shape.kvBytesPerToken() * float64(contextLength))
+	total = weights + kvCache + modelRuntimeOverhead
+	return weights, kvCache, total, true
+}
+
+// getHardwareProfile 检测本机内存和显存，OLLAMA_GPU_MEMORY 可以手动指定可用显存
+func (a *App) getHardwareProfile() HardwareProfile {
+	var profile HardwareProfile
+	total, available, err := getSystemMemory()
+	if err != nil {
+		log.Printf("getHardwareProfile: 获取内存信息失败: %v", err)
+	}
+	profile.TotalRAM, profile.AvailableRAM = total, available
+
+	// 显卡检测需要调用 PowerShell，只检测一次
+	gpuDevicesOnce.Do(func() {
+		gpuDevices = detectGPUDevices()
+	})
+	for _, device := range gpuDevices {
+		lower := strings.ToLower(device.Name)
+		// 使用 Intel GPU 时只考虑 Intel 显卡，优先选择显存最大的
+		if a.getConfigBool("OLLAMA_INTEL_GPU", true) && !strings.Contains(lower, "intel") {
+			continue
+		}
+		memory := device.Memory
+		shared := memory < dedicatedGPUMemoryMin
+		if shared {
+			memory = uint64(float64(total) * sharedGPUMemoryRatio)
+		}
+		if memory > profile.GPUMemory {
+			profile.GPUName, profile.GPUMemory, profile.GPUShared = device.Name, memory, shared
+		}
+	}
+
+	if value := a.getConfigString("OLLAMA_GPU_MEMORY", ""); value != "" {
+		if size, err := parseByteSize(value); err == nil && size > 0 {
+			profile.GPUMemory, profile.GPUOverridden = uint64(size), true
+		} else {
+			log.Printf("getHardwareProfile: 无效的 OLLAMA_GPU_MEMORY: %s", value)
+		}
+	}
+
+	profile.TotalRAMText = formatBytes(int64(profile.TotalRAM))
+	profile.GPUMemoryText = formatBytes(int64(profile.GPUMemory))
+	return profile
+}
+
+// estimateModelFit 判断模型能否放入显存、内存，或者超出本机内存
+func (a *App) estimateModelFit(shape modelShape, profile HardwareProfile) ModelFit {
+	contextLength := a.getConfigInt("OLLAMA_NUM_CTX", 2048)
+	weights, kvCache, required, ok := estimateModelMemory(shape, contextLength)
+	if !ok {
+		return ModelFit{Label: ModelFitUnknown, Message: "缺少参数量或模型大小，无法估算"}
+	}
+
+	fit := ModelFit{
+		RequiredBytes: required,
+		RequiredText:  formatBytes(required),
+		WeightsBytes:  weights,
+		KVCacheBytes:  kvCache,
+		ContextLength: contextLength,
+	}
+	if shape.ContextLength > 0 && shape.ContextLength < contextLength {
+		fit.ContextLength = shape.ContextLength
+	}
+
+	usableRAM := float64(profile.TotalRAM) * (1 - systemMemoryReserve)
+	switch {
+	case profile.TotalRAM == 0:
+		fit.Label = ModelFitUnknown
+		fit.Message = fmt.Sprintf("预计需要 %s，无法检测本机内存", fit.RequiredText)
+	case profile.GPUMemory > 0 && float64(required) <= float64(profile.GPUMemory)*gpuMemoryUsable:
+		fit.Label = ModelFitGPU
+		fit.Message = fmt.Sprintf("预计需要 %s，可以完全加载到显卡（%s）", fit.RequiredText, profile.GPUMemoryText)
+	case float64(required) <= usableRAM:
+		fit.Label = ModelFitCPU
+		fit.Message = fmt.Sprintf("预计需要 %s，超出显存但可以在内存（%s）中运行，速度较慢", fit.RequiredText, profile.TotalRAMText)
+	default:
+		fit.Label = ModelFitTooLarge
+		fit.Message = fmt.Sprintf("预计需要 %s，超过本机内存（%s），无法正常运行", fit.RequiredText, profile.TotalRAMText)
+	}
+	return fit
+}
+
+// onlineModelShape 从在线模型列表的 size 和 details 中提取模型信息
+func onlineModelShape(model map[string]interface{}) modelShape {
+	var shape modelShape
+	if size := getString(model, "size"); size != "" {
+		if bytes, err := parseByteSize(size); err == nil {
+			shape.FileSize = bytes
+		}
+	}
+	if details, ok := model["details"].(map[string]interface{}); ok {
+		shape.Parameters = parseParameterSize(getString(details, "parameter_size"))
+		shape.Quantization = getString(details, "quantization_level")
+		if length, ok := details["context_length"].(float64); ok {
+			shape.ContextLength = int(length)
+		} else if length, ok := details["context_length"].(int); ok {
+			shape.ContextLength = length
+		}
+	}
+	return shape
+}
+
+// OnlineSizeFit 在线模型一个参数量版本的估算结果
+type OnlineSizeFit struct {
+	Size string   `json:"size"`
+	Fit  ModelFit `json:"fit"`
+}
+
+// 估算结果从好到差的顺序，用于在多个版本中选出最容易运行的版本
+var modelFitRank = map[string]int{
+	ModelFitGPU:      0,
+	ModelFitCPU:      1,
+	ModelFitTooLarge: 2,
+	ModelFitUnknown:  3,
+}
+
+// onlineModelSizeShapes 在线模型各参数量版本（sizes）的模型信息
+// 模型大小只描述其中一个版本，有多个版本时不使用，按参数量和量化类型推算
+func onlineModelSizeShapes(model map[string]interface{}) ([]string, []modelShape) {
+	base := onlineModelShape(model)
+	sizes := stringList(model, "sizes")
+	shapes := make([]modelShape, 0, len(sizes))
+	for _, size := range sizes {
+		shape := base
+		if params := parseParameterSize(size); params > 0 {
+			shape.Parameters = params
+		}
+		if len(sizes) > 1 {
+			shape.FileSize = 0
+		}
+		shapes = append(shapes, shape)
+	}
+	return sizes, shapes
+}
+
+// bestModelFit 返回最容易运行的版本的估算结果
+func bestModelFit(fits []OnlineSizeFit) ModelFit {
+	best := fits[0].Fit
+	for _, sizeFit := range fits[1:] {
+		if modelFitRank[sizeFit.Fit.Label] < modelFitRank[best.Label] {
+			best = sizeFit.Fit
+		}
+	}
+	return best
+}
+
+// annotateModelFit 为在线模型列表添加 fit（估算结果）、fit_label 和 size_fits（每个参数量版本的估算结果）字段，
+// 返回新的列表，不修改原有的 map。有多个版本时 fit 和 fit_label 描述最容易运行的版本，
+// 按能否运行筛选时只要有一个版本可以运行就保留该模型
+func (a *App) annotateModelFit(models []map[string]interface{}) []map[string]interface{} {
+	profile := a.getHardwareProfile()
+	annotated := make([]map[string]interface{}, 0, len(models))
+	for _, model := range models {
+		sizes, shapes := onlineModelSizeShapes(model)
+		var fit ModelFit
+		var sizeFits []OnlineSizeFit
+		if len(shapes) == 0 {
+			fit = a.estimateModelFit(onlineModelShape(model), profile)
+		} else {
+			for i, shape := range shapes {
+				sizeFits = append(sizeFits, OnlineSizeFit{Size: sizes[i], Fit: a.estimateModelFit(shape, profile)})
+			}
+			fit = bestModelFit(sizeFits)
+		}
+
+		copied := make(map[string]interface{}, len(model)+3)
+		for key, value := range model {
+			copied[key] = value
+		}
+		copied["fit"] = fit
+		copied["fit_label"] = fit.Label
+		if sizeFits != nil {
+			copied["size_fits"] = sizeFits
+		}
+		annotated = append(annotated, copied)
+	}
+	return annotated
+}
+
+// ggufModelShape 从 GGUF 文件头中提取模型信息
+func ggufModelShape(info *GGUFInfo) modelShape {
+	return modelShape{
+		Parameters:      float64(info.ParameterCount),
+		Quantization:    info.Quantization,
+		FileSize:        info.FileSize,
+		ContextLength:   int(info.ContextLength),
+		BlockCount:      info.BlockCount,
+		EmbeddingLength: info.EmbeddingLength,
+		HeadCount:       info.HeadCount,
+		HeadCountKV:     info.HeadCountKV,
+	}
+}
+
+// GetHardwareProfile 获取本机用于运行模型的内存和显存
+func (a *App) GetHardwareProfile() HardwareProfile {
+	return a.getHardwareProfile()
+}
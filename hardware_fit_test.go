@@ -0,0 +1,110 @@
+package main
+
+import "testing"
+
+func TestOnlineModelSizeShapes(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      map[string]interface{}
+		wantParams []float64
+		wantFile   int64
+	}{
+		{
+			name:  "没有版本",
+			model: map[string]interface{}{"details": map[string]interface{}{"parameter_size": "8B"}},
+		},
+		{
+			name: "单个版本保留模型大小",
+			model: map[string]interface{}{
+				"size":    "4.7GB",
+				"sizes":   []string{"8b"},
+				"details": map[string]interface{}{"parameter_size": "8B"},
+			},
+			wantParams: []float64{8e9},
+			wantFile:   4700000000,
+		},
+		{
+			name: "多个版本按参数量推算",
+			model: map[string]interface{}{
+				"size":    "4.7GB",
+				"sizes":   []interface{}{"8b", "70b", "405b"},
+				"details": map[string]interface{}{"parameter_size": "8B", "quantization_level": "Q4_K_M"},
+			},
+			wantParams: []float64{8e9, 70e9, 405e9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes, shapes := onlineModelSizeShapes(tt.model)
+			if len(sizes) != len(tt.wantParams) || len(shapes) != len(tt.wantParams) {
+				t.Fatalf("got %d sizes, %d shapes, want %d", len(sizes), len(shapes), len(tt.wantParams))
+			}
+			for i, shape := range shapes {
+				if shape.Parameters != tt.wantParams[i] {
+					t.Errorf("shapes[%d].Parameters = %v, want %v", i, shape.Parameters, tt.wantParams[i])
+				}
+				if shape.FileSize != tt.wantFile {
+					t.Errorf("shapes[%d].FileSize = %d, want %d", i, shape.FileSize, tt.wantFile)
+				}
+			}
+		})
+	}
+}
+
+func TestBestModelFit(t *testing.T) {
+	tests := []struct {
+		labels []string
+		want   string
+	}{
+		{[]string{ModelFitTooLarge}, ModelFitTooLarge},
+		{[]string{ModelFitUnknown, ModelFitTooLarge}, ModelFitTooLarge},
+		{[]string{ModelFitTooLarge, ModelFitCPU, ModelFitGPU}, ModelFitGPU},
+		{[]string{ModelFitGPU, ModelFitCPU}, ModelFitGPU},
+		{[]string{ModelFitCPU, ModelFitTooLarge, ModelFitUnknown}, ModelFitCPU},
+	}
+	for _, tt := range tests {
+		fits := make([]OnlineSizeFit, 0, len(tt.labels))
+		for _, label := range tt.labels {
+			fits = append(fits, OnlineSizeFit{Fit: ModelFit{Label: label}})
+		}
+		if got := bestModelFit(fits).Label; got != tt.want {
+			t.Errorf("bestModelFit(%v) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512B", 512, false},
+		{"4.7GB", 4700000000, false},
+		{"4.7 gb", 4700000000, false},
+		{"274MB", 274000000, false},
+		{"1.5KB", 1500, false},
+		{"1.2TB", 1200000000000, false},
+		{"8G", 8000000000, false},
+		{"8GiB", 8 << 30, false},
+		{"512MiB", 512 << 20, false},
+		{"2KiB", 2048, false},
+		{"", 0, true},
+		{"GB", 0, true},
+		{"-1GB", 0, true},
+		{"10MB/s", 0, true},
+		{"abc", 0, true},
+		{"NaN", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseByteSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HealthCheckResult 单项检查结果
+type HealthCheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+}
+
+// LoadedModelStatus 已加载到内存中的模型（来自 /api/ps）
+type LoadedModelStatus struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	SizeVRAM  int64  `json:"size_vram"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// DiskSpaceStatus 模型目录所在磁盘的空间信息
+type DiskSpaceStatus struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// HealthResponse /healthz 响应
+type HealthResponse struct {
+	Status        string `json:"status"`
+	Time          string `json:"time"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// ReadinessResponse /readyz 响应
+type ReadinessResponse struct {
+	Status        string                       `json:"status"`
+	Time          string                       `json:"time"`
+	OllamaVersion string                       `json:"ollama_version,omitempty"`
+	LoadedModels  []LoadedModelStatus          `json:"loaded_models"`
+	Disk          *DiskSpaceStatus             `json:"disk,omitempty"`
+	Checks        map[string]HealthCheckResult `json:"checks"`
+}
+
+// handleHealthz 网关存活检查，只要HTTP服务器能响应即返回200
+func (a *App) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := HealthResponse{
+		Status:        "ok",
+		Time:          time.Now().Format(time.RFC3339),
+		UptimeSeconds: int64(time.Since(a.startTime).Seconds()),
+	}
+
+	writeHealthJSON(w, http.StatusOK, response)
+}
+
+// handleReadyz 就绪检查：上游 Ollama 服务、已加载模型和模型目录磁盘空间
+// 上游服务不可用时返回503，便于负载均衡器摘除节点
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := ReadinessResponse{
+		Status:       "ready",
+		Time:         time.Now().Format(time.RFC3339),
+		LoadedModels: []LoadedModelStatus{},
+		Checks:       make(map[string]HealthCheckResult),
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	// 检查上游 Ollama 服务及版本
+	version, check := a.checkUpstreamVersion(client)
+	response.Checks["ollama"] = check
+	response.OllamaVersion = version
+	if check.Status != "ok" {
+		response.Status = "not_ready"
+	}
+
+	// 获取已加载的模型
+	if check.Status == "ok" {
+		models, psCheck := a.checkLoadedModels(client)
+		response.Checks["loaded_models"] = psCheck
+		if models != nil {
+			response.LoadedModels = models
+		}
+	}
+
+	// 检查模型目录磁盘空间（仅作信息展示，不影响就绪状态）
+	disk, diskCheck := a.checkModelsDiskSpace()
+	response.Checks["disk"] = diskCheck
+	response.Disk = disk
+
+	statusCode := http.StatusOK
+	if response.Status != "ready" {
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeHealthJSON(w, statusCode, response)
+}
+
+// checkUpstreamVersion 通过 /api/version 检查 Ollama 服务
+func (a *App) checkUpstreamVersion(client *http.Client) (string, HealthCheckResult) {
+	start := time.Now()
+	resp, err := client.Get(OllamaAPIBaseURL + "/api/version")
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		return "", HealthCheckResult{Status: "error", Error: err.Error(), LatencyMs: latency}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", HealthCheckResult{
+			Status:    "error",
+			Error:     fmt.Sprintf("状态码: %d", resp.StatusCode),
+			LatencyMs: latency,
+		}
+	}
+
+	var result struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", HealthCheckResult{Status: "error", Error: err.Error(), LatencyMs: latency}
+	}
+
+	return result.Version, HealthCheckResult{Status: "ok", LatencyMs: latency}
+}
+
+// checkLoadedModels 通过 /api/ps 获取已加载到内存中的模型
+func (a *App) checkLoadedModels(client *http.Client) ([]LoadedModelStatus, HealthCheckResult) {
+	start := time.Now()
+	resp, err := client.Get(OllamaAPIBaseURL + "/api/ps")
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		return nil, HealthCheckResult{Status: "error", Error: err.Error(), LatencyMs: latency}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, HealthCheckResult{
+			Status:    "error",
+			Error:     fmt.Sprintf("状态码: %d", resp.StatusCode),
+			LatencyMs: latency,
+		}
+	}
+
+	var result struct {
+		Models []struct {
+			Name      string `json:"name"`
+			Size      int64  `json:"size"`
+			SizeVRAM  int64  `json:"size_vram"`
+			ExpiresAt string `json:"expires_at"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, HealthCheckResult{Status: "error", Error: err.Error(), LatencyMs: latency}
+	}
+
+	models := make([]LoadedModelStatus, 0, len(result.Models))
+	for _, m := range result.Models {
+		models = append(models, LoadedModelStatus{
+			Name:      m.Name,
+			Size:      m.Size,
+			SizeVRAM:  m.SizeVRAM,
+			ExpiresAt: m.ExpiresAt,
+		})
+	}
+	return models, HealthCheckResult{Status: "ok", LatencyMs: latency}
+}
+
+// checkModelsDiskSpace 获取模型目录所在磁盘的空间信息
+// 模型目录尚未创建时，向上查找最近的已存在目录
+func (a *App) checkModelsDiskSpace() (*DiskSpaceStatus, HealthCheckResult) {
+	modelsDir := a.getOllamaModelsDir()
+
+	path := modelsDir
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+
+	total, free, err := getDiskUsage(path)
+	if err != nil {
+		return nil, HealthCheckResult{Status: "error", Error: err.Error()}
+	}
+
+	usedPercent := 0.0
+	if total > 0 {
+		usedPercent = float64(total-free) / float64(total) * 100
+	}
+
+	return &DiskSpaceStatus{
+		Path:        modelsDir,
+		TotalBytes:  total,
+		FreeBytes:   free,
+		UsedPercent: usedPercent,
+	}, HealthCheckResult{Status: "ok"}
+}
+
+// writeHealthJSON 写入健康检查JSON响应，禁止缓存
+func writeHealthJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
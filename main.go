@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +14,11 @@ import (
 var assets embed.FS
 
 func main() {
+	// 带子命令运行时作为命令行工具执行
+	if code, handled := runCLI(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
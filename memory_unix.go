@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// getSystemMemory 从 /proc/meminfo 获取物理内存的总量和可用量（字节）
+func getSystemMemory() (total uint64, available uint64, err error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = value * 1024
+		case "MemAvailable:":
+			available = value * 1024
+		}
+	}
+	return total, available, scanner.Err()
+}
+
+// detectGPUDevices 非 Windows 平台不检测显卡，可通过 OLLAMA_GPU_MEMORY 手动设置显存
+func detectGPUDevices() []gpuDevice {
+	return nil
+}
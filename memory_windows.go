@@ -0,0 +1,66 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var procGlobalMemoryStatusEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+// memoryStatusEx 对应 Windows 的 MEMORYSTATUSEX 结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// getSystemMemory 获取物理内存的总量和可用量（字节）
+func getSystemMemory() (total uint64, available uint64, err error) {
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	ret, _, callErr := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return 0, 0, callErr
+	}
+	return status.TotalPhys, status.AvailPhys, nil
+}
+
+// detectGPUDevices 从显卡驱动的注册表项读取显卡名称和专用显存
+// Win32_VideoController 的 AdapterRAM 是 32 位值，超过 4GB 的显存会被截断，因此优先使用 qwMemorySize
+func detectGPUDevices() []gpuDevice {
+	script := `Get-ItemProperty "HKLM:\SYSTEM\ControlSet001\Control\Class\{4d36e968-e325-11ce-bfc1-08002be10318}\0*" -ErrorAction SilentlyContinue | ` +
+		`ForEach-Object { "$($_.DriverDesc)|$($_.'HardwareInformation.qwMemorySize')|$($_.'HardwareInformation.MemorySize')" }`
+	cmd := exec.Command("powershell", "-NoProfile", "-Command", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var devices []gpuDevice
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		device := gpuDevice{Name: strings.TrimSpace(parts[0])}
+		if size, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64); err == nil {
+			device.Memory = size
+		} else if size, err := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 64); err == nil {
+			device.Memory = size
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
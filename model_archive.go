@@ -0,0 +1,542 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 模型归档格式版本和索引文件名
+const (
+	modelArchiveFormatVersion = 1
+	modelArchiveIndexName     = "ollama-export.json"
+)
+
+// modelArchiveModel 归档中的一个模型
+type modelArchiveModel struct {
+	Name     string `json:"name"`
+	Manifest string `json:"manifest"` // 清单在归档中的路径
+	SHA256   string `json:"sha256"`   // 清单文件的校验和
+	Size     int64  `json:"size"`
+}
+
+// modelArchiveIndex 归档索引，作为归档的第一个文件
+type modelArchiveIndex struct {
+	FormatVersion int                 `json:"format_version"`
+	ExportedAt    string              `json:"exported_at"`
+	Models        []modelArchiveModel `json:"models"`
+	Blobs         map[string]int64    `json:"blobs"` // digest -> 大小
+}
+
+// ModelArchiveResult 导出或导入归档的结果
+type ModelArchiveResult struct {
+	Path         string   `json:"path"`
+	Models       []string `json:"models"`
+	Skipped      []string `json:"skipped,omitempty"` // 本地已存在相同内容的模型
+	BlobsWritten int      `json:"blobs_written"`
+	BlobsSkipped int      `json:"blobs_skipped"` // 本地已存在的 blob
+	Bytes        int64    `json:"bytes"`
+}
+
+// archiveProgress 发送模型归档进度事件
+func (a *App) archiveProgress(operation, status string, completed, total int64, message string) {
+	progress := 0.0
+	if total > 0 {
+		progress = float64(completed) / float64(total) * 100
+	}
+	event := map[string]interface{}{
+		"operation": operation,
+		"status":    status,
+		"progress":  progress,
+		"completed": completed,
+		"total":     total,
+		"message":   message,
+		"time":      time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "model_archive_progress", event)
+	}
+	if a.cliProgress != nil {
+		a.cliProgress(event)
+	}
+}
+
+// archiveManifestPath 清单在归档中的路径
+func archiveManifestPath(name string) string {
+	ref := parseModelReference(name)
+	return path.Join("manifests", ref.Host, ref.Namespace, ref.Model, ref.Tag)
+}
+
+// archiveImportPath 校验归档中的模型名称，返回清单的本地路径，路径必须位于清单目录内
+func (a *App) archiveImportPath(name string) (string, error) {
+	if _, err := validateModelName(name); err != nil {
+		return "", fmt.Errorf("归档中的模型名称无效: %v", err)
+	}
+	root := filepath.Clean(a.getManifestsDir())
+	target := filepath.Clean(a.manifestPath(name))
+	if rel, err := filepath.Rel(root, target); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("归档中的模型名称无效: %s", name)
+	}
+	return target, nil
+}
+
+// archiveBlobPath blob 在归档中的路径
+func archiveBlobPath(digest string) string {
+	return path.Join("blobs", strings.Replace(digest, ":", "-", 1))
+}
+
+// sha256Hex 计算数据的 SHA256
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// exportModels 将模型的清单和引用的 blob 导出到 tar 归档，写入时校验每个 blob 的 digest
+func (a *App) exportModels(ctx context.Context, names []string, archivePath string) (*ModelArchiveResult, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("没有要导出的模型")
+	}
+
+	index := modelArchiveIndex{
+		FormatVersion: modelArchiveFormatVersion,
+		ExportedAt:    time.Now().Format(time.RFC3339),
+		Blobs:         make(map[string]int64),
+	}
+	manifests := make(map[string][]byte)
+	var total int64
+	for _, name := range names {
+		name = parseModelReference(name).String()
+		data, err := os.ReadFile(a.manifestPath(name))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", errModelNotFound, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var manifest modelManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("解析模型清单失败: %s, %v", name, err)
+		}
+
+		for _, digest := range manifest.digests() {
+			if _, ok := index.Blobs[digest]; ok {
+				continue
+			}
+			info, err := os.Stat(a.blobPath(digest))
+			if err != nil {
+				return nil, fmt.Errorf("模型 %s 缺少数据文件 %s: %v", name, digest, err)
+			}
+			index.Blobs[digest] = info.Size()
+			total += info.Size()
+		}
+
+		manifestPath := archiveManifestPath(name)
+		manifests[manifestPath] = data
+		index.Models = append(index.Models, modelArchiveModel{
+			Name:     name,
+			Manifest: manifestPath,
+			SHA256:   sha256Hex(data),
+			Size:     manifest.totalSize(),
+		})
+	}
+
+	// 先写入临时文件，完成后再重命名，避免留下不完整的归档
+	partialPath := archivePath + ".partial"
+	f, err := os.Create(partialPath)
+	if err != nil {
+		return nil, err
+	}
+	success := false
+	defer func() {
+		if !success {
+			f.Close()
+			os.Remove(partialPath)
+		}
+	}()
+
+	tw := tar.NewWriter(f)
+	writeFile := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(modelArchiveIndexName, indexData); err != nil {
+		return nil, err
+	}
+
+	digests := make([]string, 0, len(index.Blobs))
+	for digest := range index.Blobs {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+
+	var written int64
+	var lastEmit time.Time
+	for _, digest := range digests {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := a.exportBlob(tw, digest, index.Blobs[digest], func(n int64) {
+			written += n
+			if time.Since(lastEmit) >= pullProgressEmitInterval {
+				lastEmit = time.Now()
+				a.archiveProgress("export", "writing", written, total, "导出数据: "+digest)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 清单放在最后，导入时所有 blob 校验通过后才恢复清单
+	for _, model := range index.Models {
+		if err := writeFile(model.Manifest, manifests[model.Manifest]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(partialPath, archivePath); err != nil {
+		return nil, err
+	}
+	success = true
+
+	result := &ModelArchiveResult{Path: archivePath, BlobsWritten: len(digests), Bytes: total}
+	for _, model := range index.Models {
+		result.Models = append(result.Models, model.Name)
+	}
+	a.archiveProgress("export", "completed", total, total, "导出完成: "+archivePath)
+	return result, nil
+}
+
+// exportBlob 将 blob 写入归档并校验 digest
+func (a *App) exportBlob(tw *tar.Writer, digest string, size int64, onWrite func(n int64)) error {
+	f, err := os.Open(a.blobPath(digest))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    archiveBlobPath(digest),
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	reader := &progressReader{reader: io.TeeReader(f, h)}
+	var last int64
+	reader.onProgress = func(read int64) {
+		onWrite(read - last)
+		last = read
+	}
+	if _, err := io.CopyN(tw, reader, size); err != nil {
+		return fmt.Errorf("写入数据文件 %s 失败: %v", digest, err)
+	}
+	if actual := "sha256:" + hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return fmt.Errorf("数据文件已损坏: %s（实际校验和 %s）", digest, actual)
+	}
+	return nil
+}
+
+// importModelArchive 从 tar 归档恢复模型，校验每个 blob 的 digest，本地已存在的 blob 不重复写入
+func (a *App) importModelArchive(ctx context.Context, archivePath string) (*ModelArchiveResult, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	header, err := tr.Next()
+	if err != nil || header.Name != modelArchiveIndexName {
+		return nil, fmt.Errorf("不是有效的模型归档: 缺少 %s", modelArchiveIndexName)
+	}
+	var index modelArchiveIndex
+	if err := json.NewDecoder(tr).Decode(&index); err != nil {
+		return nil, fmt.Errorf("解析归档索引失败: %v", err)
+	}
+	if index.FormatVersion > modelArchiveFormatVersion {
+		return nil, fmt.Errorf("不支持的归档格式版本: %d", index.FormatVersion)
+	}
+
+	result := &ModelArchiveResult{Path: archivePath}
+	models := make(map[string]modelArchiveModel)
+	for _, model := range index.Models {
+		// 归档来自外部，模型名称必须合法且清单路径不能超出清单目录
+		manifestPath, err := a.archiveImportPath(model.Name)
+		if err != nil {
+			return nil, err
+		}
+		model.Name = parseModelReference(model.Name).String()
+
+		// 本地已存在同名模型时，内容相同则跳过，不同则拒绝导入
+		if data, err := os.ReadFile(manifestPath); err == nil {
+			if sha256Hex(data) == model.SHA256 {
+				result.Skipped = append(result.Skipped, model.Name)
+				continue
+			}
+			return nil, fmt.Errorf("%w: %s", errModelExists, model.Name)
+		}
+		models[model.Manifest] = model
+	}
+
+	var total int64
+	for _, size := range index.Blobs {
+		total += size
+	}
+
+	if err := os.MkdirAll(a.getBlobsDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	var processed int64
+	var lastEmit time.Time
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取归档失败: %v", err)
+		}
+
+		switch {
+		case strings.HasPrefix(header.Name, "blobs/"):
+			digest := strings.Replace(path.Base(header.Name), "-", ":", 1)
+			size, ok := index.Blobs[digest]
+			if !ok || size != header.Size || !blobFileRegex.MatchString(path.Base(header.Name)) {
+				return nil, fmt.Errorf("归档中的数据文件与索引不一致: %s", header.Name)
+			}
+			wrote, err := a.importBlob(tr, digest, size, func(n int64) {
+				processed += n
+				if time.Since(lastEmit) >= pullProgressEmitInterval {
+					lastEmit = time.Now()
+					a.archiveProgress("import", "writing", processed, total, "导入数据: "+digest)
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+			if wrote {
+				result.BlobsWritten++
+				result.Bytes += size
+			} else {
+				result.BlobsSkipped++
+			}
+		case strings.HasPrefix(header.Name, "manifests/"):
+			model, ok := models[header.Name]
+			if !ok {
+				continue
+			}
+			if err := a.restoreManifest(tr, model); err != nil {
+				return nil, err
+			}
+			result.Models = append(result.Models, model.Name)
+		}
+	}
+
+	if len(result.Models)+len(result.Skipped) != len(index.Models) {
+		return nil, fmt.Errorf("归档不完整: 只恢复了 %d/%d 个模型", len(result.Models)+len(result.Skipped), len(index.Models))
+	}
+	a.archiveProgress("import", "completed", total, total, "导入完成: "+archivePath)
+	return result, nil
+}
+
+// importBlob 将归档中的 blob 写入模型目录并校验 digest，本地已存在时跳过并返回 false
+func (a *App) importBlob(r io.Reader, digest string, size int64, onRead func(n int64)) (bool, error) {
+	target := a.blobPath(digest)
+	if info, err := os.Stat(target); err == nil && info.Size() == size {
+		n, err := io.CopyN(io.Discard, r, size)
+		onRead(n)
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(a.getBlobsDir(), "import-*.partial")
+	if err != nil {
+		return false, err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	h := sha256.New()
+	reader := &progressReader{reader: r}
+	var last int64
+	reader.onProgress = func(read int64) {
+		onRead(read - last)
+		last = read
+	}
+	if _, err := io.CopyN(io.MultiWriter(tmp, h), reader, size); err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("写入数据文件 %s 失败: %v", digest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if actual := "sha256:" + hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return false, fmt.Errorf("数据文件校验失败: %s（实际校验和 %s）", digest, actual)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restoreManifest 校验并恢复模型清单，确认引用的 blob 都已存在
+func (a *App) restoreManifest(r io.Reader, model modelArchiveModel) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if sha256Hex(data) != model.SHA256 {
+		return fmt.Errorf("模型清单校验失败: %s", model.Name)
+	}
+
+	var manifest modelManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("解析模型清单失败: %s, %v", model.Name, err)
+	}
+	for _, digest := range manifest.digests() {
+		if _, err := os.Stat(a.blobPath(digest)); err != nil {
+			return fmt.Errorf("模型 %s 缺少数据文件 %s", model.Name, digest)
+		}
+	}
+	target, err := a.archiveImportPath(model.Name)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(target, data, 0644)
+}
+
+// SelectExportPath 打开保存对话框选择导出文件路径
+func (a *App) SelectExportPath(name string) string {
+	defaultName := strings.NewReplacer(":", "-", "/", "-").Replace(parseModelReference(name).String()) + ".tar"
+	path, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:           "导出模型",
+		DefaultFilename: defaultName,
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "模型归档 (*.tar)", Pattern: "*.tar"},
+		},
+	})
+	if err != nil {
+		log.Printf("SelectExportPath: %v", err)
+		return ""
+	}
+	return path
+}
+
+// SelectModelArchive 打开文件选择对话框选择要导入的模型归档
+func (a *App) SelectModelArchive() string {
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "选择模型归档",
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "模型归档 (*.tar)", Pattern: "*.tar"},
+		},
+	})
+	if err != nil {
+		log.Printf("SelectModelArchive: %v", err)
+		return ""
+	}
+	return path
+}
+
+// ExportModels 将模型导出到 tar 归档，在后台执行并通过 model_archive_progress 事件报告进度
+func (a *App) ExportModels(names []string, archivePath string) map[string]interface{} {
+	archivePath = strings.TrimSpace(archivePath)
+	if archivePath == "" || len(names) == 0 {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "error",
+			"message": "请选择要导出的模型和导出文件路径",
+		}
+	}
+	if dir := filepath.Dir(archivePath); dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return map[string]interface{}{
+				"success": false,
+				"code":    "error",
+				"message": fmt.Sprintf("导出目录不存在: %s", dir),
+			}
+		}
+	}
+
+	go func() {
+		result, err := a.exportModels(context.Background(), names, archivePath)
+		if err != nil {
+			log.Printf("ExportModels: 导出失败: %v", err)
+			a.archiveProgress("export", "error", 0, 0, err.Error())
+			return
+		}
+		log.Printf("ExportModels: 已导出 %d 个模型到 %s (%s)", len(result.Models), result.Path, formatBytes(result.Bytes))
+	}()
+
+	return map[string]interface{}{
+		"success": true,
+		"status":  "started",
+		"message": fmt.Sprintf("开始导出 %d 个模型", len(names)),
+	}
+}
+
+// ImportModelArchive 从 tar 归档恢复模型，在后台执行并通过 model_archive_progress 事件报告进度
+func (a *App) ImportModelArchive(archivePath string) map[string]interface{} {
+	archivePath = strings.TrimSpace(archivePath)
+	if _, err := os.Stat(archivePath); err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "error",
+			"message": fmt.Sprintf("归档文件不存在: %s", archivePath),
+		}
+	}
+
+	go func() {
+		result, err := a.importModelArchive(context.Background(), archivePath)
+		if err != nil {
+			log.Printf("ImportModelArchive: 导入失败: %v", err)
+			a.archiveProgress("import", "error", 0, 0, err.Error())
+			return
+		}
+		log.Printf("ImportModelArchive: 已恢复 %d 个模型，写入 %d 个数据文件，跳过 %d 个已存在的数据文件",
+			len(result.Models), result.BlobsWritten, result.BlobsSkipped)
+	}()
+
+	return map[string]interface{}{
+		"success": true,
+		"status":  "started",
+		"message": "开始导入模型归档",
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 模型目录文件的格式版本，格式不兼容时递增
+const modelCatalogFormatVersion = 1
+
+// 校验模型目录时最多报告的错误数
+const maxModelCatalogErrors = 10
+
+// 随应用发布的内置模型目录，配置目录中的 catalog.json 可以覆盖或补充其中的内容
+//
+//go:embed catalog/models.json
+var embeddedModelCatalog []byte
+
+// ModelCatalog 内置在线模型列表和模型名称纠正表
+type ModelCatalog struct {
+	FormatVersion   int               `json:"format_version"`
+	Version         int               `json:"version"` // 目录内容的版本，每次发布更新时递增
+	Updated         string            `json:"updated,omitempty"`
+	Replace         bool              `json:"replace,omitempty"` // 仅用于覆盖文件：为 true 时完全替换内置目录，否则与内置目录合并
+	Models          []CatalogEntry    `json:"models"`
+	RemovedModels   []string          `json:"removed_models,omitempty"`   // 仅用于覆盖文件：从内置目录中删除的模型
+	NameCorrections map[string]string `json:"name_corrections,omitempty"` // 拉取时纠正的模型名称，如 llama4 -> llama3.3，值为空表示删除内置的纠正
+}
+
+// CatalogEntry 内置目录中的一个模型
+type CatalogEntry struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	Size         string              `json:"size,omitempty"`
+	Capabilities []string            `json:"capabilities,omitempty"`
+	Details      CatalogEntryDetails `json:"details"`
+}
+
+// CatalogEntryDetails 模型的参数量、量化类型等信息
+type CatalogEntryDetails struct {
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+	Format            string `json:"format,omitempty"`
+	Family            string `json:"family,omitempty"`
+}
+
+// getModelCatalogPath 获取覆盖内置模型目录的文件路径
+func (a *App) getModelCatalogPath() string {
+	return filepath.Join(a.getConfigDir(), "catalog.json")
+}
+
+// parseModelCatalog 解析并校验模型目录，不允许未知字段，避免字段名写错时被静默忽略
+func parseModelCatalog(data []byte) (*ModelCatalog, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var catalog ModelCatalog
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("解析模型目录失败: %v", err)
+	}
+	if err := catalog.validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// validate 校验模型目录的格式版本、模型条目和名称纠正表
+func (c *ModelCatalog) validate() error {
+	if c.FormatVersion != modelCatalogFormatVersion {
+		return fmt.Errorf("不支持的模型目录格式版本: %d（支持 %d）", c.FormatVersion, modelCatalogFormatVersion)
+	}
+	if c.Version < 1 {
+		return fmt.Errorf("模型目录版本必须大于 0")
+	}
+
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	seen := make(map[string]bool)
+	for i, entry := range c.Models {
+		if _, err := validateModelName(entry.Name); err != nil {
+			report("models[%d]: %v", i, err)
+			continue
+		}
+		key := strings.ToLower(entry.Name)
+		if seen[key] {
+			report("models[%d]: 模型 %s 重复", i, entry.Name)
+		}
+		seen[key] = true
+		if strings.TrimSpace(entry.Description) == "" {
+			report("models[%d] %s: 缺少描述", i, entry.Name)
+		}
+		if entry.Size != "" {
+			if _, err := parseByteSize(entry.Size); err != nil {
+				report("models[%d] %s: 无效的大小 %s", i, entry.Name, entry.Size)
+			}
+		}
+		if entry.Details.ParameterSize != "" && parseParameterSize(entry.Details.ParameterSize) == 0 {
+			report("models[%d] %s: 无效的参数量 %s", i, entry.Name, entry.Details.ParameterSize)
+		}
+		for _, capability := range entry.Capabilities {
+			if !catalogCapabilities[capability] {
+				report("models[%d] %s: 未知的能力 %s", i, entry.Name, capability)
+			}
+		}
+	}
+
+	for from, to := range c.NameCorrections {
+		if from == "" || from != strings.ToLower(from) || strings.Contains(from, ":") {
+			report("name_corrections: 名称 %q 必须为小写且不含标签", from)
+		}
+		if to != "" && strings.Contains(to, ":") {
+			report("name_corrections: %s 的纠正结果 %q 不能包含标签", from, to)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	if len(problems) > maxModelCatalogErrors {
+		problems = append(problems[:maxModelCatalogErrors], fmt.Sprintf("还有 %d 个错误", len(problems)-maxModelCatalogErrors))
+	}
+	return fmt.Errorf("模型目录校验失败: %s", strings.Join(problems, "; "))
+}
+
+// mergeModelCatalog 将覆盖文件合并到内置目录
+// 同名模型以覆盖文件为准，新模型追加到末尾，removed_models 中的模型被删除；名称纠正表按名称合并
+func mergeModelCatalog(base, override *ModelCatalog) *ModelCatalog {
+	if override.Replace {
+		merged := *override
+		merged.NameCorrections = make(map[string]string)
+		for from, to := range override.NameCorrections {
+			if to != "" {
+				merged.NameCorrections[from] = to
+			}
+		}
+		return &merged
+	}
+
+	merged := &ModelCatalog{
+		FormatVersion:   base.FormatVersion,
+		Version:         base.Version,
+		Updated:         base.Updated,
+		NameCorrections: make(map[string]string),
+	}
+	if override.Version > merged.Version {
+		merged.Version = override.Version
+		merged.Updated = override.Updated
+	}
+
+	removed := make(map[string]bool)
+	for _, name := range override.RemovedModels {
+		removed[strings.ToLower(name)] = true
+	}
+	overrides := make(map[string]CatalogEntry)
+	for _, entry := range override.Models {
+		overrides[strings.ToLower(entry.Name)] = entry
+	}
+
+	for _, entry := range base.Models {
+		key := strings.ToLower(entry.Name)
+		if removed[key] {
+			continue
+		}
+		if replacement, ok := overrides[key]; ok {
+			entry = replacement
+			delete(overrides, key)
+		}
+		merged.Models = append(merged.Models, entry)
+	}
+	for _, entry := range override.Models {
+		key := strings.ToLower(entry.Name)
+		if _, ok := overrides[key]; ok && !removed[key] {
+			merged.Models = append(merged.Models, entry)
+		}
+	}
+
+	for from, to := range base.NameCorrections {
+		merged.NameCorrections[from] = to
+	}
+	for from, to := range override.NameCorrections {
+		if to == "" {
+			delete(merged.NameCorrections, from)
+		} else {
+			merged.NameCorrections[from] = to
+		}
+	}
+	return merged
+}
+
+// loadModelCatalog 获取当前的模型目录，覆盖文件修改后自动重新加载
+// 覆盖文件无效时记录错误并只使用内置目录
+func (a *App) loadModelCatalog() *ModelCatalog {
+	a.modelCatalogMutex.Lock()
+	defer a.modelCatalogMutex.Unlock()
+
+	var modTime time.Time
+	info, statErr := os.Stat(a.getModelCatalogPath())
+	if statErr == nil {
+		modTime = info.ModTime()
+	}
+	if a.modelCatalog != nil && modTime.Equal(a.modelCatalogModTime) {
+		return a.modelCatalog
+	}
+	a.modelCatalogModTime = modTime
+	a.modelCatalogError = ""
+
+	builtin, err := parseModelCatalog(embeddedModelCatalog)
+	if err != nil {
+		log.Printf("loadModelCatalog: 内置模型目录无效: %v", err)
+		builtin = &ModelCatalog{FormatVersion: modelCatalogFormatVersion, Version: 1}
+	}
+	a.modelCatalog = builtin
+	if statErr != nil {
+		return a.modelCatalog
+	}
+
+	data, err := os.ReadFile(a.getModelCatalogPath())
+	if err == nil {
+		var override *ModelCatalog
+		if override, err = parseModelCatalog(data); err == nil {
+			a.modelCatalog = mergeModelCatalog(builtin, override)
+			log.Printf("loadModelCatalog: 已加载模型目录覆盖文件，版本 %d，%d 个模型", a.modelCatalog.Version, len(a.modelCatalog.Models))
+			return a.modelCatalog
+		}
+	}
+	log.Printf("loadModelCatalog: 忽略模型目录覆盖文件: %v", err)
+	a.modelCatalogError = err.Error()
+	return a.modelCatalog
+}
+
+// catalogEntryMap 转换为在线模型列表使用的 map 格式
+func catalogEntryMap(entry CatalogEntry) map[string]interface{} {
+	model := map[string]interface{}{
+		"name":        entry.Name,
+		"description": entry.Description,
+		"size":        entry.Size,
+		"details": map[string]interface{}{
+			"parameter_size":     entry.Details.ParameterSize,
+			"quantization_level": entry.Details.QuantizationLevel,
+			"format":             entry.Details.Format,
+			"family":             entry.Details.Family,
+		},
+	}
+	if len(entry.Capabilities) > 0 {
+		model["capabilities"] = entry.Capabilities
+	}
+	return model
+}
+
+// GetModelCatalogInfo 获取模型目录的版本、来源和覆盖文件的加载情况
+func (a *App) GetModelCatalogInfo() map[string]interface{} {
+	catalog := a.loadModelCatalog()
+
+	a.modelCatalogMutex.Lock()
+	overrideError := a.modelCatalogError
+	overridePresent := !a.modelCatalogModTime.IsZero()
+	a.modelCatalogMutex.Unlock()
+
+	builtinVersion := 0
+	if builtin, err := parseModelCatalog(embeddedModelCatalog); err == nil {
+		builtinVersion = builtin.Version
+	}
+
+	return map[string]interface{}{
+		"version":          catalog.Version,
+		"updated":          catalog.Updated,
+		"builtin_version":  builtinVersion,
+		"model_count":      len(catalog.Models),
+		"correction_count": len(catalog.NameCorrections),
+		"override_path":    a.getModelCatalogPath(),
+		"override_present": overridePresent,
+		"override_loaded":  overridePresent && overrideError == "",
+		"override_error":   overrideError,
+	}
+}
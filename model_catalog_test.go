@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedModelCatalog(t *testing.T) {
+	catalog, err := parseModelCatalog(embeddedModelCatalog)
+	if err != nil {
+		t.Fatalf("内置模型目录无效: %v", err)
+	}
+	if len(catalog.Models) == 0 {
+		t.Error("内置模型目录没有模型")
+	}
+}
+
+func TestParseModelCatalogErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"未知字段", `{"format_version":1,"version":1,"modles":[]}`, "unknown field"},
+		{"格式版本", `{"format_version":2,"version":1}`, "格式版本"},
+		{"模型重复", `{"format_version":1,"version":1,"models":[{"name":"a:1b","description":"x"},{"name":"A:1b","description":"y"}]}`, "重复"},
+		{"无效的大小", `{"format_version":1,"version":1,"models":[{"name":"a:1b","description":"x","size":"大"}]}`, "无效的大小"},
+		{"纠正表包含标签", `{"format_version":1,"version":1,"name_corrections":{"a":"b:1b"}}`, "不能包含标签"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseModelCatalog([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseModelCatalog error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// catalogModelNames 获取目录中的模型名称和描述，便于比较
+func catalogModelNames(catalog *ModelCatalog) []string {
+	var names []string
+	for _, entry := range catalog.Models {
+		names = append(names, entry.Name+"="+entry.Description)
+	}
+	return names
+}
+
+func TestMergeModelCatalog(t *testing.T) {
+	base := &ModelCatalog{
+		FormatVersion: 1,
+		Version:       2,
+		Models: []CatalogEntry{
+			{Name: "llama3:8b", Description: "内置"},
+			{Name: "mistral:7b", Description: "内置"},
+			{Name: "gemma:2b", Description: "内置"},
+		},
+		NameCorrections: map[string]string{"llama4": "llama3.3", "qwen": "qwen3"},
+	}
+
+	tests := []struct {
+		name            string
+		override        *ModelCatalog
+		wantVersion     int
+		wantModels      []string
+		wantCorrections map[string]string
+	}{
+		{
+			name: "合并",
+			override: &ModelCatalog{
+				Version: 1,
+				Models: []CatalogEntry{
+					{Name: "Mistral:7b", Description: "覆盖"},
+					{Name: "phi3:mini", Description: "新增"},
+				},
+				NameCorrections: map[string]string{"deepseek": "deepseek-r1"},
+			},
+			wantVersion:     2,
+			wantModels:      []string{"llama3:8b=内置", "Mistral:7b=覆盖", "gemma:2b=内置", "phi3:mini=新增"},
+			wantCorrections: map[string]string{"llama4": "llama3.3", "qwen": "qwen3", "deepseek": "deepseek-r1"},
+		},
+		{
+			name: "删除模型",
+			override: &ModelCatalog{
+				Version:       3,
+				Models:        []CatalogEntry{{Name: "phi3:mini", Description: "新增"}, {Name: "gemma:2b", Description: "覆盖"}},
+				RemovedModels: []string{"LLAMA3:8b", "phi3:mini", "gemma:2b"},
+			},
+			wantVersion:     3,
+			wantModels:      []string{"mistral:7b=内置"},
+			wantCorrections: map[string]string{"llama4": "llama3.3", "qwen": "qwen3"},
+		},
+		{
+			name: "值为空时删除内置的纠正",
+			override: &ModelCatalog{
+				Version:         1,
+				NameCorrections: map[string]string{"llama4": "", "missing": ""},
+			},
+			wantVersion:     2,
+			wantModels:      []string{"llama3:8b=内置", "mistral:7b=内置", "gemma:2b=内置"},
+			wantCorrections: map[string]string{"qwen": "qwen3"},
+		},
+		{
+			name: "完全替换",
+			override: &ModelCatalog{
+				FormatVersion:   1,
+				Version:         1,
+				Replace:         true,
+				Models:          []CatalogEntry{{Name: "phi3:mini", Description: "替换"}},
+				NameCorrections: map[string]string{"llama4": "", "deepseek": "deepseek-r1"},
+			},
+			wantVersion:     1,
+			wantModels:      []string{"phi3:mini=替换"},
+			wantCorrections: map[string]string{"deepseek": "deepseek-r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeModelCatalog(base, tt.override)
+			if merged.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", merged.Version, tt.wantVersion)
+			}
+			if got := catalogModelNames(merged); !reflect.DeepEqual(got, tt.wantModels) {
+				t.Errorf("models = %q, want %q", got, tt.wantModels)
+			}
+			if !reflect.DeepEqual(merged.NameCorrections, tt.wantCorrections) {
+				t.Errorf("name corrections = %v, want %v", merged.NameCorrections, tt.wantCorrections)
+			}
+		})
+	}
+
+	if len(base.Models) != 3 || len(base.NameCorrections) != 2 {
+		t.Error("mergeModelCatalog modified the base catalog")
+	}
+}
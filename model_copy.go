@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// errModelExists 目标模型名称已被占用
+var errModelExists = errors.New("目标模型已存在")
+
+// 模型名称各部分允许的字符，与 Ollama 的命名规则一致
+var (
+	modelNamePartRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,79}$`)
+	modelTagRegex      = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,79}$`)
+	modelHostRegex     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*(:[0-9]+)?$`)
+)
+
+// validateModelName 校验并规范化模型名称，未指定标签时使用 latest
+func validateModelName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("模型名称不能为空")
+	}
+	if strings.ContainsAny(name, " \t\\") {
+		return "", fmt.Errorf("模型名称不能包含空格或反斜杠: %s", name)
+	}
+
+	ref := parseModelReference(name)
+	if !modelHostRegex.MatchString(ref.Host) {
+		return "", fmt.Errorf("无效的仓库地址: %s", ref.Host)
+	}
+	for _, part := range strings.Split(ref.Namespace, "/") {
+		if !modelNamePartRegex.MatchString(part) {
+			return "", fmt.Errorf("无效的命名空间: %s", ref.Namespace)
+		}
+	}
+	if !modelNamePartRegex.MatchString(ref.Model) {
+		return "", fmt.Errorf("无效的模型名称: %s", ref.Model)
+	}
+	if !modelTagRegex.MatchString(ref.Tag) {
+		return "", fmt.Errorf("无效的标签: %s", ref.Tag)
+	}
+	return ref.String(), nil
+}
+
+// localModelExists 检查本地是否存在指定模型，服务不可用时检查清单文件
+func (a *App) localModelExists(name string) bool {
+	models, err := a.fetchLocalModels()
+	if err != nil {
+		_, err := a.readModelManifest(name)
+		return err == nil
+	}
+	for _, m := range models {
+		if sameModelName(m.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyModel 通过 /api/copy 复制模型，目标名称已存在时返回 errModelExists
+func (a *App) copyModel(source, destination string) (string, string, error) {
+	source, err := validateModelName(source)
+	if err != nil {
+		return "", "", err
+	}
+	destination, err = validateModelName(destination)
+	if err != nil {
+		return "", "", err
+	}
+	if sameModelName(source, destination) {
+		return "", "", fmt.Errorf("源模型和目标模型相同: %s", source)
+	}
+	if !a.localModelExists(source) {
+		return "", "", errModelNotFound
+	}
+	if a.localModelExists(destination) {
+		return "", "", errModelExists
+	}
+
+	reqBody, err := json.Marshal(map[string]string{
+		"source":      source,
+		"destination": destination,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Post(OllamaAPIBaseURL+"/api/copy", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", "", fmt.Errorf("连接Ollama服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return source, destination, nil
+	case http.StatusNotFound:
+		return "", "", errModelNotFound
+	default:
+		message := strings.TrimSpace(string(body))
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return "", "", fmt.Errorf("复制模型失败 (%d): %s", resp.StatusCode, message)
+	}
+}
+
+// renameModel 复制模型后删除原模型，删除失败时撤销复制
+func (a *App) renameModel(source, destination string) (string, string, error) {
+	// 删除原模型前会做同样的检查，提前检查避免复制后再撤销
+	if a.generationActive(source) {
+		return "", "", errModelInUse
+	}
+	if loaded, err := a.fetchLoadedModels(); err != nil {
+		log.Printf("renameModel: %v", err)
+	} else if isModelLoaded(loaded, source) {
+		return "", "", errModelLoaded
+	}
+
+	source, destination, err := a.copyModel(source, destination)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := a.deleteModel(source, false); err != nil {
+		// 新旧名称共享同一份数据，撤销复制不会删除模型文件
+		if _, rollbackErr := a.deleteModel(destination, true); rollbackErr != nil {
+			log.Printf("renameModel: 撤销复制失败: %s, %v", destination, rollbackErr)
+		}
+		return "", "", err
+	}
+	return source, destination, nil
+}
+
+// modelOperationErrorCode 将模型操作错误转换为前端使用的错误代码，错误可以是包装后的
+func modelOperationErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errModelNotFound):
+		return "not_found"
+	case errors.Is(err, errModelExists):
+		return "exists"
+	case errors.Is(err, errModelInUse):
+		return "in_use"
+	case errors.Is(err, errModelLoaded):
+		return "loaded"
+	}
+	return "error"
+}
+
+// CopyModel 复制模型到新名称
+func (a *App) CopyModel(source, destination string) map[string]interface{} {
+	source, destination, err := a.copyModel(source, destination)
+	if err != nil {
+		log.Printf("CopyModel: 复制模型失败: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"message": fmt.Sprintf("复制模型失败: %v", err),
+		}
+	}
+
+	log.Printf("CopyModel: %s -> %s", source, destination)
+	return map[string]interface{}{
+		"success":     true,
+		"source":      source,
+		"destination": destination,
+		"message":     fmt.Sprintf("模型已复制: %s -> %s", source, destination),
+	}
+}
+
+// RenameModel 重命名模型
+func (a *App) RenameModel(source, destination string) map[string]interface{} {
+	source, destination, err := a.renameModel(source, destination)
+	if err != nil {
+		log.Printf("RenameModel: 重命名模型失败: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"message": fmt.Sprintf("重命名模型失败: %v", err),
+		}
+	}
+
+	log.Printf("RenameModel: %s -> %s", source, destination)
+	return map[string]interface{}{
+		"success":     true,
+		"source":      source,
+		"destination": destination,
+		"message":     fmt.Sprintf("模型已重命名: %s -> %s", source, destination),
+	}
+}
+
+// retagTarget 将模型名称的标签替换为新标签
+func retagTarget(name, tag string) string {
+	ref := parseModelReference(name)
+	ref.Tag = tag
+	return ref.String()
+}
+
+// RetagModels 批量为模型设置新标签，move 为 true 时删除原标签
+// 执行前先校验所有目标名称，任何一个冲突时不做任何修改
+func (a *App) RetagModels(names []string, tag string, move bool) map[string]interface{} {
+	tag = strings.TrimSpace(tag)
+	if !modelTagRegex.MatchString(tag) {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "error",
+			"message": fmt.Sprintf("无效的标签: %s", tag),
+		}
+	}
+
+	targets := make(map[string]string, len(names))
+	seen := make(map[string]string)
+	for _, name := range names {
+		source, err := validateModelName(name)
+		if err != nil {
+			return map[string]interface{}{
+				"success": false,
+				"code":    "error",
+				"message": err.Error(),
+			}
+		}
+		target := retagTarget(source, tag)
+		if other, ok := seen[strings.ToLower(target)]; ok {
+			return map[string]interface{}{
+				"success": false,
+				"code":    "exists",
+				"message": fmt.Sprintf("%s 和 %s 的目标名称相同: %s", other, source, target),
+			}
+		}
+		if !sameModelName(source, target) && a.localModelExists(target) {
+			return map[string]interface{}{
+				"success": false,
+				"code":    "exists",
+				"message": fmt.Sprintf("目标模型已存在: %s", target),
+			}
+		}
+		seen[strings.ToLower(target)] = source
+		targets[source] = target
+	}
+
+	var results []map[string]interface{}
+	succeeded := 0
+	for _, name := range names {
+		source, _ := validateModelName(name)
+		target := targets[source]
+		result := map[string]interface{}{
+			"source":      source,
+			"destination": target,
+			"success":     true,
+		}
+
+		if !sameModelName(source, target) {
+			var err error
+			if move {
+				_, _, err = a.renameModel(source, target)
+			} else {
+				_, _, err = a.copyModel(source, target)
+			}
+			if err != nil {
+				log.Printf("RetagModels: %s -> %s 失败: %v", source, target, err)
+				result["success"] = false
+				result["code"] = modelOperationErrorCode(err)
+				result["message"] = err.Error()
+			}
+		}
+		if result["success"] == true {
+			succeeded++
+		}
+		results = append(results, result)
+	}
+
+	return map[string]interface{}{
+		"success": succeeded == len(names),
+		"results": results,
+		"message": fmt.Sprintf("已设置 %d/%d 个模型的标签为 %s", succeeded, len(names), tag),
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestModelOperationErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errModelNotFound, "not_found"},
+		{fmt.Errorf("%w: qwen3:latest", errModelNotFound), "not_found"},
+		{fmt.Errorf("%w: qwen3:latest", errModelExists), "exists"},
+		{fmt.Errorf("导入失败: %w", fmt.Errorf("%w: qwen3", errModelInUse)), "in_use"},
+		{errModelLoaded, "loaded"},
+		{errors.New("磁盘已满"), "error"},
+	}
+	for _, tt := range tests {
+		if got := modelOperationErrorCode(tt.err); got != tt.want {
+			t.Errorf("modelOperationErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 支持结构化 /api/create 请求（from、files、parameters 等字段）的最低 Ollama 版本
+// 更早的版本只接受 modelfile 文本
+var structuredCreateMinVersion = [3]int{0, 5, 5}
+
+// ollamaCreateRequest /api/create 请求，同时包含新旧两种格式的字段
+type ollamaCreateRequest struct {
+	Model      string                 `json:"model"`
+	Name       string                 `json:"name,omitempty"`
+	Modelfile  string                 `json:"modelfile,omitempty"`
+	From       string                 `json:"from,omitempty"`
+	Files      map[string]string      `json:"files,omitempty"`
+	Adapters   map[string]string      `json:"adapters,omitempty"`
+	Template   string                 `json:"template,omitempty"`
+	System     string                 `json:"system,omitempty"`
+	License    []string               `json:"license,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Messages   []ChatMessage          `json:"messages,omitempty"`
+	Stream     bool                   `json:"stream"`
+}
+
+// progressReader 读取数据时回调已读取的字节数
+type progressReader struct {
+	reader     io.Reader
+	read       int64
+	onProgress func(read int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if n > 0 && r.onProgress != nil {
+		r.onProgress(r.read)
+	}
+	return n, err
+}
+
+// parseVersionPrefix 解析版本号开头的 x.y.z，例如 "0.5.4-ipexllm-20250318"
+func parseVersionPrefix(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	fields := strings.SplitN(version, ".", 3)
+	if len(fields) < 2 {
+		return parts, false
+	}
+	for i, field := range fields {
+		end := 0
+		for end < len(field) && field[end] >= '0' && field[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(field[:end])
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// supportsStructuredCreate 根据 Ollama 版本判断是否支持结构化 /api/create，版本未知时按新版本处理
+func (a *App) supportsStructuredCreate() bool {
+	client := &http.Client{Timeout: 5 * time.Second}
+	version, check := a.checkUpstreamVersion(client)
+	if check.Status != "ok" {
+		return true
+	}
+	parts, ok := parseVersionPrefix(version)
+	if !ok {
+		return true
+	}
+	for i := range parts {
+		if parts[i] != structuredCreateMinVersion[i] {
+			return parts[i] > structuredCreateMinVersion[i]
+		}
+	}
+	return true
+}
+
+// fileSHA256 计算文件的 SHA256，返回 sha256:<hex> 格式的 digest
+func fileSHA256(ctx context.Context, path string, onProgress func(read int64)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	reader := &progressReader{reader: f, onProgress: onProgress}
+	buf := make([]byte, 1024*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := reader.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// blobExists 检查 Ollama 服务中是否已存在指定 blob
+func (a *App) blobExists(ctx context.Context, digest string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, OllamaAPIBaseURL+"/api/blobs/"+digest, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("连接Ollama服务失败: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// uploadBlob 通过 /api/blobs 上传文件，已存在时跳过
+func (a *App) uploadBlob(ctx context.Context, path, digest string, onProgress func(sent int64)) error {
+	exists, err := a.blobExists(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	body := &progressReader{reader: f, onProgress: onProgress}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, OllamaAPIBaseURL+"/api/blobs/"+digest, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("上传文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("上传文件失败 (%d): %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// createProgress 发送模型创建进度事件
+func (a *App) createProgress(model, status string, completed, total int64, message string) {
+	progress := 0.0
+	if total > 0 {
+		progress = float64(completed) / float64(total) * 100
+	}
+	if status == "completed" {
+		progress = 100
+	}
+
+	event := map[string]interface{}{
+		"model":     model,
+		"status":    status,
+		"progress":  progress,
+		"completed": completed,
+		"total":     total,
+		"message":   message,
+		"time":      time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "model_create_progress", event)
+	}
+	// 命令行模式下没有前端，由命令行输出进度
+	if a.cliProgress != nil {
+		a.cliProgress(event)
+	}
+}
+
+// uploadModelfileFiles 计算并上传 Modelfile 引用的本地文件（目录中的所有文件），返回文件名到 digest 的映射
+func (a *App) uploadModelfileFiles(ctx context.Context, model, path string) (map[string]string, error) {
+	var paths []string
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+	} else {
+		paths = append(paths, path)
+	}
+
+	files := make(map[string]string, len(paths))
+	for _, file := range paths {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		total := info.Size()
+		name := filepath.Base(file)
+
+		var lastEmit time.Time
+		throttled := func(status, message string) func(int64) {
+			return func(done int64) {
+				if time.Since(lastEmit) >= pullProgressEmitInterval || done == total {
+					lastEmit = time.Now()
+					a.createProgress(model, status, done, total, message)
+				}
+			}
+		}
+
+		digest, err := fileSHA256(ctx, file, throttled("hashing", "计算校验和: "+name))
+		if err != nil {
+			return nil, fmt.Errorf("计算校验和失败: %v", err)
+		}
+		if err := a.uploadBlob(ctx, file, digest, throttled("uploading", "上传文件: "+name)); err != nil {
+			return nil, err
+		}
+		files[name] = digest
+	}
+	return files, nil
+}
+
+// formatModelfileCommand 将指令格式化为 Modelfile 文本
+func formatModelfileCommand(cmd ModelfileCommand) (string, error) {
+	switch cmd.Name {
+	case "parameter", "message":
+		name, value := splitModelfileArg(cmd.Value)
+		quoted, err := quoteModelfileValue(value)
+		if err != nil {
+			return "", ModelfileError{Line: cmd.Line, Message: err.Error()}
+		}
+		return fmt.Sprintf("%s %s %s", strings.ToUpper(cmd.Name), name, quoted), nil
+	case "from", "adapter", "requires":
+		return fmt.Sprintf("%s %s", strings.ToUpper(cmd.Name), cmd.Value), nil
+	default:
+		quoted, err := quoteModelfileValue(cmd.Value)
+		if err != nil {
+			return "", ModelfileError{Line: cmd.Line, Message: err.Error()}
+		}
+		return fmt.Sprintf("%s %s", strings.ToUpper(cmd.Name), quoted), nil
+	}
+}
+
+// parameterValue 将 PARAMETER 的值转换为对应的类型
+func parameterValue(name, value string) interface{} {
+	switch modelfileParameters[name] {
+	case modelfileParamInt:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case modelfileParamFloat:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case modelfileParamBool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// buildCreateRequest 根据 Modelfile 构建 /api/create 请求，本地文件先上传为 blob
+func (a *App) buildCreateRequest(ctx context.Context, model string, modelfile *Modelfile, baseDir string, structured bool) (*ollamaCreateRequest, error) {
+	req := &ollamaCreateRequest{Model: model, Stream: true}
+	var lines []string
+
+	for _, cmd := range modelfile.Commands {
+		switch cmd.Name {
+		case "from", "adapter":
+			value := cmd.Value
+			var files map[string]string
+			if cmd.Name == "adapter" || isLocalModelPath(value) {
+				var err error
+				files, err = a.uploadModelfileFiles(ctx, model, resolveModelfilePath(value, baseDir))
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if !structured {
+				// 旧版本通过 @digest 引用已上传的 blob
+				if len(files) == 1 {
+					for _, digest := range files {
+						cmd.Value = "@" + digest
+					}
+				} else if len(files) > 1 {
+					return nil, fmt.Errorf("当前 Ollama 版本不支持从目录创建模型，请升级 Ollama")
+				}
+				break
+			}
+
+			if cmd.Name == "adapter" {
+				if req.Adapters == nil {
+					req.Adapters = make(map[string]string)
+				}
+				for name, digest := range files {
+					req.Adapters[name] = digest
+				}
+			} else if files != nil {
+				req.Files = files
+			} else {
+				req.From = value
+			}
+		case "template":
+			req.Template = cmd.Value
+		case "system":
+			req.System = cmd.Value
+		case "license":
+			req.License = append(req.License, cmd.Value)
+		case "parameter":
+			name, value := splitModelfileArg(cmd.Value)
+			name = strings.ToLower(name)
+			if req.Parameters == nil {
+				req.Parameters = make(map[string]interface{})
+			}
+			if name == "stop" {
+				stops, _ := req.Parameters["stop"].([]string)
+				req.Parameters["stop"] = append(stops, value)
+			} else {
+				req.Parameters[name] = parameterValue(name, value)
+			}
+		case "message":
+			role, content := splitModelfileArg(cmd.Value)
+			req.Messages = append(req.Messages, ChatMessage{Role: strings.ToLower(role), Content: content})
+		}
+		line, err := formatModelfileCommand(cmd)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	if !structured {
+		// 旧版本只接受 name 和 modelfile
+		return &ollamaCreateRequest{
+			Model:     model,
+			Name:      model,
+			Modelfile: strings.Join(lines, "\n") + "\n",
+			Stream:    true,
+		}, nil
+	}
+	return req, nil
+}
+
+// createModel 解析 Modelfile 并通过 /api/create 创建模型，进度通过 model_create_progress 事件发送
+func (a *App) createModel(ctx context.Context, model string, modelfile *Modelfile, baseDir string) error {
+	a.createProgress(model, "started", 0, 0, "开始创建模型")
+
+	req, err := a.buildCreateRequest(ctx, model, modelfile, baseDir, a.supportsStructuredCreate())
+	if err != nil {
+		return err
+	}
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, OllamaAPIBaseURL+"/api/create", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("连接Ollama服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		message := strings.TrimSpace(string(body))
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return fmt.Errorf("创建模型失败 (%d): %s", resp.StatusCode, message)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var status pullStatusLine
+		if err := json.Unmarshal(scanner.Bytes(), &status); err != nil {
+			continue
+		}
+		if status.Error != "" {
+			return fmt.Errorf("%s", status.Error)
+		}
+		if status.Status == "success" {
+			a.createProgress(model, "completed", 0, 0, "模型创建完成")
+			return nil
+		}
+		if status.Status != "" {
+			a.createProgress(model, "creating", status.Completed, status.Total, status.Status)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取创建进度失败: %v", err)
+	}
+	return fmt.Errorf("创建未完成: 服务端提前结束了响应")
+}
+
+// modelfileErrorsResult 构建 Modelfile 校验失败的返回结果
+func modelfileErrorsResult(errs []ModelfileError) map[string]interface{} {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return map[string]interface{}{
+		"success": false,
+		"code":    "invalid_modelfile",
+		"errors":  errs,
+		"message": "Modelfile 无效: " + strings.Join(messages, "; "),
+	}
+}
+
+// ValidateModelfile 校验 Modelfile，返回带行号的错误列表
+// baseDir 为 Modelfile 所在的目录，FROM 和 ADAPTER 中的相对路径相对于该目录，为空时相对于当前工作目录
+func (a *App) ValidateModelfile(content, baseDir string) map[string]interface{} {
+	modelfile, errs := validateModelfile(content, baseDir)
+	if len(errs) > 0 {
+		return modelfileErrorsResult(errs)
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"commands": modelfile.Commands,
+		"message":  "Modelfile 有效",
+	}
+}
+
+// CreateModel 根据 Modelfile 创建模型，在后台执行并通过 model_create_progress 事件报告进度
+// baseDir 为 Modelfile 所在的目录，用于解析 FROM 和 ADAPTER 中的相对路径
+func (a *App) CreateModel(name, content, baseDir string) map[string]interface{} {
+	model, err := validateModelName(name)
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "error",
+			"message": err.Error(),
+		}
+	}
+	if a.localModelExists(model) {
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(errModelExists),
+			"message": fmt.Sprintf("模型已存在: %s", model),
+		}
+	}
+
+	modelfile, errs := validateModelfile(content, baseDir)
+	if len(errs) > 0 {
+		return modelfileErrorsResult(errs)
+	}
+
+	go func() {
+		if err := a.createModel(context.Background(), model, modelfile, baseDir); err != nil {
+			log.Printf("CreateModel: 创建模型失败: %s, %v", model, err)
+			a.createProgress(model, "error", 0, 0, err.Error())
+			return
+		}
+		log.Printf("CreateModel: 模型创建完成: %s", model)
+	}()
+
+	return map[string]interface{}{
+		"success": true,
+		"model":   model,
+		"status":  "started",
+		"message": fmt.Sprintf("开始创建模型: %s", model),
+	}
+}
+
+// GenerateModelfile 根据现有模型的信息生成 Modelfile，作为创建派生模型的起点
+func (a *App) GenerateModelfile(name string) map[string]interface{} {
+	show, err := a.fetchModelShow(name)
+	if err != nil {
+		log.Printf("GenerateModelfile: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"message": fmt.Sprintf("获取模型信息失败: %v", err),
+		}
+	}
+
+	modelfile, err := generateModelfile(name, show)
+	if err != nil {
+		log.Printf("GenerateModelfile: %s, %v", name, err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    "invalid_modelfile",
+			"message": fmt.Sprintf("生成 Modelfile 失败: %v", err),
+		}
+	}
+
+	return map[string]interface{}{
+		"success":   true,
+		"model":     name,
+		"modelfile": modelfile,
+	}
+}
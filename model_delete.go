@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var (
+	// errModelInUse 模型正在被本应用发起的请求用于生成内容
+	errModelInUse = errors.New("模型正在生成内容，请等待完成后再操作")
+	// errModelLoaded 模型已加载到内存中（/api/ps）
+	errModelLoaded = errors.New("模型已加载到内存中，请先卸载或强制删除")
+)
+
+// 卸载模型后等待其从 /api/ps 中消失的最长时间
+const modelUnloadTimeout = 10 * time.Second
+
+// trackGeneration 记录正在进行的生成请求，返回请求结束时调用的函数
+func (a *App) trackGeneration(model string) func() {
+	key := parseModelReference(model).String()
+
+	a.activeGenerationsMutex.Lock()
+	a.activeGenerations[key]++
+	a.activeGenerationsMutex.Unlock()
+
+	return func() {
+		a.activeGenerationsMutex.Lock()
+		defer a.activeGenerationsMutex.Unlock()
+		if a.activeGenerations[key] <= 1 {
+			delete(a.activeGenerations, key)
+		} else {
+			a.activeGenerations[key]--
+		}
+	}
+}
+
+// generationActive 模型是否有正在进行的生成请求
+func (a *App) generationActive(model string) bool {
+	a.activeGenerationsMutex.Lock()
+	defer a.activeGenerationsMutex.Unlock()
+	return a.activeGenerations[parseModelReference(model).String()] > 0
+}
+
+// fetchLoadedModels 获取已加载到内存中的模型
+func (a *App) fetchLoadedModels() ([]LoadedModelStatus, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	models, check := a.checkLoadedModels(client)
+	if check.Status != "ok" {
+		return nil, fmt.Errorf("获取已加载模型失败: %s", check.Error)
+	}
+	return models, nil
+}
+
+// isModelLoaded 模型是否在已加载列表中
+func isModelLoaded(loaded []LoadedModelStatus, name string) bool {
+	for _, m := range loaded {
+		if sameModelName(m.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// unloadModel 通过 keep_alive=0 让 Ollama 立即卸载模型，并等待卸载完成
+func (a *App) unloadModel(name string) error {
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"model":      name,
+		"keep_alive": 0,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(OllamaAPIBaseURL+"/api/generate", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("卸载模型失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("卸载模型失败 (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	deadline := time.Now().Add(modelUnloadTimeout)
+	for time.Now().Before(deadline) {
+		loaded, err := a.fetchLoadedModels()
+		if err == nil && !isModelLoaded(loaded, name) {
+			return nil
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return fmt.Errorf("等待模型卸载超时: %s", name)
+}
+
+// deleteModel 通过 /api/delete 同步删除模型，返回实际释放的磁盘空间
+// force 为 true 时先卸载已加载到内存中的模型
+func (a *App) deleteModel(name string, force bool) (int64, error) {
+	if a.generationActive(name) {
+		return 0, errModelInUse
+	}
+
+	loaded, err := a.fetchLoadedModels()
+	if err != nil {
+		log.Printf("deleteModel: %v", err)
+	} else if isModelLoaded(loaded, name) {
+		if !force {
+			return 0, errModelLoaded
+		}
+		log.Printf("deleteModel: 卸载模型: %s", name)
+		if err := a.unloadModel(name); err != nil {
+			return 0, err
+		}
+	}
+
+	// 删除前记录只被该模型引用的 blob，删除后检查哪些已被清理
+	blobs, err := a.exclusiveBlobs(name)
+	if err != nil && !errors.Is(err, errModelNotFound) {
+		log.Printf("deleteModel: 读取模型清单失败: %v", err)
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"model": name,
+		"name":  name, // 兼容旧版本 Ollama
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodDelete, OllamaAPIBaseURL+"/api/delete", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("连接Ollama服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, errModelNotFound
+	default:
+		message := strings.TrimSpace(string(body))
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return 0, fmt.Errorf("删除模型失败 (%d): %s", resp.StatusCode, message)
+	}
+
+	var reclaimed int64
+	for digest, size := range blobs {
+		if _, err := os.Stat(a.blobPath(digest)); os.IsNotExist(err) {
+			reclaimed += size
+		}
+	}
+	return reclaimed, nil
+}
+
+// deleteModelResult 删除模型并构建返回给前端的结果
+func (a *App) deleteModelResult(name string, force bool) map[string]interface{} {
+	name = strings.TrimSpace(name)
+	log.Printf("DeleteModel: 删除模型: %s, 强制: %v", name, force)
+
+	reclaimed, err := a.deleteModel(name, force)
+	if err != nil {
+		log.Printf("DeleteModel: 删除模型失败: %s, %v", name, err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"model":   name,
+			"message": fmt.Sprintf("删除模型失败: %v", err),
+		}
+	}
+
+	log.Printf("DeleteModel: 模型已删除: %s, 释放空间: %s", name, formatBytes(reclaimed))
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "model_deleted", map[string]interface{}{
+			"model":           name,
+			"reclaimed_bytes": reclaimed,
+			"time":            time.Now().Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return map[string]interface{}{
+		"success":         true,
+		"model":           name,
+		"reclaimed_bytes": reclaimed,
+		"reclaimed_text":  formatBytes(reclaimed),
+		"message":         fmt.Sprintf("模型已删除: %s，释放空间 %s", name, formatBytes(reclaimed)),
+	}
+}
+
+// ForceDeleteModel 删除模型，模型已加载到内存中时先卸载
+func (a *App) ForceDeleteModel(name string) map[string]interface{} {
+	return a.deleteModelResult(name, true)
+}
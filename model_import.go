@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 从文件名生成模型名称时替换的字符
+var importNameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// defaultImportName 根据 GGUF 文件名生成模型名称，例如 Qwen2.5-7B-Q4_K_M.gguf -> qwen2.5-7b-q4_k_m:latest
+func defaultImportName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = importNameInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-._")
+	if name == "" {
+		name = "imported"
+	}
+	return name + ":" + defaultModelTag
+}
+
+// prepareGGUFImport 读取 GGUF 文件头并校验目标模型名称，name 为空时根据文件名生成
+func (a *App) prepareGGUFImport(path, name string) (*GGUFInfo, string, error) {
+	absPath, err := filepath.Abs(strings.TrimSpace(path))
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := readGGUFInfo(absPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = defaultImportName(absPath)
+	}
+	model, err := validateModelName(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if a.localModelExists(model) {
+		return nil, "", errModelExists
+	}
+	return info, model, nil
+}
+
+// importGGUF 计算校验和、上传 GGUF 文件并创建模型，进度通过 model_create_progress 事件发送
+func (a *App) importGGUF(ctx context.Context, info *GGUFInfo, model string) error {
+	modelfile := &Modelfile{
+		Commands: []ModelfileCommand{{Line: 1, Name: "from", Value: info.Path}},
+	}
+	return a.createModel(ctx, model, modelfile, "")
+}
+
+// SelectGGUFFile 打开文件选择对话框选择要导入的 GGUF 文件
+func (a *App) SelectGGUFFile() string {
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "选择 GGUF 模型文件",
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "GGUF 模型文件 (*.gguf)", Pattern: "*.gguf"},
+		},
+	})
+	if err != nil {
+		log.Printf("SelectGGUFFile: %v", err)
+		return ""
+	}
+	return path
+}
+
+// PreviewGGUF 读取 GGUF 文件头，返回架构、参数量、量化类型、上下文长度和对话模板
+func (a *App) PreviewGGUF(path string) map[string]interface{} {
+	absPath, err := filepath.Abs(strings.TrimSpace(path))
+	if err == nil {
+		var info *GGUFInfo
+		info, err = readGGUFInfo(absPath)
+		if err == nil {
+			return map[string]interface{}{
+				"success":        true,
+				"info":           info,
+				"fit":            a.estimateModelFit(ggufModelShape(info), a.getHardwareProfile()),
+				"suggested_name": defaultImportName(absPath),
+			}
+		}
+	}
+
+	log.Printf("PreviewGGUF: %v", err)
+	return map[string]interface{}{
+		"success": false,
+		"message": fmt.Sprintf("读取 GGUF 文件失败: %v", err),
+	}
+}
+
+// ImportGGUF 将本地 GGUF 文件导入为 Ollama 模型，在后台执行并通过 model_create_progress 事件报告进度
+func (a *App) ImportGGUF(path, name string) map[string]interface{} {
+	info, model, err := a.prepareGGUFImport(path, name)
+	if err != nil {
+		log.Printf("ImportGGUF: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"message": fmt.Sprintf("导入模型失败: %v", err),
+		}
+	}
+
+	go func() {
+		if err := a.importGGUF(context.Background(), info, model); err != nil {
+			log.Printf("ImportGGUF: 导入模型失败: %s, %v", model, err)
+			a.createProgress(model, "error", 0, 0, err.Error())
+			return
+		}
+		log.Printf("ImportGGUF: 模型导入完成: %s <- %s", model, info.Path)
+	}()
+
+	return map[string]interface{}{
+		"success": true,
+		"model":   model,
+		"info":    info,
+		"status":  "started",
+		"message": fmt.Sprintf("开始导入模型: %s", model),
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 默认的模型仓库和命名空间
+const (
+	defaultModelRegistry  = "registry.ollama.ai"
+	defaultModelNamespace = "library"
+	defaultModelTag       = "latest"
+)
+
+// manifestLayer 清单中的层或配置
+type manifestLayer struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// modelManifest Ollama 模型清单（manifests 目录下的文件）
+type modelManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	Config        manifestLayer   `json:"config"`
+	Layers        []manifestLayer `json:"layers"`
+}
+
+// digests 清单引用的所有 blob，包括配置
+func (m *modelManifest) digests() []string {
+	digests := make([]string, 0, len(m.Layers)+1)
+	if m.Config.Digest != "" {
+		digests = append(digests, m.Config.Digest)
+	}
+	for _, layer := range m.Layers {
+		if layer.Digest != "" {
+			digests = append(digests, layer.Digest)
+		}
+	}
+	return digests
+}
+
+// totalSize 清单中所有层和配置的大小
+func (m *modelManifest) totalSize() int64 {
+	size := m.Config.Size
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// modelReference 模型名称的各部分，例如 registry.ollama.ai/library/llama3:8b
+type modelReference struct {
+	Host      string
+	Namespace string
+	Model     string
+	Tag       string
+}
+
+// parseModelReference 解析模型名称，省略的仓库、命名空间和标签使用默认值
+func parseModelReference(name string) modelReference {
+	ref := modelReference{
+		Host:      defaultModelRegistry,
+		Namespace: defaultModelNamespace,
+		Tag:       defaultModelTag,
+	}
+
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if tag := name[i+1:]; tag != "" {
+			ref.Tag = tag
+		}
+		name = name[:i]
+	}
+
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 1:
+		ref.Model = parts[0]
+	case 2:
+		ref.Namespace = parts[0]
+		ref.Model = parts[1]
+	default:
+		ref.Host = parts[0]
+		ref.Namespace = strings.Join(parts[1:len(parts)-1], "/")
+		ref.Model = parts[len(parts)-1]
+	}
+	return ref
+}
+
+// String 返回与 ollama list 一致的简短名称
+func (r modelReference) String() string {
+	switch {
+	case r.Host == defaultModelRegistry && r.Namespace == defaultModelNamespace:
+		return r.Model + ":" + r.Tag
+	case r.Host == defaultModelRegistry:
+		return r.Namespace + "/" + r.Model + ":" + r.Tag
+	default:
+		return r.Host + "/" + r.Namespace + "/" + r.Model + ":" + r.Tag
+	}
+}
+
+// sameModelName 判断两个模型名称是否指向同一个模型
+func sameModelName(a, b string) bool {
+	return strings.EqualFold(parseModelReference(a).String(), parseModelReference(b).String())
+}
+
+// getManifestsDir 获取模型清单目录
+func (a *App) getManifestsDir() string {
+	return filepath.Join(a.getOllamaModelsDir(), "manifests")
+}
+
+// getBlobsDir 获取模型数据目录
+func (a *App) getBlobsDir() string {
+	return filepath.Join(a.getOllamaModelsDir(), "blobs")
+}
+
+// manifestPath 获取模型清单文件路径
+func (a *App) manifestPath(name string) string {
+	ref := parseModelReference(name)
+	return filepath.Join(a.getManifestsDir(), ref.Host, filepath.FromSlash(ref.Namespace), ref.Model, ref.Tag)
+}
+
+// blobPath 获取 blob 文件路径，digest 格式为 sha256:<hex>
+func (a *App) blobPath(digest string) string {
+	return filepath.Join(a.getBlobsDir(), strings.Replace(digest, ":", "-", 1))
+}
+
+// readManifest 读取并解析清单文件
+func readManifest(path string) (*modelManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest modelManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// readModelManifest 读取本地模型的清单
+func (a *App) readModelManifest(name string) (*modelManifest, error) {
+	manifest, err := readManifest(a.manifestPath(name))
+	if os.IsNotExist(err) {
+		return nil, errModelNotFound
+	}
+	return manifest, err
+}
+
+// walkManifests 遍历所有本地模型清单，无法解析的清单以 nil 传给回调
+func (a *App) walkManifests(fn func(name, path string, manifest *modelManifest)) error {
+	root := a.getManifestsDir()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) < 4 {
+			return nil
+		}
+		ref := modelReference{
+			Host:      parts[0],
+			Namespace: strings.Join(parts[1:len(parts)-2], "/"),
+			Model:     parts[len(parts)-2],
+			Tag:       parts[len(parts)-1],
+		}
+
+		manifest, _ := readManifest(path)
+		fn(ref.String(), path, manifest)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// exclusiveBlobs 获取只被指定模型引用的 blob 及其在磁盘上的大小，删除该模型后这些 blob 会被清理
+func (a *App) exclusiveBlobs(name string) (map[string]int64, error) {
+	manifest, err := a.readModelManifest(name)
+	if err != nil {
+		return nil, err
+	}
+
+	target := a.manifestPath(name)
+	shared := make(map[string]bool)
+	err = a.walkManifests(func(_, path string, other *modelManifest) {
+		if other == nil || filepath.Clean(path) == filepath.Clean(target) {
+			return
+		}
+		for _, digest := range other.digests() {
+			shared[digest] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make(map[string]int64)
+	for _, digest := range manifest.digests() {
+		if shared[digest] {
+			continue
+		}
+		if info, err := os.Stat(a.blobPath(digest)); err == nil {
+			blobs[digest] = info.Size()
+		}
+	}
+	return blobs, nil
+}
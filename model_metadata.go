@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 标签只允许小写字母、数字和 . _ -，例如 approved-for-prod
+var modelLabelRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,31}$`)
+
+// 每个模型最多的标签数和备注长度
+const (
+	maxModelLabels    = 20
+	maxModelNoteRunes = 4000
+)
+
+// ModelMetadata 应用为本地模型保存的收藏、备注、标签和默认聊天参数，按模型 digest 索引
+type ModelMetadata struct {
+	Name        string                 `json:"name"` // 最近一次使用的模型名称，模型更新后 digest 变化时据此迁移
+	Favorite    bool                   `json:"favorite,omitempty"`
+	Notes       string                 `json:"notes,omitempty"`
+	Labels      []string               `json:"labels,omitempty"`
+	ChatOptions map[string]interface{} `json:"chat_options,omitempty"`
+	UpdatedAt   string                 `json:"updated_at"`
+}
+
+// empty 元数据是否为空，为空时不再保存
+func (m *ModelMetadata) empty() bool {
+	return !m.Favorite && m.Notes == "" && len(m.Labels) == 0 && len(m.ChatOptions) == 0
+}
+
+// getModelMetadataPath 获取模型元数据文件路径
+func (a *App) getModelMetadataPath() string {
+	return filepath.Join(a.getConfigDir(), "model_metadata.json")
+}
+
+// loadModelMetadataLocked 首次使用时从文件加载模型元数据，调用方需持有 modelMetadataMutex
+func (a *App) loadModelMetadataLocked() {
+	if a.modelMetadata != nil {
+		return
+	}
+	a.modelMetadata = make(map[string]*ModelMetadata)
+
+	data, err := os.ReadFile(a.getModelMetadataPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("loadModelMetadata: 读取模型元数据失败: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &a.modelMetadata); err != nil {
+		log.Printf("loadModelMetadata: 解析模型元数据失败: %v", err)
+		a.modelMetadata = make(map[string]*ModelMetadata)
+	}
+}
+
+// saveModelMetadataLocked 保存模型元数据，调用方需持有 modelMetadataMutex
+func (a *App) saveModelMetadataLocked() error {
+	data, err := json.MarshalIndent(a.modelMetadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(a.getModelMetadataPath(), data, 0644)
+}
+
+// normalizeModelLabels 规范化标签：转为小写、去重、排序并校验格式
+func normalizeModelLabels(labels []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		if !modelLabelRegex.MatchString(label) {
+			return nil, fmt.Errorf("无效的标签: %s，只能包含小写字母、数字和 . _ -，最长 32 个字符", label)
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	if len(normalized) > maxModelLabels {
+		return nil, fmt.Errorf("标签不能超过 %d 个", maxModelLabels)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// validateChatOptions 校验默认聊天参数，参数名和值的类型与 Modelfile 的 PARAMETER 一致
+func validateChatOptions(options map[string]interface{}) error {
+	for name, value := range options {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case []interface{}:
+			// stop 可以有多个值
+			for _, item := range v {
+				if err := validateModelfileParameter(name, fmt.Sprint(item)); err != nil {
+					return err
+				}
+			}
+			continue
+		default:
+			text = fmt.Sprint(v)
+		}
+		if err := validateModelfileParameter(name, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modelDigestFor 获取本地模型的 digest
+func (a *App) modelDigestFor(name string) (string, error) {
+	models, err := a.fetchLocalModels()
+	if err != nil {
+		return "", err
+	}
+	for _, model := range models {
+		if sameModelName(model.Name, name) {
+			return model.Digest, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", errModelNotFound, name)
+}
+
+// updateModelMetadata 修改模型的元数据并保存
+func (a *App) updateModelMetadata(name string, update func(m *ModelMetadata)) (*ModelMetadata, error) {
+	digest, err := a.modelDigestFor(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a.modelMetadataMutex.Lock()
+	defer a.modelMetadataMutex.Unlock()
+	a.loadModelMetadataLocked()
+
+	metadata, ok := a.modelMetadata[digest]
+	if !ok {
+		metadata = &ModelMetadata{}
+	}
+	update(metadata)
+	metadata.Name = parseModelReference(name).String()
+	metadata.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	if metadata.empty() {
+		delete(a.modelMetadata, digest)
+	} else {
+		a.modelMetadata[digest] = metadata
+	}
+	if err := a.saveModelMetadataLocked(); err != nil {
+		return nil, err
+	}
+	copied := *metadata
+	return &copied, nil
+}
+
+// applyModelMetadata 将元数据合并到模型列表
+// 模型更新后 digest 会变化，此时按名称找到不再对应任何本地模型的旧记录并迁移到新的 digest
+func (a *App) applyModelMetadata(models []ModelInfo) {
+	a.modelMetadataMutex.Lock()
+	defer a.modelMetadataMutex.Unlock()
+	a.loadModelMetadataLocked()
+
+	present := make(map[string]bool, len(models))
+	for _, model := range models {
+		present[model.Digest] = true
+	}
+
+	migrated := false
+	for i := range models {
+		metadata, ok := a.modelMetadata[models[i].Digest]
+		if !ok {
+			for digest, stale := range a.modelMetadata {
+				if !present[digest] && sameModelName(stale.Name, models[i].Name) {
+					log.Printf("applyModelMetadata: 模型 %s 已更新，迁移元数据", models[i].Name)
+					delete(a.modelMetadata, digest)
+					a.modelMetadata[models[i].Digest] = stale
+					metadata, ok, migrated = stale, true, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		models[i].Favorite = metadata.Favorite
+		models[i].Notes = metadata.Notes
+		models[i].Labels = metadata.Labels
+		models[i].ChatOptions = metadata.ChatOptions
+	}
+
+	if migrated {
+		if err := a.saveModelMetadataLocked(); err != nil {
+			log.Printf("applyModelMetadata: 保存模型元数据失败: %v", err)
+		}
+	}
+}
+
+// defaultChatOptions 获取模型的默认聊天参数，未设置时返回 nil
+func (a *App) defaultChatOptions(model string) map[string]interface{} {
+	digest := a.lookupModelDigest(model)
+	if digest == "" {
+		return nil
+	}
+
+	a.modelMetadataMutex.Lock()
+	defer a.modelMetadataMutex.Unlock()
+	a.loadModelMetadataLocked()
+	if metadata, ok := a.modelMetadata[digest]; ok && len(metadata.ChatOptions) > 0 {
+		options := make(map[string]interface{}, len(metadata.ChatOptions))
+		for key, value := range metadata.ChatOptions {
+			options[key] = value
+		}
+		return options
+	}
+	return nil
+}
+
+// mergeChatOptions 合并模型的默认聊天参数和请求中的参数，请求中指定的参数优先，都没有时返回 nil
+func (a *App) mergeChatOptions(model string, requestOptions map[string]interface{}) map[string]interface{} {
+	options := a.defaultChatOptions(model)
+	for key, value := range requestOptions {
+		if options == nil {
+			options = make(map[string]interface{}, len(requestOptions))
+		}
+		options[key] = value
+	}
+	return options
+}
+
+// metadataResult 返回元数据修改的结果
+func metadataResult(name string, metadata *ModelMetadata, err error, message string) map[string]interface{} {
+	if err != nil {
+		log.Printf("修改模型元数据失败: %s, %v", name, err)
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(err),
+			"message": fmt.Sprintf("保存失败: %v", err),
+		}
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"model":    name,
+		"metadata": metadata,
+		"message":  message,
+	}
+}
+
+// SetModelFavorite 收藏或取消收藏模型
+func (a *App) SetModelFavorite(name string, favorite bool) map[string]interface{} {
+	metadata, err := a.updateModelMetadata(name, func(m *ModelMetadata) {
+		m.Favorite = favorite
+	})
+	message := "已收藏"
+	if !favorite {
+		message = "已取消收藏"
+	}
+	return metadataResult(name, metadata, err, message)
+}
+
+// SetModelNotes 设置模型的备注
+func (a *App) SetModelNotes(name, notes string) map[string]interface{} {
+	notes = strings.TrimSpace(notes)
+	if len([]rune(notes)) > maxModelNoteRunes {
+		return metadataResult(name, nil, fmt.Errorf("备注不能超过 %d 个字符", maxModelNoteRunes), "")
+	}
+	metadata, err := a.updateModelMetadata(name, func(m *ModelMetadata) {
+		m.Notes = notes
+	})
+	return metadataResult(name, metadata, err, "备注已保存")
+}
+
+// SetModelLabels 设置模型的自定义标签
+func (a *App) SetModelLabels(name string, labels []string) map[string]interface{} {
+	normalized, err := normalizeModelLabels(labels)
+	if err != nil {
+		return metadataResult(name, nil, err, "")
+	}
+	metadata, err := a.updateModelMetadata(name, func(m *ModelMetadata) {
+		m.Labels = normalized
+	})
+	return metadataResult(name, metadata, err, "标签已保存")
+}
+
+// SetModelChatOptions 设置模型的默认聊天参数（如 temperature、num_ctx），聊天请求未指定时使用
+func (a *App) SetModelChatOptions(name string, options map[string]interface{}) map[string]interface{} {
+	if err := validateChatOptions(options); err != nil {
+		return metadataResult(name, nil, err, "")
+	}
+	metadata, err := a.updateModelMetadata(name, func(m *ModelMetadata) {
+		m.ChatOptions = options
+	})
+	return metadataResult(name, metadata, err, "默认聊天参数已保存")
+}
+
+// ListModelLabels 获取所有自定义标签及使用该标签的模型数
+func (a *App) ListModelLabels() map[string]int {
+	a.modelMetadataMutex.Lock()
+	defer a.modelMetadataMutex.Unlock()
+	a.loadModelMetadataLocked()
+
+	counts := make(map[string]int)
+	for _, metadata := range a.modelMetadata {
+		for _, label := range metadata.Labels {
+			counts[label]++
+		}
+	}
+	return counts
+}
+
+// FilterModels 按标签和收藏筛选本地模型，label 为空时不按标签筛选
+func (a *App) FilterModels(label string, favoritesOnly bool) []ModelInfo {
+	label = strings.ToLower(strings.TrimSpace(label))
+	filtered := []ModelInfo{}
+	for _, model := range a.ListModels() {
+		if favoritesOnly && !model.Favorite {
+			continue
+		}
+		if label != "" && !containsString(model.Labels, label) {
+			continue
+		}
+		filtered = append(filtered, model)
+	}
+	return filtered
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ModelRouteRule 模型路由规则
+// Pattern 默认按通配符匹配（* 和 ?），Regex 为 true 时按正则表达式匹配，
+// 正则规则的 Target 可以使用 $1 等分组引用
+type ModelRouteRule struct {
+	Pattern string `json:"pattern"`
+	Target  string `json:"target"`
+	Regex   bool   `json:"regex,omitempty"`
+}
+
+// ModelRoutingConfig 网关模型别名和路由配置
+type ModelRoutingConfig struct {
+	Aliases        map[string]string   `json:"aliases"`
+	Rules          []ModelRouteRule    `json:"rules"`
+	DefaultModel   string              `json:"default_model"`
+	FallbackChains map[string][]string `json:"fallback_chains"`
+
+	foldedAliases map[string]string // 小写别名到目标模型，用于不区分大小写的匹配
+	compiledRules []*regexp.Regexp  // 与 Rules 一一对应，无效的规则为 nil
+}
+
+// compile 将规则编译为完整匹配的正则表达式
+func (r ModelRouteRule) compile() (*regexp.Regexp, error) {
+	if r.Regex {
+		return regexp.Compile("^(?:" + r.Pattern + ")$")
+	}
+
+	pattern := regexp.QuoteMeta(r.Pattern)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	return regexp.Compile("(?i)^" + pattern + "$")
+}
+
+// prepare 在加载或保存配置时编译路由规则并建立别名索引，避免每次请求重复编译。
+// 只有大小写不同的别名按排序后的第一个生效
+func (c *ModelRoutingConfig) prepare() {
+	c.foldedAliases = make(map[string]string, len(c.Aliases))
+	for _, alias := range c.sortedAliases() {
+		key := strings.ToLower(alias)
+		if _, ok := c.foldedAliases[key]; !ok {
+			c.foldedAliases[key] = c.Aliases[alias]
+		}
+	}
+
+	c.compiledRules = make([]*regexp.Regexp, len(c.Rules))
+	for i, rule := range c.Rules {
+		re, err := rule.compile()
+		if err != nil {
+			log.Printf("prepare: 第 %d 条路由规则无效，已忽略: %v", i+1, err)
+			continue
+		}
+		c.compiledRules[i] = re
+	}
+}
+
+// route 按别名表和路由规则解析模型名称，未命中时返回原名称和 false
+// 配置需要先调用 prepare
+func (c ModelRoutingConfig) route(name string) (string, bool) {
+	if target, ok := c.Aliases[name]; ok && target != "" {
+		return target, true
+	}
+	if target, ok := c.foldedAliases[strings.ToLower(name)]; ok {
+		return target, true
+	}
+
+	for i, rule := range c.Rules {
+		if i >= len(c.compiledRules) || c.compiledRules[i] == nil {
+			continue
+		}
+		re := c.compiledRules[i]
+		if !re.MatchString(name) {
+			continue
+		}
+		if rule.Regex {
+			return re.ReplaceAllString(name, rule.Target), true
+		}
+		return rule.Target, true
+	}
+
+	return name, false
+}
+
+// fallbackChain 获取请求失败时依次尝试的回退模型
+// 回退链可以按客户端请求的名称或解析后的模型名称配置
+func (c ModelRoutingConfig) fallbackChain(requested, resolved string) []string {
+	chain, ok := c.FallbackChains[requested]
+	if !ok {
+		chain = c.FallbackChains[resolved]
+	}
+
+	var fallbacks []string
+	seen := map[string]bool{resolved: true}
+	for _, model := range chain {
+		model = strings.TrimSpace(model)
+		if model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		fallbacks = append(fallbacks, model)
+	}
+	return fallbacks
+}
+
+// sortedAliases 返回排序后的别名列表
+func (c ModelRoutingConfig) sortedAliases() []string {
+	aliases := make([]string, 0, len(c.Aliases))
+	for alias, target := range c.Aliases {
+		if target != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// validate 校验路由配置
+func (c ModelRoutingConfig) validate() error {
+	folded := make(map[string]string, len(c.Aliases))
+	for _, alias := range c.sortedAliases() {
+		if other, ok := folded[strings.ToLower(alias)]; ok {
+			return fmt.Errorf("别名 %s 和 %s 只有大小写不同", other, alias)
+		}
+		folded[strings.ToLower(alias)] = alias
+	}
+	for alias, target := range c.Aliases {
+		if strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("别名不能为空")
+		}
+		if strings.TrimSpace(target) == "" {
+			return fmt.Errorf("别名 %s 的目标模型不能为空", alias)
+		}
+	}
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return fmt.Errorf("第 %d 条规则的匹配模式不能为空", i+1)
+		}
+		if strings.TrimSpace(rule.Target) == "" {
+			return fmt.Errorf("第 %d 条规则的目标模型不能为空", i+1)
+		}
+		if _, err := rule.compile(); err != nil {
+			return fmt.Errorf("第 %d 条规则的匹配模式无效: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// getModelRouting 获取当前的模型路由配置
+func (a *App) getModelRouting() ModelRoutingConfig {
+	a.modelRoutingMutex.RLock()
+	defer a.modelRoutingMutex.RUnlock()
+	return a.modelRouting
+}
+
+// GetModelRouting 获取网关模型别名和路由配置
+func (a *App) GetModelRouting() ModelRoutingConfig {
+	return a.getModelRouting()
+}
+
+// SaveModelRouting 保存网关模型别名和路由配置
+func (a *App) SaveModelRouting(config ModelRoutingConfig) map[string]interface{} {
+	if err := config.validate(); err != nil {
+		log.Printf("SaveModelRouting: 配置无效: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("路由配置无效: %v", err),
+		}
+	}
+
+	config.prepare()
+	a.modelRoutingMutex.Lock()
+	a.modelRouting = config
+	a.modelRoutingMutex.Unlock()
+
+	a.saveConfig()
+	log.Printf("SaveModelRouting: 已保存 %d 个别名, %d 条规则", len(config.Aliases), len(config.Rules))
+
+	return map[string]interface{}{
+		"success": true,
+		"message": "模型路由配置已保存",
+	}
+}
+
+// ResolveModelRoute 预览模型名称的路由结果，便于在设置界面中调试规则
+func (a *App) ResolveModelRoute(name string) map[string]interface{} {
+	resolved := a.resolveModelName(name)
+	return map[string]interface{}{
+		"requested": name,
+		"resolved":  resolved,
+		"fallbacks": a.getModelRouting().fallbackChain(name, resolved),
+	}
+}
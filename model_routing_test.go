@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestModelRoutingRoute(t *testing.T) {
+	config := ModelRoutingConfig{
+		Aliases: map[string]string{
+			"gpt-4":  "qwen3:32b",
+			"GPT-4o": "qwen3:14b",
+			"empty":  "",
+		},
+		Rules: []ModelRouteRule{
+			{Pattern: "claude-*", Target: "qwen3:8b"},
+			{Pattern: "([a-z]+)-(\\d+)b", Target: "$1:${2}b", Regex: true},
+			{Pattern: "(", Target: "broken", Regex: true},
+			{Pattern: "gpt-?", Target: "llama3:8b"},
+		},
+	}
+	config.prepare()
+
+	tests := []struct {
+		name   string
+		model  string
+		want   string
+		wantOK bool
+	}{
+		{"别名", "gpt-4", "qwen3:32b", true},
+		{"别名不区分大小写", "gpt-4O", "qwen3:14b", true},
+		{"目标为空的别名不生效", "empty", "empty", false},
+		{"通配符规则", "claude-3-opus", "qwen3:8b", true},
+		{"通配符不区分大小写", "Claude-3", "qwen3:8b", true},
+		{"正则规则分组引用", "qwen-7b", "qwen:7b", true},
+		{"正则规则完整匹配", "qwen-7b-instruct", "qwen-7b-instruct", false},
+		{"跳过无效规则", "gpt-5", "llama3:8b", true},
+		{"别名优先于规则", "GPT-4", "qwen3:32b", true},
+		{"未命中", "mistral:7b", "mistral:7b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := config.route(tt.model)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("route(%q) = %q, %v, want %q, %v", tt.model, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestModelRoutingCaseOnlyAliases(t *testing.T) {
+	config := ModelRoutingConfig{Aliases: map[string]string{"Fast": "a:1b", "fast": "b:1b", "FAST": "c:1b"}}
+	if err := config.validate(); err == nil {
+		t.Error("validate() accepted aliases differing only in case")
+	}
+
+	// 旧配置中已有的此类别名按排序后的第一个生效
+	config.prepare()
+	for i := 0; i < 20; i++ {
+		if got, _ := config.route("fAsT"); got != "c:1b" {
+			t.Fatalf("route(fAsT) = %q, want c:1b", got)
+		}
+	}
+	if got, _ := config.route("fast"); got != "b:1b" {
+		t.Errorf("route(fast) = %q, want exact match b:1b", got)
+	}
+}
+
+func TestModelRoutingFallbackChain(t *testing.T) {
+	config := ModelRoutingConfig{
+		FallbackChains: map[string][]string{
+			"gpt-4":     {"qwen3:14b", " qwen3:8b ", "", "qwen3:14b", "qwen3:32b"},
+			"qwen3:32b": {"llama3:70b"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		requested string
+		resolved  string
+		want      []string
+	}{
+		{"按请求名称", "gpt-4", "qwen3:32b", []string{"qwen3:14b", "qwen3:8b"}},
+		{"按解析后的名称", "big", "qwen3:32b", []string{"llama3:70b"}},
+		{"没有回退链", "small", "qwen3:0.6b", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.fallbackChain(tt.requested, tt.resolved); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fallbackChain(%q, %q) = %q, want %q", tt.requested, tt.resolved, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 默认的模型仓库地址和更新检查间隔
+const (
+	defaultRegistryURL             = "https://registry.ollama.ai"
+	defaultModelUpdateCheckHours   = 24
+	modelUpdateCheckStartupDelay   = 2 * time.Minute
+	modelUpdateCheckRequestTimeout = 30 * time.Second
+)
+
+// registryManifestMediaTypes 请求仓库清单时接受的类型
+var registryManifestMediaTypes = strings.Join([]string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}, ", ")
+
+// ModelUpdateStatus 模型的更新检查结果
+type ModelUpdateStatus struct {
+	Model           string `json:"model"`
+	UpdateAvailable bool   `json:"update_available"`
+	LocalDigest     string `json:"local_digest"` // 检查时本地清单的校验和
+	RemoteSize      int64  `json:"remote_size"`  // 仓库中最新版本的大小
+	CheckedAt       string `json:"checked_at"`
+	Error           string `json:"error,omitempty"`
+}
+
+// modelUpdateChecker 保存最近一次更新检查的结果
+type modelUpdateChecker struct {
+	mu       sync.Mutex
+	running  bool
+	statuses map[string]ModelUpdateStatus // 按规范化的模型名称索引
+}
+
+// getRegistryURL 获取模型仓库地址，可以配置为本地的仓库镜像
+func (a *App) getRegistryURL() string {
+	return strings.TrimRight(a.getConfigString("OLLAMA_REGISTRY_URL", defaultRegistryURL), "/")
+}
+
+// registryManifestURL 获取模型在仓库中的清单地址，默认仓库的模型使用配置的仓库地址
+func (a *App) registryManifestURL(ref modelReference) string {
+	base := "https://" + ref.Host
+	if ref.Host == defaultModelRegistry {
+		base = a.getRegistryURL()
+	}
+	return fmt.Sprintf("%s/v2/%s/%s/manifests/%s", base, ref.Namespace, ref.Model, ref.Tag)
+}
+
+// fetchRegistryManifest 从仓库获取模型清单
+func (a *App) fetchRegistryManifest(ctx context.Context, client *http.Client, name string) (*modelManifest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.registryManifestURL(parseModelReference(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", registryManifestMediaTypes)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求仓库失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("仓库中不存在该模型")
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("仓库需要认证")
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("仓库返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var manifest modelManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("解析仓库清单失败: %v", err)
+	}
+	return &manifest, nil
+}
+
+// sameManifestContent 判断两个清单是否引用相同的配置和层
+func sameManifestContent(local, remote *modelManifest) bool {
+	localDigests, remoteDigests := local.digests(), remote.digests()
+	if len(localDigests) != len(remoteDigests) {
+		return false
+	}
+	sort.Strings(localDigests)
+	sort.Strings(remoteDigests)
+	for i := range localDigests {
+		if localDigests[i] != remoteDigests[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// localManifestDigest 计算本地清单文件的校验和，用于判断检查结果是否已过期
+func (a *App) localManifestDigest(name string) string {
+	data, err := os.ReadFile(a.manifestPath(name))
+	if err != nil {
+		return ""
+	}
+	return sha256Hex(data)
+}
+
+// checkModelUpdate 比较本地清单与仓库清单，判断模型是否有更新
+func (a *App) checkModelUpdate(ctx context.Context, client *http.Client, name string) ModelUpdateStatus {
+	status := ModelUpdateStatus{
+		Model:     name,
+		CheckedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	data, err := os.ReadFile(a.manifestPath(name))
+	if err != nil {
+		status.Error = fmt.Sprintf("读取本地清单失败: %v", err)
+		return status
+	}
+	var local modelManifest
+	if err := json.Unmarshal(data, &local); err != nil {
+		status.Error = fmt.Sprintf("解析本地清单失败: %v", err)
+		return status
+	}
+	status.LocalDigest = sha256Hex(data)
+
+	remote, err := a.fetchRegistryManifest(ctx, client, name)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.RemoteSize = remote.totalSize()
+	status.UpdateAvailable = !sameManifestContent(&local, remote)
+	return status
+}
+
+// checkModelUpdates 检查所有本地模型的更新，完成后发送 model_updates_checked 事件
+func (a *App) checkModelUpdates(ctx context.Context) ([]ModelUpdateStatus, error) {
+	a.modelUpdates.mu.Lock()
+	if a.modelUpdates.running {
+		a.modelUpdates.mu.Unlock()
+		return nil, fmt.Errorf("正在检查模型更新")
+	}
+	a.modelUpdates.running = true
+	a.modelUpdates.mu.Unlock()
+	defer func() {
+		a.modelUpdates.mu.Lock()
+		a.modelUpdates.running = false
+		a.modelUpdates.mu.Unlock()
+	}()
+
+	var names []string
+	err := a.walkManifests(func(name, path string, manifest *modelManifest) {
+		names = append(names, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: modelUpdateCheckRequestTimeout}
+	statuses := make([]ModelUpdateStatus, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		status := a.checkModelUpdate(ctx, client, name)
+		if status.Error != "" {
+			log.Printf("checkModelUpdates: %s: %s", name, status.Error)
+		}
+		statuses = append(statuses, status)
+	}
+
+	a.modelUpdates.mu.Lock()
+	a.modelUpdates.statuses = make(map[string]ModelUpdateStatus, len(statuses))
+	for _, status := range statuses {
+		a.modelUpdates.statuses[parseModelReference(status.Model).String()] = status
+	}
+	a.modelUpdates.mu.Unlock()
+
+	outdated := 0
+	for _, status := range statuses {
+		if status.UpdateAvailable {
+			outdated++
+		}
+	}
+	log.Printf("checkModelUpdates: 已检查 %d 个模型，%d 个有更新", len(statuses), outdated)
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "model_updates_checked", statuses)
+	}
+	return statuses, nil
+}
+
+// modelUpdateStatus 获取模型最近一次的检查结果，本地清单在检查后发生变化（如已更新）时视为无结果
+func (a *App) modelUpdateStatus(name string) (ModelUpdateStatus, bool) {
+	a.modelUpdates.mu.Lock()
+	status, ok := a.modelUpdates.statuses[parseModelReference(name).String()]
+	a.modelUpdates.mu.Unlock()
+	if !ok || status.LocalDigest != a.localManifestDigest(name) {
+		return ModelUpdateStatus{}, false
+	}
+	return status, true
+}
+
+// applyModelUpdateStatus 将更新检查结果合并到模型列表
+func (a *App) applyModelUpdateStatus(models []ModelInfo) {
+	for i := range models {
+		if status, ok := a.modelUpdateStatus(models[i].Name); ok {
+			models[i].UpdateAvailable = status.UpdateAvailable
+			models[i].UpdateCheckedAt = status.CheckedAt
+		}
+	}
+}
+
+// startModelUpdateChecker 按 OLLAMA_MODEL_UPDATE_CHECK_HOURS 定期检查模型更新，0 表示不自动检查
+func (a *App) startModelUpdateChecker() {
+	go func() {
+		time.Sleep(modelUpdateCheckStartupDelay)
+		for {
+			hours := a.getConfigInt("OLLAMA_MODEL_UPDATE_CHECK_HOURS", defaultModelUpdateCheckHours)
+			if hours > 0 {
+				if _, err := a.checkModelUpdates(context.Background()); err != nil {
+					log.Printf("startModelUpdateChecker: %v", err)
+				}
+			} else {
+				// 未开启自动检查时每小时重新读取一次设置
+				hours = 1
+			}
+			time.Sleep(time.Duration(hours) * time.Hour)
+		}
+	}()
+}
+
+// CheckModelUpdates 立即检查所有本地模型是否有更新
+func (a *App) CheckModelUpdates() map[string]interface{} {
+	statuses, err := a.checkModelUpdates(context.Background())
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("检查模型更新失败: %v", err),
+		}
+	}
+
+	var outdated []string
+	for _, status := range statuses {
+		if status.UpdateAvailable {
+			outdated = append(outdated, status.Model)
+		}
+	}
+	message := "所有模型都是最新版本"
+	if len(outdated) > 0 {
+		message = fmt.Sprintf("%d 个模型有更新: %s", len(outdated), strings.Join(outdated, ", "))
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"results":  statuses,
+		"outdated": outdated,
+		"message":  message,
+	}
+}
+
+// UpdateModel 将有更新的模型加入拉取队列
+func (a *App) UpdateModel(name string) map[string]interface{} {
+	if !a.localModelExists(name) {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "not_found",
+			"message": fmt.Sprintf("模型不存在: %s", name),
+		}
+	}
+
+	job := a.enqueuePullJob(parseModelReference(name).String())
+	return map[string]interface{}{
+		"success": true,
+		"job":     job,
+		"message": fmt.Sprintf("已加入下载队列: %s", job.Model),
+	}
+}
+
+// UpdateAllModels 将所有有更新的模型加入拉取队列
+func (a *App) UpdateAllModels() map[string]interface{} {
+	a.modelUpdates.mu.Lock()
+	var names []string
+	for _, status := range a.modelUpdates.statuses {
+		if status.UpdateAvailable {
+			names = append(names, status.Model)
+		}
+	}
+	a.modelUpdates.mu.Unlock()
+	sort.Strings(names)
+
+	var jobs []PullJob
+	for _, name := range names {
+		if _, ok := a.modelUpdateStatus(name); ok {
+			jobs = append(jobs, a.enqueuePullJob(parseModelReference(name).String()))
+		}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"jobs":    jobs,
+		"message": fmt.Sprintf("已将 %d 个模型加入下载队列", len(jobs)),
+	}
+}
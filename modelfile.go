@@ -0,0 +1,381 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// modelfileParamType PARAMETER 值的类型
+type modelfileParamType int
+
+const (
+	modelfileParamInt modelfileParamType = iota
+	modelfileParamFloat
+	modelfileParamBool
+	modelfileParamString
+)
+
+// modelfileParameters Ollama 支持的 PARAMETER 名称及其类型
+var modelfileParameters = map[string]modelfileParamType{
+	"mirostat":          modelfileParamInt,
+	"mirostat_eta":      modelfileParamFloat,
+	"mirostat_tau":      modelfileParamFloat,
+	"num_ctx":           modelfileParamInt,
+	"num_batch":         modelfileParamInt,
+	"num_gpu":           modelfileParamInt,
+	"main_gpu":          modelfileParamInt,
+	"num_thread":        modelfileParamInt,
+	"num_keep":          modelfileParamInt,
+	"num_predict":       modelfileParamInt,
+	"repeat_last_n":     modelfileParamInt,
+	"repeat_penalty":    modelfileParamFloat,
+	"presence_penalty":  modelfileParamFloat,
+	"frequency_penalty": modelfileParamFloat,
+	"temperature":       modelfileParamFloat,
+	"seed":              modelfileParamInt,
+	"stop":              modelfileParamString,
+	"tfs_z":             modelfileParamFloat,
+	"top_k":             modelfileParamInt,
+	"top_p":             modelfileParamFloat,
+	"min_p":             modelfileParamFloat,
+	"typical_p":         modelfileParamFloat,
+	"penalize_newline":  modelfileParamBool,
+	"use_mmap":          modelfileParamBool,
+	"use_mlock":         modelfileParamBool,
+	"numa":              modelfileParamBool,
+	"low_vram":          modelfileParamBool,
+}
+
+// modelfileInstructions 支持的指令
+var modelfileInstructions = map[string]bool{
+	"from":      true,
+	"parameter": true,
+	"template":  true,
+	"system":    true,
+	"adapter":   true,
+	"license":   true,
+	"message":   true,
+	"requires":  true,
+}
+
+// ModelfileCommand Modelfile 中的一条指令
+type ModelfileCommand struct {
+	Line  int    `json:"line"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ModelfileError 带行号的 Modelfile 错误，Line 为 0 表示整个文件的错误
+type ModelfileError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e ModelfileError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("第 %d 行: %s", e.Line, e.Message)
+}
+
+// Modelfile 解析后的 Modelfile
+type Modelfile struct {
+	Commands []ModelfileCommand `json:"commands"`
+}
+
+// parseModelfile 解析 Modelfile 文本，支持 # 注释、"..." 和 """...""" 多行值
+func parseModelfile(content string) (*Modelfile, []ModelfileError) {
+	modelfile := &Modelfile{}
+	var errs []ModelfileError
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest := line, ""
+		if idx := strings.IndexAny(line, " \t"); idx >= 0 {
+			name, rest = line[:idx], strings.TrimSpace(line[idx+1:])
+		}
+		name = strings.ToLower(name)
+		if !modelfileInstructions[name] {
+			errs = append(errs, ModelfileError{Line: lineNo, Message: fmt.Sprintf("未知的指令: %s", strings.ToUpper(name))})
+			continue
+		}
+
+		// PARAMETER 和 MESSAGE 的第一个参数是名称或角色，值在其后
+		prefix := ""
+		if name == "parameter" || name == "message" {
+			if idx := strings.IndexAny(rest, " \t"); idx >= 0 {
+				prefix, rest = rest[:idx], strings.TrimSpace(rest[idx+1:])
+			} else {
+				prefix, rest = rest, ""
+			}
+		}
+
+		value := rest
+		switch {
+		case strings.HasPrefix(rest, `"""`):
+			body := rest[3:]
+			if end := strings.Index(body, `"""`); end >= 0 {
+				value = body[:end]
+				if trailing := strings.TrimSpace(body[end+3:]); trailing != "" {
+					errs = append(errs, ModelfileError{Line: lineNo, Message: fmt.Sprintf(`结束的 """ 之后有多余的内容: %s`, trailing)})
+				}
+				break
+			}
+			// 多行值，直到遇到结束的 """
+			parts := []string{body}
+			closed := false
+			for i+1 < len(lines) {
+				i++
+				if end := strings.Index(lines[i], `"""`); end >= 0 {
+					parts = append(parts, lines[i][:end])
+					closed = true
+					if trailing := strings.TrimSpace(lines[i][end+3:]); trailing != "" {
+						errs = append(errs, ModelfileError{Line: i + 1, Message: fmt.Sprintf(`结束的 """ 之后有多余的内容: %s`, trailing)})
+					}
+					break
+				}
+				parts = append(parts, lines[i])
+			}
+			if !closed {
+				errs = append(errs, ModelfileError{Line: lineNo, Message: `多行值缺少结束的 """`})
+			}
+			value = strings.Join(parts, "\n")
+			value = strings.TrimPrefix(value, "\n")
+		case strings.HasPrefix(rest, `"`):
+			// 没有转义语法，值中可以有引号，最后一个 " 为结束的引号
+			end := strings.LastIndex(rest, `"`)
+			if end == 0 {
+				errs = append(errs, ModelfileError{Line: lineNo, Message: `值缺少结束的 "`})
+				value = rest[1:]
+				break
+			}
+			value = rest[1:end]
+			if trailing := strings.TrimSpace(rest[end+1:]); trailing != "" {
+				errs = append(errs, ModelfileError{Line: lineNo, Message: fmt.Sprintf(`结束的 " 之后有多余的内容: %s`, trailing)})
+			}
+		}
+
+		if prefix != "" {
+			value = prefix + " " + value
+		}
+		modelfile.Commands = append(modelfile.Commands, ModelfileCommand{Line: lineNo, Name: name, Value: value})
+	}
+	return modelfile, errs
+}
+
+// splitModelfileArg 拆分 PARAMETER 和 MESSAGE 的名称与值
+func splitModelfileArg(value string) (string, string) {
+	if idx := strings.Index(value, " "); idx >= 0 {
+		return value[:idx], value[idx+1:]
+	}
+	return value, ""
+}
+
+// isLocalModelPath 判断 FROM 或 ADAPTER 的值是否为本地文件路径，而不是模型名称
+func isLocalModelPath(value string) bool {
+	if strings.HasPrefix(value, ".") || strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~") ||
+		strings.Contains(value, `\`) || filepath.IsAbs(value) {
+		return true
+	}
+	lower := strings.ToLower(value)
+	for _, ext := range []string{".gguf", ".bin", ".safetensors"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveModelfilePath 将相对路径解析为相对于 baseDir 的绝对路径
+func resolveModelfilePath(value, baseDir string) string {
+	if strings.HasPrefix(value, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			value = filepath.Join(home, value[1:])
+		}
+	}
+	if !filepath.IsAbs(value) && baseDir != "" {
+		value = filepath.Join(baseDir, value)
+	}
+	return filepath.Clean(value)
+}
+
+// validateModelfileParameter 校验 PARAMETER 的名称和值
+func validateModelfileParameter(name, value string) error {
+	paramType, ok := modelfileParameters[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("未知的参数: %s", name)
+	}
+	if value == "" {
+		return fmt.Errorf("参数 %s 缺少值", name)
+	}
+	switch paramType {
+	case modelfileParamInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("参数 %s 的值必须是整数: %s", name, value)
+		}
+	case modelfileParamFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("参数 %s 的值必须是数字: %s", name, value)
+		}
+	case modelfileParamBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("参数 %s 的值必须是 true 或 false: %s", name, value)
+		}
+	}
+	return nil
+}
+
+// validateModelfile 解析并校验 Modelfile，baseDir 用于解析相对路径
+func validateModelfile(content, baseDir string) (*Modelfile, []ModelfileError) {
+	modelfile, errs := parseModelfile(content)
+
+	fromCount := 0
+	for _, cmd := range modelfile.Commands {
+		switch cmd.Name {
+		case "from", "adapter", "requires":
+		default:
+			// 创建模型时指令要重新格式化为 Modelfile 文本，值必须能够写回
+			if _, err := quoteModelfileValue(cmd.Value); err != nil {
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: err.Error()})
+			}
+		}
+		switch cmd.Name {
+		case "from", "adapter":
+			if cmd.Name == "from" {
+				fromCount++
+			}
+			if cmd.Value == "" {
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: fmt.Sprintf("%s 缺少值", strings.ToUpper(cmd.Name))})
+				continue
+			}
+			if cmd.Name == "adapter" || isLocalModelPath(cmd.Value) {
+				path := resolveModelfilePath(cmd.Value, baseDir)
+				if _, err := os.Stat(path); err != nil {
+					errs = append(errs, ModelfileError{Line: cmd.Line, Message: fmt.Sprintf("文件不存在: %s", path)})
+				}
+			} else if _, err := validateModelName(cmd.Value); err != nil {
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: err.Error()})
+			}
+		case "parameter":
+			name, value := splitModelfileArg(cmd.Value)
+			if err := validateModelfileParameter(name, value); err != nil {
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: err.Error()})
+			}
+		case "message":
+			role, content := splitModelfileArg(cmd.Value)
+			switch strings.ToLower(role) {
+			case "system", "user", "assistant":
+			default:
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: fmt.Sprintf("无效的消息角色: %s，只能是 system、user 或 assistant", role)})
+			}
+			if strings.TrimSpace(content) == "" {
+				errs = append(errs, ModelfileError{Line: cmd.Line, Message: "MESSAGE 缺少内容"})
+			}
+		}
+	}
+
+	if fromCount == 0 {
+		errs = append(errs, ModelfileError{Line: 0, Message: "缺少 FROM 指令"})
+	} else if fromCount > 1 {
+		errs = append(errs, ModelfileError{Line: 0, Message: "只能有一条 FROM 指令"})
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return modelfile, errs
+}
+
+// errModelfileUnquotable Modelfile 没有转义语法，包含 """ 或以 " 结尾的多行值无法写入
+var errModelfileUnquotable = errors.New(`值中包含 """ 或多行值以 " 结尾，无法写入 Modelfile`)
+
+// quoteModelfileValue 按需为值加上引号，多行或包含引号的值使用 """
+func quoteModelfileValue(value string) (string, error) {
+	if strings.Contains(value, `"""`) {
+		return "", errModelfileUnquotable
+	}
+	if strings.ContainsAny(value, "\n\"") {
+		// 以 " 结尾的值会和结束的 """ 连在一起，单行值改用 "..."
+		if strings.HasSuffix(value, `"`) {
+			if strings.Contains(value, "\n") || strings.HasPrefix(value, `""`) {
+				return "", errModelfileUnquotable
+			}
+			return `"` + value + `"`, nil
+		}
+		return `"""` + value + `"""`, nil
+	}
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`, nil
+	}
+	return value, nil
+}
+
+// generateModelfile 根据 /api/show 的结果生成以该模型为基础的 Modelfile
+func generateModelfile(name string, show map[string]interface{}) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 基于 %s 生成的 Modelfile\n", name)
+	fmt.Fprintf(&b, "FROM %s\n", name)
+
+	// writeCommand 写入一条指令，值无法写入 Modelfile 时返回错误
+	writeCommand := func(prefix, value string) error {
+		quoted, err := quoteModelfileValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", strings.Fields(prefix)[0], err)
+		}
+		fmt.Fprintf(&b, "%s %s\n", prefix, quoted)
+		return nil
+	}
+
+	if template := getString(show, "template"); template != "" {
+		b.WriteString("\n")
+		if err := writeCommand("TEMPLATE", template); err != nil {
+			return "", err
+		}
+	}
+	if system := getString(show, "system"); system != "" {
+		b.WriteString("\n")
+		if err := writeCommand("SYSTEM", system); err != nil {
+			return "", err
+		}
+	}
+
+	// parameters 为 "名称 值" 格式的多行文本，同一参数（如 stop）可以出现多次
+	if parameters := getString(show, "parameters"); parameters != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(parameters, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if err := writeCommand("PARAMETER "+fields[0], value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if messages, ok := show["messages"].([]interface{}); ok && len(messages) > 0 {
+		b.WriteString("\n")
+		for _, item := range messages {
+			if message, ok := item.(map[string]interface{}); ok {
+				if err := writeCommand("MESSAGE "+getString(message, "role"), getString(message, "content")); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	return b.String(), nil
+}
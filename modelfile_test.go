@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseModelfileTrailingText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLine int // 0 表示没有错误
+		want     string
+	}{
+		{"单行值", "FROM qwen3\nSYSTEM \"\"\"你是助手\"\"\"\n", 0, "你是助手"},
+		{"单行值后有内容", "FROM qwen3\nSYSTEM \"\"\"你是助手\"\"\" 多余\n", 2, "你是助手"},
+		{"多行值", "FROM qwen3\nSYSTEM \"\"\"\n第一行\n第二行\"\"\"\n", 0, "第一行\n第二行"},
+		{"多行值后有内容", "FROM qwen3\nSYSTEM \"\"\"\n第一行\n第二行\"\"\" PARAMETER top_k 20\n", 4, "第一行\n第二行"},
+		{"结束引号后只有空白", "FROM qwen3\nSYSTEM \"\"\"你是助手\"\"\"   \n", 0, "你是助手"},
+		{"引号值", "FROM qwen3\nSYSTEM \"你是 助手\"\n", 0, "你是 助手"},
+		{"引号值中有引号", "FROM qwen3\nSYSTEM \"说 \"你好\"\"\n", 0, `说 "你好"`},
+		{"引号值后有内容", "FROM qwen3\nSYSTEM \"a\" b\n", 2, "a"},
+		{"引号值缺少结束引号", "FROM qwen3\nSYSTEM \"unclosed\n", 2, "unclosed"},
+		{"参数的引号值后有内容", "FROM qwen3\nPARAMETER stop \"<|im_end|>\" x\n", 2, "stop <|im_end|>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modelfile, errs := parseModelfile(tt.content)
+			switch {
+			case tt.wantLine == 0 && len(errs) > 0:
+				t.Fatalf("unexpected errors: %v", errs)
+			case tt.wantLine != 0 && (len(errs) != 1 || errs[0].Line != tt.wantLine):
+				t.Fatalf("errors = %v, want one error on line %d", errs, tt.wantLine)
+			}
+			if got := modelfile.Commands[1].Value; got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteModelfileValue(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"0.7", "0.7", false},
+		{"", `""`, false},
+		{"你是 助手", `"你是 助手"`, false},
+		{"第一行\n第二行", "\"\"\"第一行\n第二行\"\"\"", false},
+		{`说 "你好" 吧`, `"""说 "你好" 吧"""`, false},
+		{`说 "你好"`, `"说 "你好""`, false},
+		{"第一行\n说 \"你好\"", "", true},
+		{`""你好"`, "", true},
+		{`包含 """ 的值`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := quoteModelfileValue(tt.value)
+		if tt.wantErr {
+			if !errors.Is(err, errModelfileUnquotable) {
+				t.Errorf("quoteModelfileValue(%q) error = %v, want errModelfileUnquotable", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("quoteModelfileValue(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			continue
+		}
+		// 写回的值要能解析为原来的值
+		modelfile, errs := parseModelfile("SYSTEM " + got)
+		if len(errs) > 0 || modelfile.Commands[0].Value != tt.value {
+			t.Errorf("parse(%q) = %q, %v, want %q", got, modelfile.Commands[0].Value, errs, tt.value)
+		}
+	}
+}
+
+func TestGenerateModelfileRoundTrip(t *testing.T) {
+	show := map[string]interface{}{
+		"template":   "{{ .System }}\n{{ .Prompt }}",
+		"system":     "你是助手",
+		"parameters": "stop \"<|im_end|>\"\ntemperature 0.7",
+	}
+	content, err := generateModelfile("qwen3:8b", show)
+	if err != nil {
+		t.Fatalf("generateModelfile: %v", err)
+	}
+	modelfile, errs := validateModelfile(content, "")
+	if len(errs) > 0 {
+		t.Fatalf("generated Modelfile is invalid: %v\n%s", errs, content)
+	}
+	if got := len(modelfile.Commands); got != 5 {
+		t.Errorf("commands = %d, want 5\n%s", got, content)
+	}
+
+	show["system"] = `不能包含 """`
+	if _, err := generateModelfile("qwen3:8b", show); err == nil || !strings.Contains(err.Error(), "SYSTEM") {
+		t.Errorf("generateModelfile error = %v, want SYSTEM error", err)
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 在线模型的排序方式
+const (
+	OnlineSortRelevance = "relevance"
+	OnlineSortPulls     = "pulls"
+	OnlineSortUpdated   = "updated"
+	OnlineSortSize      = "size"
+	OnlineSortName      = "name"
+)
+
+// OnlineModelQuery 在线模型的结构化查询，未设置的条件不参与筛选
+type OnlineModelQuery struct {
+	Query        string   `json:"query"`         // 关键词，容忍少量拼写错误
+	Capabilities []string `json:"capabilities"`  // 需要同时具备的能力，如 vision、tools、embedding、thinking
+	MinParams    float64  `json:"min_params"`    // 最小参数量（十亿），0 表示不限
+	MaxParams    float64  `json:"max_params"`    // 最大参数量（十亿），0 表示不限
+	Quantization string   `json:"quantization"`  // 量化类型，如 Q4_K_M；目录来源没有提供任何模型的量化类型时忽略，见 ignored_filters
+	Family       string   `json:"family"`        // 模型系列，如 llama、qwen
+	FitsHardware bool     `json:"fits_hardware"` // 只返回本机能运行的模型（至少一个参数量版本为 fits-gpu 或 fits-cpu）
+	SortBy       string   `json:"sort_by"`       // relevance、pulls、updated、size 或 name，默认有关键词时为 relevance，否则为 pulls
+	Ascending    bool     `json:"ascending"`     // 反转默认排序方向（默认：下载量从多到少、从新到旧、从小到大、名称按字母顺序）
+	Page         int      `json:"page"`
+	Limit        int      `json:"limit"`
+}
+
+// 参数量分组，用于分面统计
+var onlineParamBuckets = []struct {
+	label string
+	max   float64 // 十亿
+}{
+	{"<3B", 3}, {"3-9B", 9}, {"9-15B", 15}, {"15-40B", 40}, {">40B", math.Inf(1)},
+}
+
+// 模型系列取名称开头的字母部分，例如 llama3.1 -> llama、deepseek-r1 -> deepseek
+var onlineFamilyRegex = regexp.MustCompile(`^[a-z]+`)
+
+// 相对时间，例如 9 months ago、an hour ago
+var relativeTimeRegex = regexp.MustCompile(`(?i)^(?:about\s+)?(\d+|an?)\s+(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+var relativeTimeUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// onlineModelEntry 查询时从在线模型 map 中提取的字段
+type onlineModelEntry struct {
+	model        map[string]interface{}
+	name         string
+	tokens       []string // 名称拆分后的词，用于模糊匹配
+	description  string
+	capabilities []string
+	params       []float64 // 各版本的参数量（十亿）
+	fileSize     int64
+	quantization string
+	family       string
+	pulls        int64
+	age          time.Duration // 距离最近更新的时间，未知时为 -1
+	fitLabel     string
+	score        float64
+}
+
+// onlineBaseName 去掉仓库、命名空间和标签后的模型名称
+func onlineBaseName(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// stringList 读取 map 中的字符串列表，兼容 []string 和 []interface{}
+func stringList(model map[string]interface{}, key string) []string {
+	switch v := model[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// parseCatalogAge 解析 9 months ago、yesterday 或 2024-05-01 这样的更新时间，返回距今的时长
+func parseCatalogAge(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "":
+		return -1
+	case "just now", "today":
+		return 0
+	case "yesterday":
+		return 24 * time.Hour
+	}
+	if m := relativeTimeRegex.FindStringSubmatch(value); m != nil {
+		count := 1
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			count = n
+		}
+		return time.Duration(count) * relativeTimeUnits[strings.ToLower(m[2])]
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return time.Since(t)
+	}
+	return -1
+}
+
+// newOnlineModelEntry 提取查询需要的字段
+func newOnlineModelEntry(model map[string]interface{}) *onlineModelEntry {
+	entry := &onlineModelEntry{
+		model:        model,
+		name:         getString(model, "name"),
+		description:  strings.ToLower(getString(model, "description")),
+		capabilities: stringList(model, "capabilities"),
+		fitLabel:     getString(model, "fit_label"),
+		age:          parseCatalogAge(getString(model, "updated")),
+	}
+
+	base := onlineBaseName(entry.name)
+	entry.tokens = append(entry.tokens, base)
+	for _, part := range strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+		entry.tokens = append(entry.tokens, part)
+		if alpha := onlineFamilyRegex.FindString(part); alpha != "" && alpha != part {
+			entry.tokens = append(entry.tokens, alpha)
+		}
+	}
+
+	for _, size := range stringList(model, "sizes") {
+		if params := parseParameterSize(size); params > 0 {
+			entry.params = append(entry.params, params/1e9)
+		}
+	}
+	if details, ok := model["details"].(map[string]interface{}); ok {
+		if len(entry.params) == 0 {
+			if params := parseParameterSize(getString(details, "parameter_size")); params > 0 {
+				entry.params = append(entry.params, params/1e9)
+			}
+		}
+		entry.quantization = strings.ToUpper(getString(details, "quantization_level"))
+		entry.family = strings.ToLower(getString(details, "family"))
+	}
+	if entry.family == "" {
+		entry.family = onlineFamilyRegex.FindString(base)
+	}
+	if size := getString(model, "size"); size != "" {
+		entry.fileSize, _ = parseByteSize(size)
+	}
+
+	switch v := model["pull_count"].(type) {
+	case int64:
+		entry.pulls = v
+	case float64:
+		entry.pulls = int64(v)
+	default:
+		entry.pulls = parseCatalogCount(strings.TrimSuffix(getString(model, "pulls"), " Pulls"))
+	}
+	return entry
+}
+
+// levenshtein 计算两个字符串的编辑距离
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// typoTolerance 关键词允许的拼写错误数，短词必须精确匹配
+func typoTolerance(term string) int {
+	switch n := len([]rune(term)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// matchTerm 计算一个关键词与模型的匹配得分，0 表示不匹配
+func (e *onlineModelEntry) matchTerm(term string) float64 {
+	best := 0.0
+	for i, token := range e.tokens {
+		var score float64
+		switch {
+		case token == term:
+			score = 10
+		case strings.HasPrefix(token, term):
+			score = 7
+		case strings.Contains(token, term):
+			score = 5
+		default:
+			if distance := levenshtein(token, term); distance <= typoTolerance(term) {
+				score = 4 - float64(distance)
+			}
+		}
+		// 完整名称的匹配优于名称中的一部分
+		if i == 0 && score > 0 {
+			score += 1
+		}
+		best = math.Max(best, score)
+	}
+	if best == 0 && strings.Contains(e.description, term) {
+		best = 2
+	}
+	return best
+}
+
+// matchQuery 所有关键词都匹配时返回总得分，否则返回 0
+func (e *onlineModelEntry) matchQuery(terms []string) float64 {
+	total := 0.0
+	for _, term := range terms {
+		score := e.matchTerm(term)
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total
+}
+
+// matchFilters 判断模型是否满足结构化筛选条件，被忽略的条件需在调用前清空
+func (e *onlineModelEntry) matchFilters(q OnlineModelQuery) bool {
+	for _, capability := range q.Capabilities {
+		if !containsString(e.capabilities, strings.ToLower(capability)) {
+			return false
+		}
+	}
+	if q.MinParams > 0 || q.MaxParams > 0 {
+		inRange := false
+		for _, params := range e.params {
+			if (q.MinParams <= 0 || params >= q.MinParams) && (q.MaxParams <= 0 || params <= q.MaxParams) {
+				inRange = true
+				break
+			}
+		}
+		if !inRange {
+			return false
+		}
+	}
+	if q.Quantization != "" && e.quantization != strings.ToUpper(q.Quantization) {
+		return false
+	}
+	if q.Family != "" && e.family != strings.ToLower(q.Family) {
+		return false
+	}
+	if q.FitsHardware && e.fitLabel != ModelFitGPU && e.fitLabel != ModelFitCPU {
+		return false
+	}
+	return true
+}
+
+// smallestParams 最小版本的参数量，未知时为 0
+func (e *onlineModelEntry) smallestParams() float64 {
+	smallest := 0.0
+	for _, params := range e.params {
+		if smallest == 0 || params < smallest {
+			smallest = params
+		}
+	}
+	return smallest
+}
+
+// sortOnlineModels 按查询的排序方式排序，相同时按下载量和名称排序
+func sortOnlineModels(entries []*onlineModelEntry, sortBy string, ascending bool) {
+	less := func(a, b *onlineModelEntry) (bool, bool) {
+		switch sortBy {
+		case OnlineSortRelevance:
+			return a.score > b.score, a.score != b.score
+		case OnlineSortUpdated:
+			return a.age < b.age, a.age != b.age
+		case OnlineSortSize:
+			sa, sb := a.smallestParams(), b.smallestParams()
+			if sa == sb && a.fileSize != b.fileSize {
+				return a.fileSize < b.fileSize, true
+			}
+			return sa < sb, sa != sb
+		case OnlineSortName:
+			return a.name < b.name, a.name != b.name
+		default:
+			return a.pulls > b.pulls, a.pulls != b.pulls
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		// 按更新时间排序时，更新时间未知的模型总是排在最后
+		if sortBy == OnlineSortUpdated && (entries[i].age < 0) != (entries[j].age < 0) {
+			return entries[j].age < 0
+		}
+		if result, decided := less(entries[i], entries[j]); decided {
+			return result != ascending
+		}
+		if entries[i].pulls != entries[j].pulls {
+			return entries[i].pulls > entries[j].pulls
+		}
+		return entries[i].name < entries[j].name
+	})
+}
+
+// onlineModelFacets 统计各能力、系列、量化类型、参数量分组和硬件适配结果的模型数
+func onlineModelFacets(entries []*onlineModelEntry) map[string]map[string]int {
+	facets := map[string]map[string]int{
+		"capabilities":  {},
+		"families":      {},
+		"quantizations": {},
+		"params":        {},
+		"fit":           {},
+	}
+	for _, e := range entries {
+		for _, capability := range e.capabilities {
+			facets["capabilities"][capability]++
+		}
+		if e.family != "" {
+			facets["families"][e.family]++
+		}
+		if e.quantization != "" {
+			facets["quantizations"][e.quantization]++
+		}
+		counted := make(map[string]bool)
+		for _, params := range e.params {
+			for _, bucket := range onlineParamBuckets {
+				if params < bucket.max {
+					if !counted[bucket.label] {
+						counted[bucket.label] = true
+						facets["params"][bucket.label]++
+					}
+					break
+				}
+			}
+		}
+		if e.fitLabel != "" {
+			facets["fit"][e.fitLabel]++
+		}
+	}
+	return facets
+}
+
+// ignoreUnsupportedFilters 清空目录来源没有提供相应信息的筛选条件，返回被忽略的条件
+// 没有任何模型有量化类型时按量化类型筛选只会得到空结果，例如 ollama.com 的目录页面
+func ignoreUnsupportedFilters(q *OnlineModelQuery, facets map[string]map[string]int) []string {
+	ignored := []string{}
+	if q.Quantization != "" && len(facets["quantizations"]) == 0 {
+		q.Quantization = ""
+		ignored = append(ignored, "quantization")
+	}
+	return ignored
+}
+
+// QueryOnlineModels 按关键词（容忍拼写错误）、能力、参数量、量化类型、系列和硬件适配筛选在线模型并排序
+// facets 统计的是匹配关键词的全部模型，不受其他筛选条件影响，便于界面显示每个选项的数量
+// ollama.com 的目录页面不提供量化类型，没有任何模型有量化类型时忽略量化筛选，并在 ignored_filters 中说明
+func (a *App) QueryOnlineModels(q OnlineModelQuery) map[string]interface{} {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = 20
+	}
+
+	models, err := a.fetchOnlineModelsFromOllama()
+	if err != nil {
+		models = a.getBuiltinOnlineModels()
+	}
+	models = a.annotateModelFit(models)
+
+	terms := strings.Fields(strings.ToLower(q.Query))
+	var matched []*onlineModelEntry
+	for _, model := range models {
+		entry := newOnlineModelEntry(model)
+		if len(terms) > 0 {
+			if entry.score = entry.matchQuery(terms); entry.score == 0 {
+				continue
+			}
+		}
+		matched = append(matched, entry)
+	}
+	facets := onlineModelFacets(matched)
+
+	ignoredFilters := ignoreUnsupportedFilters(&q, facets)
+
+	var filtered []*onlineModelEntry
+	for _, entry := range matched {
+		if entry.matchFilters(q) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	sortBy := strings.ToLower(q.SortBy)
+	if sortBy == "" || (sortBy == OnlineSortRelevance && len(terms) == 0) {
+		sortBy = OnlineSortPulls
+		if len(terms) > 0 {
+			sortBy = OnlineSortRelevance
+		}
+	}
+	sortOnlineModels(filtered, sortBy, q.Ascending)
+
+	total := len(filtered)
+	start := (q.Page - 1) * q.Limit
+	end := start + q.Limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	page := make([]map[string]interface{}, 0, end-start)
+	for _, entry := range filtered[start:end] {
+		page = append(page, entry.model)
+	}
+
+	return map[string]interface{}{
+		"models":          page,
+		"total":           total,
+		"page":            q.Page,
+		"limit":           q.Limit,
+		"sort_by":         sortBy,
+		"facets":          facets,
+		"ignored_filters": ignoredFilters, // 目录来源不提供相应信息而没有生效的筛选条件
+	}
+}
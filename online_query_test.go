@@ -0,0 +1,210 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"qwen", "", 4},
+		{"llama", "llama", 0},
+		{"deepseek", "deepsek", 1},
+		{"mistral", "mistarl", 2},
+		{"gemma", "gamma", 1},
+		{"通义千问", "通义千问", 0},
+		{"通义千问", "通义问", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTerm(t *testing.T) {
+	llama := newOnlineModelEntry(map[string]interface{}{"name": "llama3.1", "description": "Meta's open model"})
+	deepseek := newOnlineModelEntry(map[string]interface{}{"name": "deepseek-r1"})
+	tests := []struct {
+		name  string
+		entry *onlineModelEntry
+		term  string
+		want  float64
+	}{
+		{"完整名称", llama, "llama3.1", 11},
+		{"名称前缀", llama, "llama3", 10},
+		{"系列名称", llama, "llama", 10},
+		{"包含", llama, "lam", 6},
+		{"拼写错误", deepseek, "deepsek", 3},
+		{"短词不容忍拼写错误", deepseek, "r2", 0},
+		{"描述", llama, "meta", 2},
+		{"不匹配", llama, "qwen", 0},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.matchTerm(tt.term); got != tt.want {
+			t.Errorf("%s: matchTerm(%q) = %v, want %v", tt.name, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	entry := newOnlineModelEntry(map[string]interface{}{"name": "deepseek-r1", "description": "reasoning model"})
+	tests := []struct {
+		terms []string
+		want  float64
+	}{
+		{[]string{"deepseek"}, 10},
+		{[]string{"deepsek", "r1"}, 13},
+		{[]string{"deepseek", "reasoning"}, 12},
+		{[]string{"deepseek", "vision"}, 0},
+	}
+	for _, tt := range tests {
+		if got := entry.matchQuery(tt.terms); got != tt.want {
+			t.Errorf("matchQuery(%v) = %v, want %v", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestParseCatalogAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", -1},
+		{"just now", 0},
+		{"yesterday", day},
+		{"an hour ago", time.Hour},
+		{"3 days ago", 3 * day},
+		{"about 2 months ago", 60 * day},
+		{"9 Months ago", 270 * day},
+		{"1 year ago", 365 * day},
+		{"last week", -1},
+		{"some time", -1},
+	}
+	for _, tt := range tests {
+		if got := parseCatalogAge(tt.value); got != tt.want {
+			t.Errorf("parseCatalogAge(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	date := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
+	if got := parseCatalogAge(date); got < 9*day || got > 11*day {
+		t.Errorf("parseCatalogAge(%q) = %v, want about 10 days", date, got)
+	}
+}
+
+func TestSortOnlineModels(t *testing.T) {
+	entries := func() []*onlineModelEntry {
+		return []*onlineModelEntry{
+			{name: "qwen3", pulls: 500, age: 30 * 24 * time.Hour, params: []float64{8, 0.6}, score: 10},
+			{name: "llama3.1", pulls: 900, age: -1, params: []float64{8}, fileSize: 4900000000, score: 8},
+			{name: "gemma3", pulls: 500, age: 7 * 24 * time.Hour, params: []float64{8}, fileSize: 3300000000, score: 11},
+			{name: "phi4", pulls: 100, age: 60 * 24 * time.Hour, params: []float64{14}, score: 8},
+		}
+	}
+	tests := []struct {
+		sortBy    string
+		ascending bool
+		want      []string
+	}{
+		{OnlineSortPulls, false, []string{"llama3.1", "gemma3", "qwen3", "phi4"}},
+		{OnlineSortPulls, true, []string{"phi4", "gemma3", "qwen3", "llama3.1"}},
+		{OnlineSortRelevance, false, []string{"gemma3", "qwen3", "llama3.1", "phi4"}},
+		// 更新时间未知的模型无论排序方向都在最后
+		{OnlineSortUpdated, false, []string{"gemma3", "qwen3", "phi4", "llama3.1"}},
+		{OnlineSortUpdated, true, []string{"phi4", "qwen3", "gemma3", "llama3.1"}},
+		// 按最小版本的参数量排序，相同时按文件大小
+		{OnlineSortSize, false, []string{"qwen3", "gemma3", "llama3.1", "phi4"}},
+		{OnlineSortName, false, []string{"gemma3", "llama3.1", "phi4", "qwen3"}},
+	}
+	for _, tt := range tests {
+		sorted := entries()
+		sortOnlineModels(sorted, tt.sortBy, tt.ascending)
+		var got []string
+		for _, e := range sorted {
+			got = append(got, e.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sortOnlineModels(%s, ascending=%v) = %v, want %v", tt.sortBy, tt.ascending, got, tt.want)
+		}
+	}
+}
+
+func TestOnlineModelFacets(t *testing.T) {
+	models := []map[string]interface{}{
+		{"name": "qwen3", "capabilities": []string{"tools", "thinking"}, "sizes": []string{"0.6b", "8b", "14b"}, "fit_label": ModelFitGPU},
+		{"name": "llama3.2-vision", "capabilities": []interface{}{"vision"}, "sizes": []string{"11b", "90b"}, "fit_label": ModelFitTooLarge},
+		{"name": "qwen2.5", "capabilities": []string{"tools"}, "details": map[string]interface{}{"parameter_size": "7B", "quantization_level": "Q4_K_M"}},
+	}
+	var entries []*onlineModelEntry
+	for _, model := range models {
+		entries = append(entries, newOnlineModelEntry(model))
+	}
+	want := map[string]map[string]int{
+		"capabilities":  {"tools": 2, "thinking": 1, "vision": 1},
+		"families":      {"qwen": 2, "llama": 1},
+		"quantizations": {"Q4_K_M": 1},
+		"params":        {"<3B": 1, "3-9B": 2, "9-15B": 2, ">40B": 1},
+		"fit":           {ModelFitGPU: 1, ModelFitTooLarge: 1},
+	}
+	if got := onlineModelFacets(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("onlineModelFacets = %v, want %v", got, want)
+	}
+}
+
+func TestMatchFilters(t *testing.T) {
+	entry := newOnlineModelEntry(map[string]interface{}{
+		"name":         "qwen3",
+		"capabilities": []string{"tools", "thinking"},
+		"sizes":        []string{"8b", "32b"},
+		"details":      map[string]interface{}{"quantization_level": "Q4_K_M"},
+		"fit_label":    ModelFitCPU,
+	})
+	tests := []struct {
+		name  string
+		query OnlineModelQuery
+		want  bool
+	}{
+		{"没有条件", OnlineModelQuery{}, true},
+		{"能力", OnlineModelQuery{Capabilities: []string{"Tools", "thinking"}}, true},
+		{"缺少能力", OnlineModelQuery{Capabilities: []string{"vision"}}, false},
+		{"有一个版本在参数量范围内", OnlineModelQuery{MinParams: 20, MaxParams: 40}, true},
+		{"参数量范围外", OnlineModelQuery{MinParams: 40}, false},
+		{"量化类型不区分大小写", OnlineModelQuery{Quantization: "q4_k_m"}, true},
+		{"量化类型不同", OnlineModelQuery{Quantization: "Q8_0"}, false},
+		{"系列", OnlineModelQuery{Family: "Qwen"}, true},
+		{"本机可运行", OnlineModelQuery{FitsHardware: true}, true},
+	}
+	for _, tt := range tests {
+		if got := entry.matchFilters(tt.query); got != tt.want {
+			t.Errorf("%s: matchFilters = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreUnsupportedFilters(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantization string
+		facets       map[string]map[string]int
+		want         []string
+		wantQuant    string
+	}{
+		{"没有量化筛选", "", map[string]map[string]int{"quantizations": {}}, []string{}, ""},
+		{"目录没有量化类型", "Q4_K_M", map[string]map[string]int{"quantizations": {}}, []string{"quantization"}, ""},
+		{"目录有量化类型", "Q4_K_M", map[string]map[string]int{"quantizations": {"Q8_0": 1}}, []string{}, "Q4_K_M"},
+	}
+	for _, tt := range tests {
+		q := OnlineModelQuery{Quantization: tt.quantization}
+		got := ignoreUnsupportedFilters(&q, tt.facets)
+		if !reflect.DeepEqual(got, tt.want) || q.Quantization != tt.wantQuant {
+			t.Errorf("%s: ignored = %v, quantization = %q, want %v, %q", tt.name, got, q.Quantization, tt.want, tt.wantQuant)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errModelNotFound 模型在本地不存在
+var errModelNotFound = errors.New("模型不存在")
+
+// OllamaModelCapabilities 模型能力
+type OllamaModelCapabilities struct {
+	Completion bool `json:"completion"`
+	Vision     bool `json:"vision"`
+	Tools      bool `json:"tools"`
+	Embedding  bool `json:"embedding"`
+}
+
+// OllamaModelMetadata /v1/models 响应中的 Ollama 模型扩展信息
+type OllamaModelMetadata struct {
+	Digest            string                   `json:"digest,omitempty"`
+	Size              string                   `json:"size,omitempty"`
+	Format            string                   `json:"format,omitempty"`
+	Family            string                   `json:"family,omitempty"`
+	Families          []string                 `json:"families,omitempty"`
+	ParameterSize     string                   `json:"parameter_size,omitempty"`
+	QuantizationLevel string                   `json:"quantization_level,omitempty"`
+	ContextLength     int64                    `json:"context_length,omitempty"`
+	Capabilities      *OllamaModelCapabilities `json:"capabilities,omitempty"`
+}
+
+// OpenAIErrorResponse OpenAI兼容的错误响应
+type OpenAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code,omitempty"`
+	} `json:"error"`
+}
+
+// writeOpenAIError 写入OpenAI兼容的错误响应
+func writeOpenAIError(w http.ResponseWriter, statusCode int, message, errorType, code string) {
+	var resp OpenAIErrorResponse
+	resp.Error.Message = message
+	resp.Error.Type = errorType
+	resp.Error.Code = code
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseModelCreated 将 Ollama 的 modified_at 转换为 Unix 时间戳，解析失败时返回 0
+func parseModelCreated(modifiedAt string) int64 {
+	if modifiedAt == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339Nano, modifiedAt)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// modelMetadataFromDetails 从 /api/tags 返回的 details 构建扩展信息
+func modelMetadataFromDetails(model ModelInfo) *OllamaModelMetadata {
+	metadata := &OllamaModelMetadata{
+		Digest: model.Digest,
+		Size:   model.Size,
+	}
+	applyModelDetails(metadata, model.Details)
+	return metadata
+}
+
+// applyModelDetails 合并 details 字段中的格式、家族、参数量和量化信息
+func applyModelDetails(metadata *OllamaModelMetadata, details map[string]interface{}) {
+	if details == nil {
+		return
+	}
+	if v := getString(details, "format"); v != "" {
+		metadata.Format = v
+	}
+	if v := getString(details, "family"); v != "" {
+		metadata.Family = v
+	}
+	if v := getString(details, "parameter_size"); v != "" {
+		metadata.ParameterSize = v
+	}
+	if v := getString(details, "quantization_level"); v != "" {
+		metadata.QuantizationLevel = v
+	}
+	if families, ok := details["families"].([]interface{}); ok {
+		metadata.Families = metadata.Families[:0]
+		for _, family := range families {
+			if s, ok := family.(string); ok {
+				metadata.Families = append(metadata.Families, s)
+			}
+		}
+	}
+}
+
+// applyShowMetadata 合并 /api/show 返回的上下文长度和能力信息
+func applyShowMetadata(metadata *OllamaModelMetadata, show map[string]interface{}) {
+	if details, ok := show["details"].(map[string]interface{}); ok {
+		applyModelDetails(metadata, details)
+	}
+
+	modelInfo, _ := show["model_info"].(map[string]interface{})
+	for key, value := range modelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if length, ok := value.(float64); ok {
+				metadata.ContextLength = int64(length)
+			}
+		}
+	}
+
+	capabilities := &OllamaModelCapabilities{}
+	if list, ok := show["capabilities"].([]interface{}); ok {
+		// 新版 Ollama 直接返回能力列表
+		for _, item := range list {
+			switch item {
+			case "completion":
+				capabilities.Completion = true
+			case "vision":
+				capabilities.Vision = true
+			case "tools":
+				capabilities.Tools = true
+			case "embedding":
+				capabilities.Embedding = true
+			}
+		}
+	} else {
+		// 旧版 Ollama 根据投影器、模板和池化配置推断
+		_, hasProjector := show["projector_info"]
+		capabilities.Vision = hasProjector
+		template, _ := show["template"].(string)
+		capabilities.Tools = strings.Contains(template, ".Tools")
+		for key := range modelInfo {
+			if strings.HasSuffix(key, ".pooling_type") {
+				capabilities.Embedding = true
+			}
+		}
+		capabilities.Completion = !capabilities.Embedding
+	}
+	metadata.Capabilities = capabilities
+}
+
+// openAIModelMetadataFor 获取模型的扩展信息，包括 /api/show 中的上下文长度和能力
+// 结果按 digest 缓存，模型列表中的每个模型只在 digest 变化后才重新请求 /api/show
+// 模型已不存在时返回 errModelNotFound，其他原因获取详情失败时返回列表中的基础信息
+func (a *App) openAIModelMetadataFor(model ModelInfo) (*OllamaModelMetadata, error) {
+	if model.Digest != "" {
+		a.openAIModelMutex.Lock()
+		cached, ok := a.openAIModelMetadata[model.Digest]
+		a.openAIModelMutex.Unlock()
+		if ok {
+			copied := *cached
+			return &copied, nil
+		}
+	}
+
+	metadata := modelMetadataFromDetails(model)
+	show, err := a.fetchModelShow(model.Name)
+	if errors.Is(err, errModelNotFound) {
+		return nil, errModelNotFound
+	}
+	if err != nil {
+		log.Printf("[OpenAI API] 获取模型详情失败: %s, %v", model.Name, err)
+		return metadata, nil
+	}
+	applyShowMetadata(metadata, show)
+
+	if model.Digest != "" {
+		a.openAIModelMutex.Lock()
+		if a.openAIModelMetadata == nil {
+			a.openAIModelMetadata = make(map[string]*OllamaModelMetadata)
+		}
+		cached := *metadata
+		a.openAIModelMetadata[model.Digest] = &cached
+		a.openAIModelMutex.Unlock()
+	}
+	return metadata, nil
+}
+
+// lookupOpenAIModel 按模型名称、别名或短digest查找本地模型并返回完整信息
+// 模型不存在时返回 errModelNotFound
+func (a *App) lookupOpenAIModel(modelID string) (*OpenAIModelResponse, error) {
+	models, err := a.fetchLocalModels()
+	if err != nil {
+		return nil, err
+	}
+
+	name := modelID
+	if target, ok := a.getModelRouting().route(modelID); ok {
+		name = target
+	}
+
+	resolved, ok := matchLocalModel(models, name)
+	if !ok {
+		return nil, errModelNotFound
+	}
+
+	var model ModelInfo
+	for _, m := range models {
+		if m.Name == resolved {
+			model = m
+			break
+		}
+	}
+
+	metadata, err := a.openAIModelMetadataFor(model)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &OpenAIModelResponse{
+		ID:      modelID,
+		Object:  "model",
+		Created: parseModelCreated(model.Modified),
+		OwnedBy: "ollama",
+		Ollama:  metadata,
+	}
+	if resolved != modelID {
+		resp.Root = resolved
+	}
+	return resp, nil
+}
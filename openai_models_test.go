@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyShowMetadata(t *testing.T) {
+	tests := []struct {
+		name             string
+		show             map[string]interface{}
+		wantContext      int64
+		wantCapabilities OllamaModelCapabilities
+	}{
+		{
+			name: "能力列表",
+			show: map[string]interface{}{
+				"capabilities": []interface{}{"completion", "vision", "tools"},
+				"model_info":   map[string]interface{}{"gemma3.context_length": float64(131072)},
+			},
+			wantContext:      131072,
+			wantCapabilities: OllamaModelCapabilities{Completion: true, Vision: true, Tools: true},
+		},
+		{
+			name: "旧版按模板推断",
+			show: map[string]interface{}{
+				"template":   "{{ if .Tools }}{{ .Tools }}{{ end }}",
+				"model_info": map[string]interface{}{"llama.context_length": float64(8192)},
+			},
+			wantContext:      8192,
+			wantCapabilities: OllamaModelCapabilities{Completion: true, Tools: true},
+		},
+		{
+			name: "旧版嵌入模型",
+			show: map[string]interface{}{
+				"model_info": map[string]interface{}{"bert.pooling_type": float64(1), "bert.context_length": float64(2048)},
+			},
+			wantContext:      2048,
+			wantCapabilities: OllamaModelCapabilities{Embedding: true},
+		},
+	}
+	for _, tt := range tests {
+		metadata := &OllamaModelMetadata{}
+		applyShowMetadata(metadata, tt.show)
+		if metadata.ContextLength != tt.wantContext {
+			t.Errorf("%s: context_length = %d, want %d", tt.name, metadata.ContextLength, tt.wantContext)
+		}
+		if metadata.Capabilities == nil || *metadata.Capabilities != tt.wantCapabilities {
+			t.Errorf("%s: capabilities = %+v, want %+v", tt.name, metadata.Capabilities, tt.wantCapabilities)
+		}
+	}
+}
+
+func TestOpenAIModelMetadataCache(t *testing.T) {
+	cached := &OllamaModelMetadata{
+		Digest:        "sha256:abc",
+		ContextLength: 40960,
+		Capabilities:  &OllamaModelCapabilities{Completion: true, Tools: true},
+	}
+	app := &App{openAIModelMetadata: map[string]*OllamaModelMetadata{"sha256:abc": cached}}
+
+	// digest 已缓存时不请求 /api/show
+	got, err := app.openAIModelMetadataFor(ModelInfo{Name: "qwen3:8b", Digest: "sha256:abc"})
+	if err != nil {
+		t.Fatalf("openAIModelMetadataFor: %v", err)
+	}
+	if !reflect.DeepEqual(got, cached) {
+		t.Errorf("metadata = %+v, want %+v", got, cached)
+	}
+	got.ContextLength = 1
+	if cached.ContextLength != 40960 {
+		t.Errorf("returned metadata shares the cached entry")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 拉取进度事件的最小发送间隔，避免下载时事件过多
+const pullProgressEmitInterval = 250 * time.Millisecond
+
+// pullUnreachableError Ollama 服务不可达，可以回退到命令行拉取
+type pullUnreachableError struct {
+	err error
+}
+
+func (e *pullUnreachableError) Error() string {
+	return fmt.Sprintf("Ollama 服务不可达: %v", e.err)
+}
+
+// pullStatusLine /api/pull 流式响应中的一行
+type pullStatusLine struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// pullLayerProgress 单个层的下载进度
+type pullLayerProgress struct {
+	Digest    string `json:"digest"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// pullProgressTracker 汇总各层进度，计算总体百分比、下载速度和剩余时间
+type pullProgressTracker struct {
+	layers      map[string]*pullLayerProgress
+	order       []string
+	sampleTime  time.Time
+	sampleBytes int64
+	speed       float64 // 字节/秒，指数移动平均
+	lastEmit    time.Time
+}
+
+func newPullProgressTracker() *pullProgressTracker {
+	return &pullProgressTracker{
+		layers:     make(map[string]*pullLayerProgress),
+		sampleTime: time.Now(),
+	}
+}
+
+// update 记录某一层的最新进度
+func (t *pullProgressTracker) update(digest string, total, completed int64) {
+	layer, ok := t.layers[digest]
+	if !ok {
+		layer = &pullLayerProgress{Digest: digest}
+		t.layers[digest] = layer
+		t.order = append(t.order, digest)
+	}
+	if total > 0 {
+		layer.Total = total
+	}
+	if completed > layer.Completed {
+		layer.Completed = completed
+	}
+
+	// 至少间隔 500ms 采样一次速度
+	now := time.Now()
+	elapsed := now.Sub(t.sampleTime).Seconds()
+	if elapsed >= 0.5 {
+		completedBytes, _ := t.totals()
+		instant := float64(completedBytes-t.sampleBytes) / elapsed
+		if t.speed == 0 {
+			t.speed = instant
+		} else {
+			t.speed = 0.3*instant + 0.7*t.speed
+		}
+		t.sampleTime = now
+		t.sampleBytes = completedBytes
+	}
+}
+
+// totals 所有层已完成和总字节数
+func (t *pullProgressTracker) totals() (completed int64, total int64) {
+	for _, layer := range t.layers {
+		completed += layer.Completed
+		total += layer.Total
+	}
+	return completed, total
+}
+
+// percent 总体进度百分比
+func (t *pullProgressTracker) percent() float64 {
+	completed, total := t.totals()
+	if total <= 0 {
+		return 0
+	}
+	return float64(completed) / float64(total) * 100
+}
+
+// shouldEmit 按时间间隔节流进度事件
+func (t *pullProgressTracker) shouldEmit() bool {
+	if time.Since(t.lastEmit) < pullProgressEmitInterval {
+		return false
+	}
+	t.lastEmit = time.Now()
+	return true
+}
+
+// details 构建进度事件的详情字段
+func (t *pullProgressTracker) details(currentDigest string) map[string]interface{} {
+	completed, total := t.totals()
+
+	layers := make([]pullLayerProgress, 0, len(t.order))
+	for _, digest := range t.order {
+		layers = append(layers, *t.layers[digest])
+	}
+
+	etaSeconds := int64(-1)
+	if t.speed > 0 && total > completed {
+		etaSeconds = int64(float64(total-completed) / t.speed)
+	}
+
+	details := map[string]interface{}{
+		"digest":          currentDigest,
+		"completed_bytes": completed,
+		"total_bytes":     total,
+		"layers":          layers,
+		"speed":           int64(t.speed),
+		"speed_text":      formatBytes(int64(t.speed)) + "/s",
+		"eta_seconds":     etaSeconds,
+	}
+	if layer, ok := t.layers[currentDigest]; ok {
+		details["completed"] = layer.Completed
+		details["total"] = layer.Total
+	}
+	return details
+}
+
+// pullModelViaAPI 通过 Ollama 的流式 /api/pull 拉取模型，按字节报告进度
+// 无法连接服务时返回 *pullUnreachableError，由调用方决定是否回退到命令行
+func (a *App) pullModelViaAPI(ctx context.Context, modelName string) error {
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"model":  modelName,
+		"name":   modelName, // 兼容旧版本 Ollama
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, OllamaAPIBaseURL+"/api/pull", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 拉取大模型可能持续数小时，不设置整体超时，由上下文控制取消
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &pullUnreachableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		message := strings.TrimSpace(string(body))
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		if friendly := a.parsePullError("Error: " + message); friendly != "" {
+			return fmt.Errorf("%s", friendly)
+		}
+		return fmt.Errorf("拉取模型失败 (%d): %s", resp.StatusCode, message)
+	}
+
+	tracker := newPullProgressTracker()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var status pullStatusLine
+		if err := json.Unmarshal([]byte(line), &status); err != nil {
+			log.Printf("pullModelViaAPI: 解析进度失败: %v, 行: %s", err, line)
+			continue
+		}
+
+		if status.Error != "" {
+			if friendly := a.parsePullError("Error: " + status.Error); friendly != "" {
+				return fmt.Errorf("%s", friendly)
+			}
+			return fmt.Errorf("%s", status.Error)
+		}
+
+		switch {
+		case status.Digest != "" && status.Total > 0:
+			tracker.update(status.Digest, status.Total, status.Completed)
+			if tracker.shouldEmit() {
+				completed, total := tracker.totals()
+				message := fmt.Sprintf("%s (%s / %s)", status.Status, formatBytes(completed), formatBytes(total))
+				a.sendPullProgressDetail(modelName, "downloading", tracker.percent(), message, tracker.details(status.Digest))
+			}
+		case status.Status == "success":
+			completed, total := tracker.totals()
+			details := tracker.details("")
+			details["completed_bytes"] = completed
+			details["total_bytes"] = total
+			a.sendPullProgressDetail(modelName, "completed", 100, "模型拉取完成", details)
+			log.Printf("模型拉取完成: %s", modelName)
+			return nil
+		case status.Status != "":
+			// pulling manifest / verifying sha256 digest / writing manifest 等阶段状态
+			a.sendPullProgressDetail(modelName, "status", tracker.percent(), status.Status, tracker.details(""))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("读取拉取进度失败: %v", err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("拉取未完成: 服务端提前结束了响应")
+}
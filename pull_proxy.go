@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 下载限速代理每次读取的数据块大小
+const pullProxyChunkSize = 32 * 1024
+
+// bandwidthLimiter 所有连接共享的下载速率限制器
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	rate int64     // 字节/秒，0 表示不限速
+	next time.Time // 下一块数据允许发送的时间
+}
+
+// setRate 更新速率限制，立即对正在进行的下载生效
+func (l *bandwidthLimiter) setRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.next = time.Time{}
+}
+
+// wait 按速率限制等待，直到可以发送 n 字节
+func (l *bandwidthLimiter) wait(n int) {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / float64(l.rate) * float64(time.Second)))
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// copy 按速率限制从 src 复制数据到 dst
+func (l *bandwidthLimiter) copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := make([]byte, pullProxyChunkSize)
+	var written int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			l.wait(n)
+			m, werr := dst.Write(buf[:n])
+			written += int64(m)
+			if werr != nil {
+				return written, werr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// parseBandwidth 解析带宽配置，例如 "10MB/s"、"512KB"、"1.5M"，空值或 0 表示不限速
+func parseBandwidth(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "/S")
+	s = strings.TrimSuffix(s, "PS")
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	factor := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			factor = unit.factor
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("无效的带宽限制: %s", value)
+	}
+	return int64(number * factor), nil
+}
+
+// getPullBandwidthLimit 获取配置的下载带宽限制（字节/秒）
+func (a *App) getPullBandwidthLimit() int64 {
+	value, ok := a.environmentVariables["OLLAMA_PULL_BANDWIDTH_LIMIT"]
+	if !ok || value == nil {
+		return 0
+	}
+	limit, err := parseBandwidth(fmt.Sprint(value))
+	if err != nil {
+		log.Printf("getPullBandwidthLimit: %v", err)
+		return 0
+	}
+	return limit
+}
+
+// 下载限速代理认证使用的用户名，密码是每次启动生成的令牌
+const pullProxyUser = "ollama"
+
+// pullProxy 本地 HTTP 代理，Ollama 服务通过它访问模型仓库，下载流量在这里限速
+type pullProxy struct {
+	listener  net.Listener
+	limiter   *bandwidthLimiter
+	transport *http.Transport
+	token     string // 每次启动生成，只有带此令牌的请求才能使用代理
+}
+
+// proxyURL 获取带认证信息的代理地址
+func (p *pullProxy) proxyURL() string {
+	return "http://" + pullProxyUser + ":" + p.token + "@" + p.listener.Addr().String()
+}
+
+// authorized 检查 Proxy-Authorization 中的令牌，避免本机其他进程借用代理
+func (p *pullProxy) authorized(r *http.Request) bool {
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(pullProxyUser+":"+p.token))
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Proxy-Authorization")), []byte(want)) == 1
+}
+
+// ServeHTTP 处理 CONNECT 隧道和普通 HTTP 转发
+func (p *pullProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !p.authorized(r) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="ollama-pull-proxy"`)
+		http.Error(w, "代理认证失败", http.StatusProxyAuthRequired)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+
+	if r.URL.Host == "" {
+		http.Error(w, "仅支持代理请求", http.StatusBadRequest)
+		return
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+	outReq.Header.Del("Proxy-Connection")
+	outReq.Header.Del("Proxy-Authorization")
+
+	resp, err := p.transport.RoundTrip(outReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	p.limiter.copy(w, resp.Body)
+}
+
+// serveConnect 建立到目标主机的隧道，下行数据按速率限制转发
+func (p *pullProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	target, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(w, "不支持连接劫持", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		return
+	}
+	client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+
+	go func() {
+		// 客户端在握手后可能已经发送了数据
+		if n := buffered.Reader.Buffered(); n > 0 {
+			data, _ := buffered.Reader.Peek(n)
+			target.Write(data)
+		}
+		io.Copy(target, client)
+		target.Close()
+	}()
+
+	p.limiter.copy(client, target)
+	client.Close()
+	target.Close()
+}
+
+// startPullProxy 启动下载限速代理，监听本机随机端口
+func (a *App) startPullProxy() {
+	// 用户已经配置了代理时不覆盖，限速不可用
+	if a.getConfigString("HTTPS_PROXY", os.Getenv("HTTPS_PROXY")) != "" {
+		log.Println("startPullProxy: 已配置 HTTPS_PROXY，下载限速代理未启用")
+		return
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("startPullProxy: 生成代理令牌失败: %v", err)
+		return
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Printf("startPullProxy: 启动下载限速代理失败: %v", err)
+		return
+	}
+
+	limiter := &bandwidthLimiter{}
+	limiter.setRate(a.getPullBandwidthLimit())
+	a.pullProxy = &pullProxy{
+		listener: listener,
+		limiter:  limiter,
+		token:    hex.EncodeToString(buf),
+		transport: &http.Transport{
+			Proxy:                 nil,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+
+	go func() {
+		if err := http.Serve(listener, a.pullProxy); err != nil {
+			log.Printf("startPullProxy: 下载限速代理已停止: %v", err)
+		}
+	}()
+	log.Printf("startPullProxy: 下载限速代理已启动: %s", listener.Addr())
+}
+
+// pullProxyEnv 获取 Ollama 服务需要的代理环境变量，代理未启动时返回空
+func (a *App) pullProxyEnv() []string {
+	if a.pullProxy == nil {
+		return nil
+	}
+	proxyURL := a.pullProxy.proxyURL()
+	return []string{
+		"HTTPS_PROXY=" + proxyURL,
+		"HTTP_PROXY=" + proxyURL,
+		"NO_PROXY=localhost,127.0.0.1,::1",
+	}
+}
+
+// pullThrottleStatus 检查下载限速是否实际生效，未生效时返回原因。
+// 只有本应用启动的 Ollama 服务才带有限速代理的环境变量
+func (a *App) pullThrottleStatus() (bool, string) {
+	if a.pullProxy == nil {
+		if a.getConfigString("HTTPS_PROXY", os.Getenv("HTTPS_PROXY")) != "" {
+			return false, "已配置 HTTPS_PROXY，下载流量不经过限速代理"
+		}
+		return false, "下载限速代理未运行，限速只对桌面应用启动的 Ollama 服务生效"
+	}
+	if a.ollamaCmd == nil || !containsString(a.ollamaCmd.Env, "HTTPS_PROXY="+a.pullProxy.proxyURL()) {
+		return false, "Ollama 服务不是由本应用启动的，下载流量不经过限速代理，请在应用中重启服务"
+	}
+	return true, ""
+}
+
+// applyPullBandwidthLimit 使新的带宽限制对正在进行的下载生效
+func (a *App) applyPullBandwidthLimit() {
+	if a.pullProxy == nil {
+		return
+	}
+	limit := a.getPullBandwidthLimit()
+	a.pullProxy.limiter.setRate(limit)
+	if limit > 0 {
+		log.Printf("applyPullBandwidthLimit: 下载限速 %s/s", formatBytes(limit))
+	} else {
+		log.Println("applyPullBandwidthLimit: 下载不限速")
+	}
+}
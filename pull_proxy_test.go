@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"testing"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"  ", 0, false},
+		{"1024", 1024, false},
+		{"512B", 512, false},
+		{"512KB", 512 << 10, false},
+		{"512kb/s", 512 << 10, false},
+		{"10MB/s", 10 << 20, false},
+		{"10 MBps", 10 << 20, false},
+		{"1.5M", 3 << 19, false},
+		{"2MiB", 2 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"1.5g", 3 << 29, false},
+		{"9.3GB", 9985798963, false},
+		{"-1MB", 0, true},
+		{"abc", 0, true},
+		{"MB", 0, true},
+		{"10TB", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBandwidth(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBandwidth(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBandwidth(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func newTestPullProxy(t *testing.T) *pullProxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pullProxy{
+		listener:  listener,
+		limiter:   &bandwidthLimiter{},
+		transport: &http.Transport{},
+		token:     "token",
+	}
+	go http.Serve(listener, p)
+	t.Cleanup(func() { listener.Close() })
+	return p
+}
+
+func TestPullProxyAuthorization(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Error("Proxy-Authorization forwarded to upstream")
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	p := newTestPullProxy(t)
+	addr := p.listener.Addr().String()
+
+	tests := []struct {
+		name     string
+		proxyURL string
+		want     int
+	}{
+		{"带令牌", p.proxyURL(), http.StatusOK},
+		{"没有令牌", "http://" + addr, http.StatusProxyAuthRequired},
+		{"令牌错误", "http://" + pullProxyUser + ":wrong@" + addr, http.StatusProxyAuthRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxyURL, _ := url.Parse(tt.proxyURL)
+			client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+			resp, err := client.Get(upstream.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	// 没有令牌的 CONNECT 不建立隧道
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", upstream.Listener.Addr(), upstream.Listener.Addr())
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Errorf("CONNECT status = %d, want %d", resp.StatusCode, http.StatusProxyAuthRequired)
+	}
+}
+
+func TestPullThrottleStatus(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "")
+	p := newTestPullProxy(t)
+
+	tests := []struct {
+		name string
+		app  *App
+		want bool
+	}{
+		{"代理未运行", &App{}, false},
+		{"已配置 HTTPS_PROXY", &App{environmentVariables: map[string]interface{}{"HTTPS_PROXY": "http://proxy:8080"}}, false},
+		{"服务不是由本应用启动", &App{pullProxy: p}, false},
+		{"服务启动于代理之前", &App{pullProxy: p, ollamaCmd: &exec.Cmd{Env: []string{"PATH=/bin"}}}, false},
+		{"服务使用限速代理", &App{pullProxy: p, ollamaCmd: &exec.Cmd{Env: []string{"HTTPS_PROXY=" + p.proxyURL()}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, reason := tt.app.pullThrottleStatus()
+			if active != tt.want || (reason == "") != tt.want {
+				t.Errorf("pullThrottleStatus() = %v, %q, want active %v", active, reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// PullJobState 拉取任务状态
+type PullJobState string
+
+const (
+	PullJobQueued    PullJobState = "queued"
+	PullJobRunning   PullJobState = "running"
+	PullJobPaused    PullJobState = "paused"
+	PullJobCompleted PullJobState = "completed"
+	PullJobFailed    PullJobState = "failed"
+	PullJobCancelled PullJobState = "cancelled"
+)
+
+// 队列中最多保留的已结束任务数
+const maxFinishedPullJobs = 50
+
+// PullJobEvent 拉取任务的一次状态变化
+type PullJobEvent struct {
+	State   PullJobState `json:"state"`
+	Message string       `json:"message,omitempty"`
+	Time    time.Time    `json:"time"`
+}
+
+// PullJob 拉取队列中的任务
+type PullJob struct {
+	ID        string         `json:"id"`
+	Model     string         `json:"model"`
+	State     PullJobState   `json:"state"`
+	Progress  float64        `json:"progress"`
+	Error     string         `json:"error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	History   []PullJobEvent `json:"history"`
+}
+
+// finished 任务是否已经结束
+func (j *PullJob) finished() bool {
+	return j.State == PullJobCompleted || j.State == PullJobFailed || j.State == PullJobCancelled
+}
+
+// setState 更新任务状态并记录历史
+func (j *PullJob) setState(state PullJobState, message string) {
+	now := time.Now()
+	j.State = state
+	j.UpdatedAt = now
+	j.History = append(j.History, PullJobEvent{State: state, Message: message, Time: now})
+}
+
+// clone 复制任务，避免调用方修改队列中的数据
+func (j *PullJob) clone() PullJob {
+	c := *j
+	c.History = append([]PullJobEvent(nil), j.History...)
+	return c
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免写入中断导致文件损坏
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// getPullQueuePath 获取拉取队列持久化文件路径
+func (a *App) getPullQueuePath() string {
+	return filepath.Join(a.getConfigDir(), "pull_queue.json")
+}
+
+// loadPullQueue 加载持久化的拉取队列，上次中断的任务重新排队并自动恢复
+func (a *App) loadPullQueue() {
+	data, err := os.ReadFile(a.getPullQueuePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("loadPullQueue: 读取拉取队列失败: %v", err)
+		}
+		return
+	}
+
+	var jobs []*PullJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		log.Printf("loadPullQueue: 解析拉取队列失败: %v", err)
+		return
+	}
+
+	resumed := 0
+	for _, job := range jobs {
+		if job.State == PullJobRunning {
+			job.setState(PullJobQueued, "应用重启，任务已恢复")
+			resumed++
+		}
+	}
+
+	a.pullProcessesMutex.Lock()
+	a.pullJobs = jobs
+	a.savePullQueueLocked()
+	a.pullProcessesMutex.Unlock()
+
+	log.Printf("loadPullQueue: 已加载 %d 个拉取任务，恢复 %d 个中断的任务", len(jobs), resumed)
+	a.schedulePullJobs()
+}
+
+// savePullQueueLocked 持久化拉取队列，调用方需持有 pullProcessesMutex
+func (a *App) savePullQueueLocked() {
+	data, err := json.MarshalIndent(a.pullJobs, "", "  ")
+	if err != nil {
+		log.Printf("savePullQueue: 序列化拉取队列失败: %v", err)
+		return
+	}
+	if err := writeFileAtomic(a.getPullQueuePath(), data, 0644); err != nil {
+		log.Printf("savePullQueue: 写入拉取队列失败: %v", err)
+	}
+}
+
+// prunePullJobsLocked 只保留最近的已结束任务
+func (a *App) prunePullJobsLocked() {
+	finished := 0
+	for _, job := range a.pullJobs {
+		if job.finished() {
+			finished++
+		}
+	}
+	if finished <= maxFinishedPullJobs {
+		return
+	}
+
+	remove := finished - maxFinishedPullJobs
+	jobs := a.pullJobs[:0]
+	for _, job := range a.pullJobs {
+		if remove > 0 && job.finished() {
+			remove--
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	a.pullJobs = jobs
+}
+
+// findPullJobLocked 按任务ID或模型名称查找任务，按模型名称查找时只匹配未结束的任务
+func (a *App) findPullJobLocked(ref string) *PullJob {
+	for _, job := range a.pullJobs {
+		if job.ID == ref {
+			return job
+		}
+	}
+
+	modelName := a.normalizeModelName(ref)
+	for i := len(a.pullJobs) - 1; i >= 0; i-- {
+		job := a.pullJobs[i]
+		if job.Model == modelName && !job.finished() {
+			return job
+		}
+	}
+	return nil
+}
+
+// snapshotPullJobsLocked 复制当前队列
+func (a *App) snapshotPullJobsLocked() []PullJob {
+	jobs := make([]PullJob, 0, len(a.pullJobs))
+	for _, job := range a.pullJobs {
+		jobs = append(jobs, job.clone())
+	}
+	return jobs
+}
+
+// emitPullQueueUpdated 通知前端拉取队列已变化
+func (a *App) emitPullQueueUpdated(jobs []PullJob) {
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "pull_queue_updated", jobs)
+	}
+}
+
+// enqueuePullJob 将模型加入拉取队列，已在队列中时返回已有任务
+func (a *App) enqueuePullJob(modelName string) PullJob {
+	a.pullProcessesMutex.Lock()
+	job := a.findPullJobLocked(modelName)
+	if job != nil {
+		if job.State == PullJobPaused {
+			job.setState(PullJobQueued, "重新加入队列")
+		}
+	} else {
+		job = &PullJob{
+			ID:        fmt.Sprintf("pull-%d", time.Now().UnixNano()),
+			Model:     modelName,
+			CreatedAt: time.Now(),
+		}
+		job.setState(PullJobQueued, "加入队列")
+		a.pullJobs = append(a.pullJobs, job)
+	}
+	result := job.clone()
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	log.Printf("enqueuePullJob: %s (%s) 状态: %s", modelName, result.ID, result.State)
+	a.emitPullQueueUpdated(jobs)
+	a.schedulePullJobs()
+	return result
+}
+
+// schedulePullJobs 按队列顺序启动任务，直到达到并发上限
+// 不在允许下载的时段内时任务保持排队
+func (a *App) schedulePullJobs() {
+	if !a.pullAllowedNow() {
+		return
+	}
+
+	limit := a.getConfigInt("OLLAMA_PULL_CONCURRENCY", 2)
+	if limit < 1 {
+		limit = 1
+	}
+
+	a.pullProcessesMutex.Lock()
+	running := 0
+	for _, job := range a.pullJobs {
+		if job.State == PullJobRunning {
+			running++
+		}
+	}
+
+	started := false
+	for _, job := range a.pullJobs {
+		if running >= limit {
+			break
+		}
+		if job.State != PullJobQueued {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		a.pullCancels[job.ID] = cancel
+		job.Error = ""
+		job.setState(PullJobRunning, "开始下载")
+		go a.runPullJob(ctx, job.ID, job.Model)
+		running++
+		started = true
+	}
+
+	if !started {
+		a.pullProcessesMutex.Unlock()
+		return
+	}
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	a.emitPullQueueUpdated(jobs)
+}
+
+// runPullJob 执行拉取任务并在结束后记录结果
+func (a *App) runPullJob(ctx context.Context, jobID, modelName string) {
+	err := a.pullModelWithProgress(ctx, modelName)
+
+	a.pullProcessesMutex.Lock()
+	if ctx.Err() != nil {
+		// 任务被暂停、取消或应用退出中断，状态已由对应操作更新，
+		// 应用退出时中断的任务保持运行状态，下次启动时恢复
+		a.pullProcessesMutex.Unlock()
+		return
+	}
+	if cancel, ok := a.pullCancels[jobID]; ok {
+		cancel()
+		delete(a.pullCancels, jobID)
+	}
+
+	job := a.findPullJobLocked(jobID)
+	if job == nil || job.State != PullJobRunning {
+		a.pullProcessesMutex.Unlock()
+		a.schedulePullJobs()
+		return
+	}
+
+	if err == nil {
+		job.Progress = 100
+		job.setState(PullJobCompleted, "下载完成")
+	} else {
+		job.Error = err.Error()
+		job.setState(PullJobFailed, err.Error())
+	}
+	a.prunePullJobsLocked()
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	a.emitPullQueueUpdated(jobs)
+	a.schedulePullJobs()
+}
+
+// transitionPullJob 暂停、恢复或取消任务
+func (a *App) transitionPullJob(ref string, target PullJobState, message string) (PullJob, error) {
+	a.pullProcessesMutex.Lock()
+	job := a.findPullJobLocked(ref)
+	if job == nil {
+		a.pullProcessesMutex.Unlock()
+		return PullJob{}, fmt.Errorf("未找到拉取任务: %s", ref)
+	}
+
+	allowed := false
+	switch target {
+	case PullJobPaused:
+		allowed = job.State == PullJobQueued || job.State == PullJobRunning
+	case PullJobQueued:
+		allowed = job.State == PullJobPaused || job.State == PullJobFailed || job.State == PullJobCancelled
+	case PullJobCancelled:
+		allowed = !job.finished()
+	}
+	if !allowed {
+		a.pullProcessesMutex.Unlock()
+		return PullJob{}, fmt.Errorf("任务 %s 当前状态为 %s，无法执行该操作", job.Model, job.State)
+	}
+
+	// 中断正在进行的下载，Ollama 会保留已下载的分片，恢复后继续下载
+	if cancel, ok := a.pullCancels[job.ID]; ok {
+		cancel()
+		delete(a.pullCancels, job.ID)
+	}
+	if target == PullJobQueued {
+		job.Error = ""
+	}
+	job.setState(target, message)
+
+	result := job.clone()
+	a.prunePullJobsLocked()
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	a.emitPullQueueUpdated(jobs)
+	a.schedulePullJobs()
+	return result, nil
+}
+
+// updatePullJobProgress 记录正在运行的任务的下载进度
+func (a *App) updatePullJobProgress(modelName string, progress float64) {
+	a.pullProcessesMutex.Lock()
+	defer a.pullProcessesMutex.Unlock()
+
+	for _, job := range a.pullJobs {
+		if job.Model == modelName && job.State == PullJobRunning && progress > job.Progress {
+			job.Progress = progress
+		}
+	}
+}
+
+// stopPullQueue 应用退出时中断正在进行的下载，任务状态保留以便下次启动恢复
+func (a *App) stopPullQueue() {
+	a.pullProcessesMutex.Lock()
+	defer a.pullProcessesMutex.Unlock()
+
+	for id, cancel := range a.pullCancels {
+		cancel()
+		delete(a.pullCancels, id)
+	}
+}
+
+// ListPullJobs 获取拉取队列中的所有任务及其状态历史
+func (a *App) ListPullJobs() []PullJob {
+	a.pullProcessesMutex.Lock()
+	defer a.pullProcessesMutex.Unlock()
+	return a.snapshotPullJobsLocked()
+}
+
+// PausePull 暂停拉取任务，参数可以是模型名称或任务ID
+func (a *App) PausePull(ref string) map[string]interface{} {
+	job, err := a.transitionPullJob(ref, PullJobPaused, "用户暂停")
+	if err != nil {
+		log.Printf("PausePull: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+
+	a.sendPullProgressEvent(job.Model, "paused", job.Progress, "模型拉取已暂停")
+	return map[string]interface{}{
+		"success": true,
+		"message": "模型拉取已暂停",
+		"job":     job,
+	}
+}
+
+// ResumePull 恢复已暂停、失败或取消的拉取任务
+func (a *App) ResumePull(ref string) map[string]interface{} {
+	job, err := a.transitionPullJob(ref, PullJobQueued, "用户恢复")
+	if err != nil {
+		log.Printf("ResumePull: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"message": "模型拉取已恢复",
+		"job":     job,
+	}
+}
+
+// MovePullJob 调整任务在队列中的位置（从 0 开始），只影响尚未开始的任务的启动顺序
+func (a *App) MovePullJob(ref string, position int) map[string]interface{} {
+	a.pullProcessesMutex.Lock()
+	index := -1
+	if job := a.findPullJobLocked(ref); job != nil {
+		for i, j := range a.pullJobs {
+			if j == job {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		a.pullProcessesMutex.Unlock()
+		return map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("未找到拉取任务: %s", ref),
+		}
+	}
+
+	if position < 0 {
+		position = 0
+	}
+	if position >= len(a.pullJobs) {
+		position = len(a.pullJobs) - 1
+	}
+
+	job := a.pullJobs[index]
+	a.pullJobs = append(a.pullJobs[:index], a.pullJobs[index+1:]...)
+	a.pullJobs = append(a.pullJobs[:position], append([]*PullJob{job}, a.pullJobs[position:]...)...)
+
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	a.emitPullQueueUpdated(jobs)
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("已将 %s 移动到第 %d 位", job.Model, position+1),
+	}
+}
+
+// ClearFinishedPullJobs 清除已完成、失败和取消的任务
+func (a *App) ClearFinishedPullJobs() map[string]interface{} {
+	a.pullProcessesMutex.Lock()
+	removed := 0
+	jobs := a.pullJobs[:0]
+	for _, job := range a.pullJobs {
+		if job.finished() {
+			removed++
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	a.pullJobs = jobs
+	a.savePullQueueLocked()
+	snapshot := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	a.emitPullQueueUpdated(snapshot)
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("已清除 %d 个已结束的任务", removed),
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 下载时段检查间隔
+const pullScheduleCheckInterval = 30 * time.Second
+
+// pullWindow 每天允许下载的时段，以当天的分钟数表示，End 小于 Start 时表示跨越午夜
+type pullWindow struct {
+	Start int
+	End   int
+}
+
+// contains 判断某个时刻是否在时段内
+func (w pullWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return minute >= w.Start && minute < w.End
+	default:
+		return minute >= w.Start || minute < w.End
+	}
+}
+
+// parseClock 解析 "19:00" 格式的时间
+func parseClock(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	return (hour*60 + minute) % (24 * 60), nil
+}
+
+// parsePullSchedule 解析下载时段配置，例如 "19:00-07:00" 或 "12:00-13:30,19:00-07:00"
+// 空值表示任何时间都可以下载
+func parsePullSchedule(value string) ([]pullWindow, error) {
+	var windows []pullWindow
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("无效的下载时段: %s，格式应为 HH:MM-HH:MM", part)
+		}
+		start, err := parseClock(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, pullWindow{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+// inPullWindow 判断某个时刻是否允许下载
+func inPullWindow(windows []pullWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextPullWindowStart 获取下一个下载时段的开始时间
+func nextPullWindowStart(windows []pullWindow, now time.Time) time.Time {
+	var next time.Time
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, w := range windows {
+		start := midnight.Add(time.Duration(w.Start) * time.Minute)
+		if !start.After(now) {
+			start = start.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next
+}
+
+// getPullSchedule 获取配置的下载时段，配置无效时不限制时段
+func (a *App) getPullSchedule() []pullWindow {
+	windows, err := parsePullSchedule(a.getConfigString("OLLAMA_PULL_SCHEDULE", ""))
+	if err != nil {
+		log.Printf("getPullSchedule: %v", err)
+		return nil
+	}
+	return windows
+}
+
+// pullAllowedNow 当前是否在允许下载的时段内
+func (a *App) pullAllowedNow() bool {
+	return inPullWindow(a.getPullSchedule(), time.Now())
+}
+
+// startPullScheduler 定期检查下载时段，时段结束时暂停正在进行的下载，时段开始时继续
+func (a *App) startPullScheduler() {
+	go func() {
+		ticker := time.NewTicker(pullScheduleCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if a.pullAllowedNow() {
+				a.schedulePullJobs()
+			} else {
+				a.suspendRunningPullJobs("不在下载时段内，等待下一时段")
+			}
+		}
+	}()
+}
+
+// suspendRunningPullJobs 中断正在进行的下载并重新排队，等待下一个下载时段
+func (a *App) suspendRunningPullJobs(message string) {
+	a.pullProcessesMutex.Lock()
+	var suspended []string
+	for _, job := range a.pullJobs {
+		if job.State != PullJobRunning {
+			continue
+		}
+		if cancel, ok := a.pullCancels[job.ID]; ok {
+			cancel()
+			delete(a.pullCancels, job.ID)
+		}
+		job.setState(PullJobQueued, message)
+		suspended = append(suspended, job.Model)
+	}
+	if len(suspended) == 0 {
+		a.pullProcessesMutex.Unlock()
+		return
+	}
+	a.savePullQueueLocked()
+	jobs := a.snapshotPullJobsLocked()
+	a.pullProcessesMutex.Unlock()
+
+	for _, model := range suspended {
+		a.sendPullProgressEvent(model, "waiting", 0, message)
+	}
+	log.Printf("suspendRunningPullJobs: 已暂停 %d 个下载任务", len(suspended))
+	a.emitPullQueueUpdated(jobs)
+}
+
+// GetPullSchedule 获取下载限速和时段设置及当前状态
+func (a *App) GetPullSchedule() map[string]interface{} {
+	schedule := a.getConfigString("OLLAMA_PULL_SCHEDULE", "")
+	windows, err := parsePullSchedule(schedule)
+	limit := a.getPullBandwidthLimit()
+
+	result := map[string]interface{}{
+		"schedule":        schedule,
+		"bandwidth_limit": limit,
+		"bandwidth_text":  "不限速",
+		"allowed_now":     inPullWindow(windows, time.Now()),
+		"proxy_enabled":   a.pullProxy != nil,
+		"throttle_status": "active",
+	}
+	if limit > 0 {
+		result["bandwidth_text"] = formatBytes(limit) + "/s"
+	}
+	if active, reason := a.pullThrottleStatus(); !active {
+		result["throttle_status"] = "inactive"
+		result["throttle_reason"] = reason
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	} else if len(windows) > 0 && !inPullWindow(windows, time.Now()) {
+		result["next_window"] = nextPullWindowStart(windows, time.Now()).Format(time.RFC3339)
+	}
+	return result
+}
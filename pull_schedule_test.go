@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"00:00", 0, false},
+		{"07:30", 7*60 + 30, false},
+		{" 19:00 ", 19 * 60, false},
+		{"7:05", 7*60 + 5, false},
+		{"24:00", 0, false},
+		{"24:01", 0, true},
+		{"25:00", 0, true},
+		{"12:60", 0, true},
+		{"-1:00", 0, true},
+		{"12", 0, true},
+		{"12:00:00", 0, true},
+		{"ab:cd", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseClock(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseClock(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseClock(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParsePullSchedule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []pullWindow
+		wantErr bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		{"19:00-07:00", []pullWindow{{19 * 60, 7 * 60}}, false},
+		{"12:00-13:30, 19:00-24:00", []pullWindow{{12 * 60, 13*60 + 30}, {19 * 60, 0}}, false},
+		{"19:00", nil, true},
+		{"19:00-07:00-08:00", nil, true},
+		{"19:00-25:00", nil, true},
+		{"12:00-13:00,晚上", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePullSchedule(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePullSchedule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parsePullSchedule(%q) = %v, want %v", tt.value, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parsePullSchedule(%q) = %v, want %v", tt.value, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestInPullWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.Local)
+	}
+	overnight := []pullWindow{{19 * 60, 7 * 60}}
+	lunch := []pullWindow{{12 * 60, 13*60 + 30}}
+	tests := []struct {
+		name    string
+		windows []pullWindow
+		t       time.Time
+		want    bool
+	}{
+		{"不限时段", nil, at(10, 0), true},
+		{"跨午夜的开始", overnight, at(19, 0), true},
+		{"跨午夜的午夜后", overnight, at(3, 0), true},
+		{"跨午夜的结束不包含", overnight, at(7, 0), false},
+		{"跨午夜的白天", overnight, at(12, 0), false},
+		{"当天时段内", lunch, at(12, 45), true},
+		{"当天时段后", lunch, at(13, 30), false},
+		{"开始等于结束表示全天", []pullWindow{{8 * 60, 8 * 60}}, at(2, 0), true},
+		{"多个时段", append(lunch, overnight...), at(23, 59), true},
+	}
+	for _, tt := range tests {
+		if got := inPullWindow(tt.windows, tt.t); got != tt.want {
+			t.Errorf("%s: inPullWindow(%v, %s) = %v, want %v", tt.name, tt.windows, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestNextPullWindowStart(t *testing.T) {
+	windows := []pullWindow{{12 * 60, 13 * 60}, {19 * 60, 7 * 60}}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local), time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)},
+		{time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local), time.Date(2026, 10, 19, 19, 0, 0, 0, time.Local)},
+		{time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local), time.Date(2026, 10, 20, 12, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		if got := nextPullWindowStart(windows, tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextPullWindowStart(%s) = %s, want %s", tt.now.Format(time.DateTime), got.Format(time.DateTime), tt.want.Format(time.DateTime))
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCacheEntry 缓存的聊天响应
+type responseCacheEntry struct {
+	Key       string    `json:"key"`
+	Model     string    `json:"model"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+// ResponseCacheStats 响应缓存统计
+type ResponseCacheStats struct {
+	Enabled     bool    `json:"enabled"`
+	Entries     int     `json:"entries"`
+	MaxEntries  int     `json:"max_entries"`
+	TTLSeconds  int64   `json:"ttl_seconds"`
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	Bypasses    uint64  `json:"bypasses"`
+	Stores      uint64  `json:"stores"`
+	Evictions   uint64  `json:"evictions"`
+	Expirations uint64  `json:"expirations"`
+	HitRate     float64 `json:"hit_rate"`
+}
+
+// responseCache 基于内容寻址的响应缓存
+// 内存中按 LRU 淘汰，每个条目同时持久化为缓存目录下的一个 JSON 文件
+type responseCache struct {
+	mu          sync.Mutex
+	dir         string
+	maxEntries  int
+	ttl         time.Duration
+	order       *list.List
+	entries     map[string]*list.Element
+	hits        uint64
+	misses      uint64
+	bypasses    uint64
+	stores      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+// newResponseCache 创建响应缓存并从磁盘恢复已有条目
+func newResponseCache(dir string, maxEntries int, ttl time.Duration) *responseCache {
+	c := &responseCache{
+		dir:        dir,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("responseCache: 创建缓存目录失败: %v", err)
+		return c
+	}
+	c.loadFromDisk()
+	return c
+}
+
+// loadFromDisk 从磁盘加载缓存条目，按最近使用时间恢复 LRU 顺序
+func (c *responseCache) loadFromDisk() {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+
+	var loaded []*responseCacheEntry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry responseCacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Key == "" {
+			os.Remove(file)
+			continue
+		}
+		if c.isExpired(&entry) {
+			os.Remove(file)
+			continue
+		}
+		loaded = append(loaded, &entry)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].LastUsed.Before(loaded[j].LastUsed)
+	})
+	for _, entry := range loaded {
+		c.entries[entry.Key] = c.order.PushFront(entry)
+	}
+	c.evictOverflow()
+
+	log.Printf("responseCache: 从磁盘加载 %d 个缓存条目", len(c.entries))
+}
+
+// setLimits 更新容量和过期时间，容量缩小时立即淘汰
+func (c *responseCache) setLimits(maxEntries int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxEntries = maxEntries
+	c.ttl = ttl
+	c.evictOverflow()
+}
+
+// get 查找缓存条目，过期条目会被删除
+func (c *responseCache) get(key string) (*responseCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*responseCacheEntry)
+	if c.isExpired(entry) {
+		c.removeElement(element)
+		c.expirations++
+		c.misses++
+		return nil, false
+	}
+
+	entry.LastUsed = time.Now()
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry, true
+}
+
+// put 写入缓存条目并持久化到磁盘
+func (c *responseCache) put(entry *responseCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[entry.Key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[entry.Key] = c.order.PushFront(entry)
+	}
+	c.stores++
+
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.WriteFile(c.entryPath(entry.Key), data, 0644)
+	}
+	if err != nil {
+		log.Printf("responseCache: 写入缓存文件失败: %v", err)
+	}
+
+	c.evictOverflow()
+}
+
+// recordBypass 记录一次被请求头绕过的缓存查询
+func (c *responseCache) recordBypass() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bypasses++
+}
+
+// clear 清空内存和磁盘中的所有缓存条目
+func (c *responseCache) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := len(c.entries)
+	for c.order.Len() > 0 {
+		c.removeElement(c.order.Back())
+	}
+	return count
+}
+
+// stats 返回缓存统计信息
+func (c *responseCache) stats() ResponseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := ResponseCacheStats{
+		Entries:     len(c.entries),
+		MaxEntries:  c.maxEntries,
+		TTLSeconds:  int64(c.ttl.Seconds()),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Bypasses:    c.bypasses,
+		Stores:      c.stores,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// evictOverflow 淘汰超出容量的最久未使用条目（调用方需持有锁）
+func (c *responseCache) evictOverflow() {
+	if c.maxEntries <= 0 {
+		return
+	}
+	for len(c.entries) > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+// removeElement 从内存和磁盘删除条目（调用方需持有锁）
+func (c *responseCache) removeElement(element *list.Element) {
+	entry := element.Value.(*responseCacheEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.Key)
+	os.Remove(c.entryPath(entry.Key))
+}
+
+// isExpired 判断条目是否已过期
+func (c *responseCache) isExpired(entry *responseCacheEntry) bool {
+	return c.ttl > 0 && time.Since(entry.CreatedAt) > c.ttl
+}
+
+// entryPath 缓存条目的磁盘路径
+func (c *responseCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// getResponseCache 获取网关响应缓存，首次调用时初始化，每次调用同步最新配置
+func (a *App) getResponseCache() *responseCache {
+	maxEntries := a.getConfigInt("OLLAMA_GATEWAY_CACHE_MAX_ENTRIES", 1000)
+	ttl := time.Duration(a.getConfigInt("OLLAMA_GATEWAY_CACHE_TTL", 86400)) * time.Second
+
+	a.responseCacheOnce.Do(func() {
+		dir := filepath.Join(a.getConfigDir(), "cache", "responses")
+		a.responseCache = newResponseCache(dir, maxEntries, ttl)
+	})
+	a.responseCache.setLimits(maxEntries, ttl)
+	return a.responseCache
+}
+
+// isDeterministicChatRequest 判断请求结果是否可复现（temperature 为 0 或指定了 seed）
+func isDeterministicChatRequest(req OpenAIChatRequest) bool {
+	if req.Temperature != nil && *req.Temperature == 0 {
+		return true
+	}
+	return req.Seed != nil
+}
+
+// parseCacheControl 解析请求的 Cache-Control 头
+// no-cache / max-age=0 跳过缓存读取但仍写入，no-store 既不读取也不写入
+func parseCacheControl(header string) (noCache bool, noStore bool) {
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "max-age=0":
+			noCache = true
+		case directive == "no-store":
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
+
+// responseCacheKey 根据模型 digest、消息和选项计算缓存键
+func responseCacheKey(digest string, messages []ChatMessage, options interface{}) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"digest":   digest,
+		"messages": messages,
+		"options":  options,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lookupModelDigest 查找本地模型的 digest，未找到时返回空字符串
+// 本地模型的 digest 就是清单文件的 sha256，优先直接读取清单，避免每次请求都获取完整的模型列表
+func (a *App) lookupModelDigest(modelName string) string {
+	if name, err := validateModelName(modelName); err == nil {
+		if data, err := os.ReadFile(a.manifestPath(name)); err == nil {
+			return sha256Hex(data)
+		}
+	}
+
+	candidates := []string{modelName}
+	if !strings.Contains(modelName, ":") {
+		candidates = append(candidates, modelName+":latest")
+	}
+
+	for _, model := range a.ListModels() {
+		for _, candidate := range candidates {
+			if model.Name == candidate || model.Model == candidate {
+				return model.Digest
+			}
+		}
+	}
+	return ""
+}
+
+// serveFromResponseCache 尝试用缓存响应请求
+// 返回缓存键、上游响应完成后是否应写入缓存，以及请求是否已由缓存处理
+func (a *App) serveFromResponseCache(w http.ResponseWriter, r *http.Request, req OpenAIChatRequest, ollamaReq ChatRequest) (string, bool, bool) {
+	if !a.getConfigBool("OLLAMA_GATEWAY_CACHE_ENABLED", false) || !isDeterministicChatRequest(req) {
+		return "", false, false
+	}
+
+	digest := a.lookupModelDigest(ollamaReq.Model)
+	if digest == "" {
+		log.Printf("[OpenAI API] 未找到模型digest，跳过响应缓存: %s", ollamaReq.Model)
+		return "", false, false
+	}
+
+	key, err := responseCacheKey(digest, ollamaReq.Messages, ollamaReq.Options)
+	if err != nil {
+		log.Printf("[OpenAI API] 计算缓存键失败: %v", err)
+		return "", false, false
+	}
+
+	cache := a.getResponseCache()
+	noCache, noStore := parseCacheControl(r.Header.Get("Cache-Control"))
+	if noCache || noStore {
+		cache.recordBypass()
+		w.Header().Set("X-Cache", "BYPASS")
+		return key, !noStore, false
+	}
+
+	entry, ok := cache.get(key)
+	if !ok {
+		w.Header().Set("X-Cache", "MISS")
+		return key, true, false
+	}
+
+	log.Printf("[OpenAI API] 命中响应缓存: 模型=%s, 键=%s", ollamaReq.Model, key[:12])
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("Age", strconv.FormatInt(int64(time.Since(entry.CreatedAt).Seconds()), 10))
+
+	if req.Stream {
+		replayCachedStream(w, ollamaReq.Model, entry.Content)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newOpenAIChatResponse(ollamaReq.Model, "assistant", entry.Content))
+	}
+	return key, false, true
+}
+
+// storeResponseCache 将上游返回的完整响应写入缓存
+func (a *App) storeResponseCache(key, model, content string) {
+	if key == "" {
+		return
+	}
+	now := time.Now()
+	a.getResponseCache().put(&responseCacheEntry{
+		Key:       key,
+		Model:     model,
+		Content:   content,
+		CreatedAt: now,
+		LastUsed:  now,
+	})
+}
+
+// replayCachedStream 以 SSE 流的形式回放缓存的响应内容
+func replayCachedStream(w http.ResponseWriter, model, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, _ := w.(http.Flusher)
+	responseID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+
+	writeChunk := func(chunk OpenAIStreamResponse) {
+		w.Write([]byte("data: "))
+		json.NewEncoder(w).Encode(chunk)
+		w.Write([]byte("\n"))
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// 按固定字符数切分，保证多字节字符完整
+	const runesPerChunk = 16
+	runes := []rune(content)
+	for start := 0; start < len(runes); start += runesPerChunk {
+		end := start + runesPerChunk
+		if end > len(runes) {
+			end = len(runes)
+		}
+		writeChunk(newOpenAIStreamChunk(responseID, created, model,
+			map[string]interface{}{"content": string(runes[start:end])}, ""))
+	}
+
+	writeChunk(newOpenAIStreamChunk(responseID, created, model, map[string]interface{}{}, "stop"))
+	w.Write([]byte("data: [DONE]\n\n"))
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// newOpenAIStreamChunk 构建单个OpenAI兼容的流式响应块
+func newOpenAIStreamChunk(id string, created int64, model string, delta map[string]interface{}, finishReason string) OpenAIStreamResponse {
+	return OpenAIStreamResponse{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []struct {
+			Index        int                    `json:"index"`
+			Delta        map[string]interface{} `json:"delta"`
+			FinishReason string                 `json:"finish_reason"`
+		}{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+}
+
+// handleResponseCacheStats 返回响应缓存的命中统计
+func (a *App) handleResponseCacheStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 检查API密钥（如果设置了）
+	if keyStr := a.getConfigString("OLLAMA_OPENAI_API_KEY", ""); keyStr != "" {
+		if r.Header.Get("Authorization") != "Bearer "+keyStr {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.GetResponseCacheStats())
+}
+
+// GetResponseCacheStats 获取网关响应缓存统计
+func (a *App) GetResponseCacheStats() ResponseCacheStats {
+	stats := a.getResponseCache().stats()
+	stats.Enabled = a.getConfigBool("OLLAMA_GATEWAY_CACHE_ENABLED", false)
+	return stats
+}
+
+// ClearResponseCache 清空网关响应缓存
+func (a *App) ClearResponseCache() map[string]interface{} {
+	count := a.getResponseCache().clear()
+	log.Printf("ClearResponseCache: 已清除 %d 个缓存条目", count)
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("已清除 %d 个缓存条目", count),
+		"cleared": count,
+	}
+}
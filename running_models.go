@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 检查已加载模型变化的间隔和预加载模型的超时时间
+const (
+	runningModelsPollInterval = 5 * time.Second
+	modelPreloadTimeout       = 5 * time.Minute
+)
+
+// RunningModel 已加载到内存中的模型及其显存、内存占用
+type RunningModel struct {
+	Name         string  `json:"name"`
+	Size         int64   `json:"size"`
+	SizeText     string  `json:"size_text"`
+	SizeVRAM     int64   `json:"size_vram"`
+	SizeRAM      int64   `json:"size_ram"`
+	VRAMPercent  float64 `json:"vram_percent"`
+	ExpiresAt    string  `json:"expires_at,omitempty"`
+	ExpiresIn    int64   `json:"expires_in"`           // 距离自动卸载的秒数，-1 表示不会自动卸载
+	KeepAlive    string  `json:"keep_alive,omitempty"` // 该模型的默认 keep_alive 设置
+	ActiveChats  bool    `json:"active_chats"`         // 是否有本应用发起的生成请求正在进行
+	SizeVRAMText string  `json:"size_vram_text"`
+	SizeRAMText  string  `json:"size_ram_text"`
+}
+
+// validateKeepAlive 校验 keep_alive 的值，支持 Ollama 的时长格式（如 5m、1h）、秒数和 -1（一直保留）
+func validateKeepAlive(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("无效的 keep_alive: %s，示例: 5m、1h、-1", value)
+	}
+	return nil
+}
+
+// keepAliveRequestValue 将 keep_alive 设置转换为请求中的值，纯数字按秒数传递
+func keepAliveRequestValue(value string) interface{} {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	return value
+}
+
+// keepAliveFor 获取模型的默认 keep_alive，未设置时返回空字符串
+func (a *App) keepAliveFor(model string) string {
+	a.modelKeepAliveMutex.RLock()
+	defer a.modelKeepAliveMutex.RUnlock()
+	return a.modelKeepAlive[parseModelReference(model).String()]
+}
+
+// applyKeepAlive 为发往 /api/chat 的请求设置模型的默认 keep_alive，请求中已指定时不覆盖
+func (a *App) applyKeepAlive(body map[string]interface{}, model string) {
+	if _, ok := body["keep_alive"]; ok {
+		return
+	}
+	if keepAlive := a.keepAliveFor(model); keepAlive != "" {
+		body["keep_alive"] = keepAliveRequestValue(keepAlive)
+	}
+}
+
+// getModelKeepAlive 复制当前的 keep_alive 设置
+func (a *App) getModelKeepAlive() map[string]string {
+	a.modelKeepAliveMutex.RLock()
+	defer a.modelKeepAliveMutex.RUnlock()
+	settings := make(map[string]string, len(a.modelKeepAlive))
+	for model, keepAlive := range a.modelKeepAlive {
+		settings[model] = keepAlive
+	}
+	return settings
+}
+
+// listRunningModels 通过 /api/ps 获取已加载的模型
+func (a *App) listRunningModels() ([]RunningModel, error) {
+	loaded, err := a.fetchLoadedModels()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	models := make([]RunningModel, 0, len(loaded))
+	for _, m := range loaded {
+		model := RunningModel{
+			Name:         m.Name,
+			Size:         m.Size,
+			SizeText:     formatBytes(m.Size),
+			SizeVRAM:     m.SizeVRAM,
+			SizeRAM:      m.Size - m.SizeVRAM,
+			SizeVRAMText: formatBytes(m.SizeVRAM),
+			SizeRAMText:  formatBytes(m.Size - m.SizeVRAM),
+			ExpiresAt:    m.ExpiresAt,
+			ExpiresIn:    -1,
+			KeepAlive:    a.keepAliveFor(m.Name),
+			ActiveChats:  a.generationActive(m.Name),
+		}
+		if m.Size > 0 {
+			model.VRAMPercent = float64(m.SizeVRAM) / float64(m.Size) * 100
+		}
+		// keep_alive 为 -1 时 Ollama 返回很远的过期时间
+		if expires, err := time.Parse(time.RFC3339Nano, m.ExpiresAt); err == nil && expires.Year() < 2200 {
+			model.ExpiresIn = int64(expires.Sub(now).Seconds())
+			if model.ExpiresIn < 0 {
+				model.ExpiresIn = 0
+			}
+		}
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// preloadModel 发送不带提示词的生成请求，让 Ollama 加载模型并按 keepAlive 保留在内存中
+func (a *App) preloadModel(name, keepAlive string) error {
+	body := map[string]interface{}{"model": name}
+	if keepAlive != "" {
+		body["keep_alive"] = keepAliveRequestValue(keepAlive)
+	} else {
+		a.applyKeepAlive(body, name)
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: modelPreloadTimeout}
+	resp, err := client.Post(OllamaAPIBaseURL+"/api/generate", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("加载模型失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("加载模型失败 (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// runningModelsKey 已加载模型集合的签名，用于判断是否发生变化
+func runningModelsKey(models []RunningModel) string {
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		names = append(names, m.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// refreshRunningModels 获取已加载模型，集合发生变化或 force 为 true 时发送 running_models 事件
+func (a *App) refreshRunningModels(force bool) {
+	models, err := a.listRunningModels()
+	if err != nil {
+		return
+	}
+
+	signature := runningModelsKey(models)
+	a.runningModelsMutex.Lock()
+	changed := signature != a.runningModelsSignature
+	a.runningModelsSignature = signature
+	a.runningModelsMutex.Unlock()
+
+	if (changed || force) && a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "running_models", models)
+	}
+}
+
+// startRunningModelsWatcher 定期检查已加载的模型，模型加载或卸载时发送 running_models 事件
+func (a *App) startRunningModelsWatcher() {
+	go func() {
+		ticker := time.NewTicker(runningModelsPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.refreshRunningModels(false)
+		}
+	}()
+}
+
+// ListRunningModels 获取已加载到内存中的模型及其显存、内存占用和过期时间
+func (a *App) ListRunningModels() map[string]interface{} {
+	models, err := a.listRunningModels()
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"models":  models,
+		"message": fmt.Sprintf("已加载 %d 个模型", len(models)),
+	}
+}
+
+// PreloadModel 预加载模型到内存，keepAlive 为空时使用该模型的默认设置
+func (a *App) PreloadModel(name, keepAlive string) map[string]interface{} {
+	name = strings.TrimSpace(name)
+	keepAlive = strings.TrimSpace(keepAlive)
+	if err := validateKeepAlive(keepAlive); err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	if !a.localModelExists(name) {
+		return map[string]interface{}{
+			"success": false,
+			"code":    "not_found",
+			"message": fmt.Sprintf("模型不存在: %s", name),
+		}
+	}
+
+	log.Printf("PreloadModel: 加载模型: %s, keep_alive: %s", name, keepAlive)
+	if err := a.preloadModel(name, keepAlive); err != nil {
+		log.Printf("PreloadModel: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	a.refreshRunningModels(true)
+	return map[string]interface{}{
+		"success": true,
+		"model":   name,
+		"message": fmt.Sprintf("模型已加载: %s", name),
+	}
+}
+
+// UnloadModel 立即从内存中卸载模型
+func (a *App) UnloadModel(name string) map[string]interface{} {
+	name = strings.TrimSpace(name)
+	if a.generationActive(name) {
+		return map[string]interface{}{
+			"success": false,
+			"code":    modelOperationErrorCode(errModelInUse),
+			"message": errModelInUse.Error(),
+		}
+	}
+
+	log.Printf("UnloadModel: 卸载模型: %s", name)
+	if err := a.unloadModel(name); err != nil {
+		log.Printf("UnloadModel: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	a.refreshRunningModels(true)
+	return map[string]interface{}{
+		"success": true,
+		"model":   name,
+		"message": fmt.Sprintf("模型已卸载: %s", name),
+	}
+}
+
+// GetModelKeepAlive 获取各模型的默认 keep_alive 设置
+func (a *App) GetModelKeepAlive() map[string]string {
+	return a.getModelKeepAlive()
+}
+
+// SetModelKeepAlive 设置模型的默认 keep_alive，聊天请求未指定时使用，keepAlive 为空时删除设置
+func (a *App) SetModelKeepAlive(name, keepAlive string) map[string]interface{} {
+	keepAlive = strings.TrimSpace(keepAlive)
+	if err := validateKeepAlive(keepAlive); err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	model, err := validateModelName(name)
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+
+	a.modelKeepAliveMutex.Lock()
+	if keepAlive == "" {
+		delete(a.modelKeepAlive, model)
+	} else {
+		a.modelKeepAlive[model] = keepAlive
+	}
+	a.modelKeepAliveMutex.Unlock()
+	a.saveConfig()
+
+	message := fmt.Sprintf("已设置 %s 的 keep_alive: %s", model, keepAlive)
+	if keepAlive == "" {
+		message = fmt.Sprintf("已恢复 %s 的默认 keep_alive", model)
+	}
+	log.Printf("SetModelKeepAlive: %s", message)
+	return map[string]interface{}{
+		"success": true,
+		"model":   model,
+		"message": message,
+	}
+}
+
+// checkAdminRequest 校验网关管理接口的请求来源和 API 密钥（如果设置了），并处理预检请求
+// 与其他网关接口一样只允许白名单中的来源，避免未设置密钥时任意网页跨域调用
+func (a *App) checkAdminRequest(w http.ResponseWriter, r *http.Request, methods string) bool {
+	if !a.setCORSHeaders(w, r, methods) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return false
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return false
+	}
+	if keyStr := a.getConfigString("OLLAMA_OPENAI_API_KEY", ""); keyStr != "" {
+		if r.Header.Get("Authorization") != "Bearer "+keyStr {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return false
+		}
+	}
+	return true
+}
+
+// writeAdminResult 输出管理接口的结果，失败时返回 400
+func writeAdminResult(w http.ResponseWriter, result map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if success, _ := result["success"].(bool); !success {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAdminRunningModels GET /admin/models/running 列出已加载的模型
+func (a *App) handleAdminRunningModels(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAdminRequest(w, r, "GET, OPTIONS") {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeAdminResult(w, a.ListRunningModels())
+}
+
+// adminModelRequest 管理接口的请求体
+type adminModelRequest struct {
+	Model     string `json:"model"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// decodeAdminModelRequest 解析管理接口的 POST 请求，调用前需已通过 checkAdminRequest
+func (a *App) decodeAdminModelRequest(w http.ResponseWriter, r *http.Request) (adminModelRequest, bool) {
+	var req adminModelRequest
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return req, false
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&req); err != nil || strings.TrimSpace(req.Model) == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// handleAdminLoadModel POST /admin/models/load 预加载模型 {"model": "...", "keep_alive": "30m"}
+func (a *App) handleAdminLoadModel(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAdminRequest(w, r, "POST, OPTIONS") {
+		return
+	}
+	if req, ok := a.decodeAdminModelRequest(w, r); ok {
+		writeAdminResult(w, a.PreloadModel(req.Model, req.KeepAlive))
+	}
+}
+
+// handleAdminUnloadModel POST /admin/models/unload 卸载模型 {"model": "..."}
+func (a *App) handleAdminUnloadModel(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAdminRequest(w, r, "POST, OPTIONS") {
+		return
+	}
+	if req, ok := a.decodeAdminModelRequest(w, r); ok {
+		writeAdminResult(w, a.UnloadModel(req.Model))
+	}
+}
+
+// handleAdminKeepAlive GET 获取、POST 设置模型的默认 keep_alive {"model": "...", "keep_alive": "1h"}
+func (a *App) handleAdminKeepAlive(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAdminRequest(w, r, "GET, POST, OPTIONS") {
+		return
+	}
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a.getModelKeepAlive())
+		return
+	}
+	if req, ok := a.decodeAdminModelRequest(w, r); ok {
+		writeAdminResult(w, a.SetModelKeepAlive(req.Model, req.KeepAlive))
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 最近修改过的文件可能属于正在进行的下载或创建，清理时跳过
+const storagePruneGracePeriod = 10 * time.Minute
+
+// 完整 blob 的文件名，例如 sha256-<64位十六进制>
+var blobFileRegex = regexp.MustCompile(`^sha256-[0-9a-f]{64}$`)
+
+// 清单中的 digest，用于从无法解析的清单中找出仍被引用的 blob
+var manifestDigestRegex = regexp.MustCompile(`sha256[-:]([0-9a-f]{64})`)
+
+// StorageBlob blobs 目录中的一个文件
+type StorageBlob struct {
+	File     string   `json:"file"`
+	Digest   string   `json:"digest,omitempty"`
+	Size     int64    `json:"size"`
+	SizeText string   `json:"size_text"`
+	Modified string   `json:"modified"`
+	Models   []string `json:"models,omitempty"` // 引用该 blob 的模型
+	Recent   bool     `json:"recent,omitempty"` // 最近修改过，清理时跳过
+}
+
+// StorageModelUsage 单个模型的磁盘占用
+type StorageModelUsage struct {
+	Name          string   `json:"name"`
+	Size          int64    `json:"size"`           // 模型引用的所有 blob 的大小
+	ExclusiveSize int64    `json:"exclusive_size"` // 只被该模型引用的 blob 的大小，删除模型后可释放
+	SharedSize    int64    `json:"shared_size"`    // 与其他模型共享的 blob 的大小
+	SizeText      string   `json:"size_text"`
+	Layers        int      `json:"layers"`
+	MissingBlobs  []string `json:"missing_blobs,omitempty"` // 清单引用但磁盘上不存在的 blob
+	Invalid       bool     `json:"invalid,omitempty"`       // 清单无法解析，占用按清单中能找到的 digest 统计
+}
+
+// StorageReport 模型目录的磁盘占用报告
+type StorageReport struct {
+	ModelsDir       string              `json:"models_dir"`
+	TotalSize       int64               `json:"total_size"`
+	TotalSizeText   string              `json:"total_size_text"`
+	BlobCount       int                 `json:"blob_count"`
+	Models          []StorageModelUsage `json:"models"`
+	SharedBlobs     []StorageBlob       `json:"shared_blobs"`
+	OrphanedBlobs   []StorageBlob       `json:"orphaned_blobs"`
+	PartialBlobs    []StorageBlob       `json:"partial_blobs"`
+	Reclaimable     int64               `json:"reclaimable"` // 清理孤立和残留文件可释放的空间（不含最近修改的文件）
+	ReclaimableText string              `json:"reclaimable_text"`
+	GeneratedAt     string              `json:"generated_at"`
+}
+
+// inspectStorage 遍历模型目录，将 blob 对应到模型清单，统计磁盘占用并找出孤立和下载残留的文件
+func (a *App) inspectStorage() (*StorageReport, error) {
+	report := &StorageReport{
+		ModelsDir:   a.getOllamaModelsDir(),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	// digest -> 引用它的模型
+	references := make(map[string][]string)
+	// 模型 -> 清单引用的 digest，清单无法解析时为从原始内容中找到的 digest
+	modelDigests := make(map[string][]string)
+	invalid := make(map[string]bool)
+	err := a.walkManifests(func(name, path string, manifest *modelManifest) {
+		invalid[name] = manifest == nil
+		seen := make(map[string]bool)
+		for _, digest := range manifestReferences(path, manifest) {
+			if !seen[digest] {
+				seen[digest] = true
+				references[digest] = append(references[digest], name)
+				modelDigests[name] = append(modelDigests[name], digest)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取模型清单失败: %v", err)
+	}
+
+	entries, err := os.ReadDir(a.getBlobsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取模型数据目录失败: %v", err)
+	}
+
+	sizes := make(map[string]int64)
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		blob := StorageBlob{
+			File:     entry.Name(),
+			Size:     info.Size(),
+			SizeText: formatBytes(info.Size()),
+			Modified: info.ModTime().Format("2006-01-02 15:04:05"),
+			Recent:   now.Sub(info.ModTime()) < storagePruneGracePeriod,
+		}
+		report.TotalSize += info.Size()
+		report.BlobCount++
+
+		if !blobFileRegex.MatchString(entry.Name()) {
+			// 下载或导入中断留下的 -partial 文件和临时文件
+			report.PartialBlobs = append(report.PartialBlobs, blob)
+			if !blob.Recent {
+				report.Reclaimable += blob.Size
+			}
+			continue
+		}
+
+		blob.Digest = strings.Replace(entry.Name(), "-", ":", 1)
+		sizes[blob.Digest] = blob.Size
+		models := references[blob.Digest]
+		switch {
+		case len(models) == 0:
+			report.OrphanedBlobs = append(report.OrphanedBlobs, blob)
+			if !blob.Recent {
+				report.Reclaimable += blob.Size
+			}
+		case len(models) > 1:
+			blob.Models = models
+			sort.Strings(blob.Models)
+			report.SharedBlobs = append(report.SharedBlobs, blob)
+		}
+	}
+
+	for name, isInvalid := range invalid {
+		usage := StorageModelUsage{Name: name, Invalid: isInvalid}
+		for _, digest := range modelDigests[name] {
+			usage.Layers++
+			size, ok := sizes[digest]
+			if !ok {
+				usage.MissingBlobs = append(usage.MissingBlobs, digest)
+				continue
+			}
+			usage.Size += size
+			if len(references[digest]) > 1 {
+				usage.SharedSize += size
+			} else {
+				usage.ExclusiveSize += size
+			}
+		}
+		usage.SizeText = formatBytes(usage.Size)
+		report.Models = append(report.Models, usage)
+	}
+
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Size > report.Models[j].Size })
+	sort.Slice(report.SharedBlobs, func(i, j int) bool { return report.SharedBlobs[i].Size > report.SharedBlobs[j].Size })
+	sort.Slice(report.OrphanedBlobs, func(i, j int) bool { return report.OrphanedBlobs[i].Size > report.OrphanedBlobs[j].Size })
+	sort.Slice(report.PartialBlobs, func(i, j int) bool { return report.PartialBlobs[i].Size > report.PartialBlobs[j].Size })
+	report.TotalSizeText = formatBytes(report.TotalSize)
+	report.ReclaimableText = formatBytes(report.Reclaimable)
+	return report, nil
+}
+
+// manifestReferences 获取清单引用的 digest
+// 清单无法解析时从原始内容中查找 digest，避免把仍被使用的 blob 当作孤立文件删除
+func manifestReferences(path string, manifest *modelManifest) []string {
+	if manifest != nil {
+		return manifest.digests()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var digests []string
+	for _, m := range manifestDigestRegex.FindAllStringSubmatch(string(data), -1) {
+		digests = append(digests, "sha256:"+m[1])
+	}
+	return digests
+}
+
+// hasUnfinishedPullJobs 是否有排队、下载中或已暂停的任务，这些任务的 -partial 文件恢复下载时还要使用
+func (a *App) hasUnfinishedPullJobs() bool {
+	a.pullProcessesMutex.Lock()
+	defer a.pullProcessesMutex.Unlock()
+	for _, job := range a.pullJobs {
+		if !job.finished() {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneStorage 删除孤立的 blob 和下载残留文件，dryRun 为 true 时只返回将要删除的文件
+func (a *App) pruneStorage(dryRun bool) ([]StorageBlob, int64, error) {
+	if !dryRun && a.hasUnfinishedPullJobs() {
+		return nil, 0, fmt.Errorf("有未完成的下载任务（排队、下载中或已暂停），请等待下载完成或取消任务后再清理")
+	}
+
+	report, err := a.inspectStorage()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !dryRun {
+		// 无法从清单中找到任何 digest 时不知道它引用了哪些 blob，清理可能删除仍在使用的数据
+		for _, model := range report.Models {
+			if model.Invalid && model.Layers == 0 {
+				return nil, 0, fmt.Errorf("模型 %s 的清单已损坏，无法确定其使用的数据，请先修复或删除该模型后再清理", model.Name)
+			}
+		}
+	}
+
+	var candidates []StorageBlob
+	for _, blob := range append(report.OrphanedBlobs, report.PartialBlobs...) {
+		if !blob.Recent {
+			candidates = append(candidates, blob)
+		}
+	}
+	if dryRun {
+		return candidates, report.Reclaimable, nil
+	}
+
+	var removed []StorageBlob
+	var reclaimed int64
+	var failed []string
+	for _, blob := range candidates {
+		if err := os.Remove(filepath.Join(a.getBlobsDir(), blob.File)); err != nil {
+			log.Printf("pruneStorage: 删除失败: %s, %v", blob.File, err)
+			failed = append(failed, blob.File)
+			continue
+		}
+		removed = append(removed, blob)
+		reclaimed += blob.Size
+	}
+	log.Printf("pruneStorage: 已删除 %d 个文件，释放空间 %s", len(removed), formatBytes(reclaimed))
+	if len(failed) > 0 {
+		return removed, reclaimed, fmt.Errorf("%d 个文件删除失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return removed, reclaimed, nil
+}
+
+// GetStorageReport 获取模型目录的磁盘占用报告
+func (a *App) GetStorageReport() map[string]interface{} {
+	report, err := a.inspectStorage()
+	if err != nil {
+		log.Printf("GetStorageReport: %v", err)
+		return map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"message": fmt.Sprintf("共 %d 个模型，占用 %s，可清理 %s", len(report.Models), report.TotalSizeText, report.ReclaimableText),
+	}
+}
+
+// PruneStorage 清理孤立的 blob 和下载残留文件，dryRun 为 true 时只列出将要删除的文件，确认后再以 false 调用
+func (a *App) PruneStorage(dryRun bool) map[string]interface{} {
+	files, size, err := a.pruneStorage(dryRun)
+	result := map[string]interface{}{
+		"success":         err == nil,
+		"dry_run":         dryRun,
+		"files":           files,
+		"reclaimed_bytes": size,
+		"reclaimed_text":  formatBytes(size),
+	}
+	switch {
+	case err != nil:
+		result["message"] = fmt.Sprintf("清理失败: %v", err)
+	case dryRun:
+		result["message"] = fmt.Sprintf("将删除 %d 个文件，释放空间 %s", len(files), formatBytes(size))
+	default:
+		result["message"] = fmt.Sprintf("已删除 %d 个文件，释放空间 %s", len(files), formatBytes(size))
+	}
+	return result
+}